@@ -4,17 +4,24 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"slices"
 	"strings"
+	"time"
 )
 
+var errNoBrokerUrl = errors.New("pinot/http: No broker url configured")
+
 type BrokerResponse struct {
-	ResultTable *ResultTable      `json:"resultTable,omitempty"`
-	TraceInfo   map[string]string `json:"traceInfo,omitempty"`
-	Exceptions  []BrokerException `json:"exceptions"`
+	RequestId       string            `json:"requestId,omitempty"`
+	ClientRequestId string            `json:"clientRequestId,omitempty"`
+	ResultTable     *ResultTable      `json:"resultTable,omitempty"`
+	TraceInfo       map[string]string `json:"traceInfo,omitempty"`
+	Exceptions      []BrokerException `json:"exceptions"`
 
 	NumSegmentsProcessed        int64 `json:"numSegmentsProcessed"`
 	NumServersResponded         int64 `json:"numServersResponded"`
@@ -28,12 +35,19 @@ type BrokerResponse struct {
 	TotalDocs                   int64 `json:"totalDocs"`
 	TimeUsedMs                  int64 `json:"timeUsedMs"`
 	MinConsumingFreshnessTimeMs int64 `json:"minConsumingFreshnessTimeMs"`
+
+	// CachedAt is when the response was cached, or zero if the response was not served from the result cache.
+	CachedAt time.Time `json:"-"`
 }
 
 func (x *BrokerResponse) HasExceptions() bool {
 	return len(x.Exceptions) > 0
 }
 
+func (x *BrokerResponse) IsCached() bool {
+	return !x.CachedAt.IsZero()
+}
+
 func (x *BrokerResponse) HasData() bool {
 	return x.ResultTable != nil && x.ResultTable.RowCount() > 0
 }
@@ -74,13 +88,20 @@ func (e *BrokerExceptionError) Error() string {
 	for i, exception := range e.Exceptions {
 		messages[i] = fmt.Sprintf("Code %d: %s", exception.ErrorCode, exception.Message)
 	}
-	return "Broker request completed with exceptions:\n" + strings.Join(messages, "\n")
+	msg := "Broker request completed with exceptions:\n" + strings.Join(messages, "\n")
+	if kind := e.Kind(); kind != BrokerErrorKindUnknown {
+		return kind.Message() + "\n" + msg
+	}
+	return msg
 }
 
 type SqlQuery struct {
-	Sql          string
-	Trace        bool
-	QueryOptions []QueryOption
+	Sql                 string
+	TableName           string
+	Trace               bool
+	UseMultistageEngine bool
+	SkipResultCache     bool
+	QueryOptions        []QueryOption
 }
 
 func NewSqlQuery(sql string) SqlQuery {
@@ -107,47 +128,228 @@ func (query SqlQuery) RenderSql() string {
 	return builder.String()
 }
 
+// requestQueryOptions returns the options sent in the request body instead of being rendered as SET statements.
+func (query SqlQuery) requestQueryOptions(clientQueryId string) string {
+	var options []string
+	if query.UseMultistageEngine {
+		options = append(options, "useMultistageEngine=true")
+	}
+	if clientQueryId != "" {
+		options = append(options, "clientQueryId="+clientQueryId)
+	}
+	return strings.Join(options, ";")
+}
+
 func (query SqlQuery) cacheKey() string {
 	return fmt.Sprintf("%v", query)
 }
 
 // RenderSql renders the actual SQL query string sent to Pinot.
-// The rendered query includes all query options provided in the client properties and query.
+// The rendered query includes the query options of the query, followed by the options of the client properties
+// that the query does not override. Each option is set once.
 func (p *Client) RenderSql(query SqlQuery) string {
-	query.QueryOptions = slices.Concat(query.QueryOptions, p.properties.QueryOptions)
+	query.QueryOptions = p.queryOptionsOf(query.QueryOptions)
 	return query.RenderSql()
 }
 
+// ExecuteSqlQuery sends the query to a broker, unless the result is cached.
+// Concurrent executions of the same query share one broker request, along with the deadline and timeout
+// of the execution that started it. A caller with a later deadline can therefore time out early.
+// Queries that the client's query policy does not allow are rejected with an error that wraps ErrQueryPolicy.
+// Known query options with invalid values are rejected with an error that wraps ErrInvalidQueryOption.
 func (p *Client) ExecuteSqlQuery(ctx context.Context, query SqlQuery) (*BrokerResponse, error) {
+	if err := ValidateQueryOptions(query.QueryOptions); err != nil {
+		return nil, err
+	}
+	if err := p.checkQueryPolicy(ctx, query); err != nil {
+		return nil, err
+	}
+	resp, err := p.doSqlQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.checkDocsScannedOf(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// doSqlQuery executes the query without checking the query policy.
+func (p *Client) doSqlQuery(ctx context.Context, query SqlQuery) (*BrokerResponse, error) {
+	// A cached trace would not describe the current execution of the query.
+	useCache := p.queryResultCache != nil && !query.SkipResultCache && !query.Trace
+	cacheKey := p.cacheKeyOf(query.cacheKey())
+	if useCache {
+		if cached, cachedAt, ok := p.queryResultCache.get(cacheKey); ok {
+			var respData BrokerResponse
+			if err := decodeJson(bytes.NewReader(cached), &respData); err != nil {
+				return nil, err
+			}
+			p.logger.Info("pinot/http: Using cached sql query result.", "queryString", p.RenderSql(query), "cachedAt", cachedAt)
+			respData.CachedAt = cachedAt
+			return &respData, nil
+		}
+	}
+
+	// Timeouts derived from the deadline of the context are not part of the flight key, so that concurrent callers share a request.
+	// Explicit timeoutMs options are part of the query, and so of the key.
+	timedQuery, timeout := p.withQueryTimeout(ctx, query)
+	respBody, err := p.sqlQueryFlights.do(ctx, cacheKey, func(ctx context.Context) ([]byte, error) {
+		return p.executeSqlQuery(ctx, timedQuery, timeout)
+	})
+	if err != nil {
+		return nil, newQueryTimeoutError(ctx, err, timeout)
+	}
+
+	// Each caller decodes its own copy of the response, since callers may share the response body.
+	var respData BrokerResponse
+	if err = decodeJson(bytes.NewReader(respBody), &respData); err != nil {
+		return nil, err
+	}
+	// Partial results are not cached, so the next request has a chance to get the full result.
+	// Results that scanned more docs than the query policy allows are rejected by ExecuteSqlQuery, so they are not cached either.
+	if useCache && !respData.HasExceptions() && p.checkDocsScannedOf(&respData) == nil {
+		p.queryResultCache.put(cacheKey, respBody)
+	}
+	return &respData, nil
+}
+
+// executeSqlQuery sends the query to a broker and returns the response body.
+// If the context ends while the broker is executing the query, the query is canceled on the broker.
+// When the query has a timeout, the request is abandoned once the broker had time to report the timeout.
+func (p *Client) executeSqlQuery(ctx context.Context, query SqlQuery, timeout time.Duration) ([]byte, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout+QueryTimeoutMargin)
+		defer cancel()
+	}
+
+	clientQueryId := newClientQueryId()
+	body, err := p.newSqlQueryRequestBody(query, clientQueryId)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("pinot/http: Executing sql query.", "queryString", p.RenderSql(query), "clientQueryId", clientQueryId)
+
+	var queryBrokerUrl string
+	resp, err := p.doBrokerRequest(ctx, query.TableName, func(brokerUrl string) (*http.Request, error) {
+		queryBrokerUrl = brokerUrl
+		return p.newBrokerPostRequest(ctx, brokerUrl, "/query/sql", bytes.NewReader(body))
+	})
+	if err != nil {
+		var urlErr *url.Error
+		if ctx.Err() != nil && errors.As(err, &urlErr) {
+			// The request was aborted while the broker was executing the query.
+			go p.cancelQuery(ctx, queryBrokerUrl, clientQueryId)
+		}
+		return nil, err
+	}
+	return p.readResponseBody(ctx, resp)
+}
+
+func (p *Client) newSqlQueryRequestBody(query SqlQuery, clientQueryId string) ([]byte, error) {
 	request := struct {
-		Sql   string `json:"sql"`
-		Trace bool   `json:"trace,omitempty"`
+		Sql          string `json:"sql"`
+		Trace        bool   `json:"trace,omitempty"`
+		QueryOptions string `json:"queryOptions,omitempty"`
 	}{
-		Sql:   p.RenderSql(query),
-		Trace: query.Trace,
+		Sql:          p.RenderSql(query),
+		Trace:        query.Trace,
+		QueryOptions: query.requestQueryOptions(clientQueryId),
 	}
 
 	var body bytes.Buffer
 	if err := json.NewEncoder(&body).Encode(request); err != nil {
 		return nil, err
 	}
+	return body.Bytes(), nil
+}
 
-	req, err := p.newBrokerPostRequest(ctx, "/query/sql", &body)
+// BrokerStates returns the last known state of each configured broker.
+func (p *Client) BrokerStates() []BrokerState {
+	return p.brokers.states()
+}
+
+// CheckBrokers refreshes the discovered brokers, sends a test query to every broker and returns the updated broker states.
+func (p *Client) CheckBrokers(ctx context.Context) []BrokerState {
+	body, err := p.newSqlQueryRequestBody(NewSqlQuery("SELECT 1"), "")
 	if err != nil {
-		return nil, err
+		// Realistically, this should never throw an error.
+		return p.brokers.states()
 	}
 
-	p.logger.Info("pinot/http: Executing sql query.", "queryString", request.Sql)
+	p.refreshBrokerDiscovery(ctx)
+	for _, brokerUrl := range p.brokers.urls() {
+		req, err := p.newBrokerPostRequest(ctx, brokerUrl, "/query/sql", bytes.NewReader(body))
+		if err != nil {
+			continue
+		}
 
-	var respData BrokerResponse
-	if err = p.doRequestAndDecodeResponse(req, &respData); err != nil {
-		return nil, err
+		var respData BrokerResponse
+		err = p.doRequestAndDecodeResponse(req, &respData)
+		switch {
+		case ctx.Err() != nil:
+			return p.brokers.states()
+		case err != nil:
+			p.brokers.markDown(brokerUrl, err)
+		default:
+			p.brokers.markUp(brokerUrl)
+		}
 	}
-	return &respData, nil
+	return p.brokers.states()
+}
+
+// doBrokerRequest sends a request to the next available broker serving the table.
+// When a broker is unreachable or unavailable, it is marked down and the request is sent to the next broker.
+// The request is retried with backoff when every broker fails with a transient error.
+func (p *Client) doBrokerRequest(ctx context.Context, table string, newRequest func(brokerUrl string) (*http.Request, error)) (*http.Response, error) {
+	tableBrokerUrls := p.brokerUrlsFor(ctx, table)
+	return p.doWithRetries(ctx, retryTargetBroker, func() (*http.Response, error) {
+		candidates := p.brokers.candidates()
+		if len(tableBrokerUrls) > 0 {
+			candidates = slices.DeleteFunc(candidates, func(brokerUrl string) bool {
+				return !slices.Contains(tableBrokerUrls, brokerUrl)
+			})
+		}
+		return p.doBrokerRequestWithFailover(ctx, candidates, newRequest)
+	})
+}
+
+func (p *Client) doBrokerRequestWithFailover(ctx context.Context, candidates []string, newRequest func(brokerUrl string) (*http.Request, error)) (*http.Response, error) {
+	var lastErr error
+	for _, brokerUrl := range candidates {
+		req, err := newRequest(brokerUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := p.doRequest(req)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return nil, err
+		case err != nil:
+			lastErr = err
+		case isBrokerUnavailableStatus(resp.StatusCode):
+			lastErr = p.newErrorFromResponseBody(ctx, resp)
+			p.closeResponseBody(ctx, resp)
+		default:
+			p.brokers.markUp(brokerUrl)
+			return resp, nil
+		}
+
+		p.logger.Error("pinot/http: Broker request failed.", "broker", brokerUrl, "error", lastErr)
+		p.brokers.markDown(brokerUrl, lastErr)
+	}
+
+	if lastErr == nil {
+		return nil, errNoBrokerUrl
+	}
+	return nil, lastErr
 }
 
-func (p *Client) newBrokerPostRequest(ctx context.Context, endpoint string, body io.Reader) (*http.Request, error) {
-	req, err := p.newRequest(ctx, http.MethodPost, p.properties.BrokerUrl+endpoint, body)
+func (p *Client) newBrokerPostRequest(ctx context.Context, brokerUrl string, endpoint string, body io.Reader) (*http.Request, error) {
+	req, err := p.newRequest(ctx, http.MethodPost, brokerUrl+endpoint, body)
 	if err != nil {
 		return nil, err
 	}
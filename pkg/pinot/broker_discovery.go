@@ -0,0 +1,146 @@
+package pinot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// BrokerDiscoveryInterval is how often the table to broker mapping is refreshed from the controller.
+const BrokerDiscoveryInterval = time.Minute
+
+// BrokerDiscoveryRetryInterval is how long broker discovery waits after a failed refresh before it tries again.
+const BrokerDiscoveryRetryInterval = 10 * time.Second
+
+type BrokerInstance struct {
+	InstanceName string `json:"instanceName"`
+	Host         string `json:"host"`
+	Port         int    `json:"port"`
+}
+
+// ListTableBrokers returns the brokers serving each table.
+func (p *Client) ListTableBrokers(ctx context.Context) (map[string][]BrokerInstance, error) {
+	req, err := p.newControllerGetRequest(ctx, "/v2/brokers/tables")
+	if err != nil {
+		return nil, err
+	}
+
+	var tableBrokers map[string][]BrokerInstance
+	if err = p.doRequestAndDecodeResponse(req, &tableBrokers); err != nil {
+		return nil, err
+	}
+	return tableBrokers, nil
+}
+
+// brokerDiscovery caches the table to broker url mapping discovered from the controller.
+// Stale mappings are served while a refresh runs in the background.
+// Concurrent refreshes share one controller request, and refreshes are paused for a while after one fails.
+type brokerDiscovery struct {
+	mu           sync.Mutex
+	tableBrokers map[string][]string
+	refreshedAt  time.Time
+	failedAt     time.Time
+	refreshing   bool
+	refreshes    *flightGroup[struct{}]
+	now          func() time.Time
+}
+
+func newBrokerDiscovery() *brokerDiscovery {
+	return &brokerDiscovery{refreshes: newFlightGroup[struct{}](), now: time.Now}
+}
+
+// refreshDue returns true if the mapping is stale and the last refresh did not fail recently.
+func (x *brokerDiscovery) refreshDue() bool {
+	now := x.now()
+	return now.Sub(x.refreshedAt) >= BrokerDiscoveryInterval && now.Sub(x.failedAt) >= BrokerDiscoveryRetryInterval
+}
+
+// brokerUrlsFor returns the discovered broker urls serving the table.
+// Returns nil if broker discovery is disabled or the table is unknown, in which case any broker may be used.
+func (p *Client) brokerUrlsFor(ctx context.Context, table string) []string {
+	if p.discovery == nil {
+		return nil
+	}
+
+	p.discovery.mu.Lock()
+	loaded := p.discovery.tableBrokers != nil
+	due := p.discovery.refreshDue()
+	startRefresh := loaded && due && !p.discovery.refreshing
+	if startRefresh {
+		p.discovery.refreshing = true
+	}
+	p.discovery.mu.Unlock()
+
+	if !loaded && due {
+		p.refreshBrokerDiscovery(ctx)
+	} else if startRefresh {
+		go func() {
+			refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), BrokerDiscoveryInterval)
+			defer cancel()
+			p.refreshBrokerDiscovery(refreshCtx)
+		}()
+	}
+
+	if table == "" {
+		return nil
+	}
+
+	p.discovery.mu.Lock()
+	defer p.discovery.mu.Unlock()
+	// The controller lists the tables of other databases than the default one with the database prefix.
+	if database := p.properties.DatabaseName; database != "" && database != DefaultDatabase {
+		if urls, ok := p.discovery.tableBrokers[fmt.Sprintf("%s.%s", database, table)]; ok {
+			return urls
+		}
+	}
+	return p.discovery.tableBrokers[table]
+}
+
+// refreshBrokerDiscovery reloads the table to broker mapping from the controller, if broker discovery is enabled.
+// Concurrent callers share one refresh.
+func (p *Client) refreshBrokerDiscovery(ctx context.Context) {
+	if p.discovery == nil {
+		return
+	}
+	_, _ = p.discovery.refreshes.do(ctx, "", func(ctx context.Context) (struct{}, error) {
+		p.loadBrokerDiscovery(ctx)
+		return struct{}{}, nil
+	})
+}
+
+func (p *Client) loadBrokerDiscovery(ctx context.Context) {
+	tableBrokers, err := p.ListTableBrokers(ctx)
+
+	p.discovery.mu.Lock()
+	defer p.discovery.mu.Unlock()
+	p.discovery.refreshing = false
+	if err != nil {
+		p.logger.Error("pinot/http: Failed to discover brokers.", "error", err)
+		p.discovery.failedAt = p.discovery.now()
+		return
+	}
+
+	scheme := "http"
+	if controllerUrl, err := url.Parse(p.properties.ControllerUrl); err == nil && controllerUrl.Scheme != "" {
+		scheme = controllerUrl.Scheme
+	}
+
+	discovered := make(map[string][]string, len(tableBrokers))
+	var allUrls []string
+	for table, instances := range tableBrokers {
+		urls := make([]string, 0, len(instances))
+		for _, instance := range instances {
+			urls = append(urls, fmt.Sprintf("%s://%s:%d", scheme, instance.Host, instance.Port))
+		}
+		discovered[table] = urls
+		allUrls = append(allUrls, urls...)
+	}
+
+	slices.Sort(allUrls)
+	p.brokers.setDiscovered(allUrls)
+	p.discovery.tableBrokers = discovered
+	p.discovery.refreshedAt = p.discovery.now()
+}
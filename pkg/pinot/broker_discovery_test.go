@@ -0,0 +1,157 @@
+package pinot
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPinotClient_BrokerDiscovery(t *testing.T) {
+	newBroker := func(requests *atomic.Int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			_, _ = w.Write([]byte(`{"exceptions":[]}`))
+		}))
+	}
+
+	var requestsA, requestsB atomic.Int32
+	brokerA := newBroker(&requestsA)
+	defer brokerA.Close()
+	brokerB := newBroker(&requestsB)
+	defer brokerB.Close()
+
+	instanceOf := func(server *httptest.Server) string {
+		serverUrl, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		port, err := strconv.Atoi(serverUrl.Port())
+		require.NoError(t, err)
+		return fmt.Sprintf(`{"instanceName":"Broker_%s","host":"%s","port":%d}`, serverUrl.Port(), serverUrl.Hostname(), port)
+	}
+
+	var discoveryRequests atomic.Int32
+	controller := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/brokers/tables", r.URL.Path)
+		discoveryRequests.Add(1)
+		_, _ = fmt.Fprintf(w, `{"tableA":[%s],"tableB":[%s]}`, instanceOf(brokerA), instanceOf(brokerB))
+	}))
+	defer controller.Close()
+
+	client := NewPinotClient(http.DefaultClient, ClientProperties{
+		ControllerUrl:   controller.URL,
+		BrokerDiscovery: true,
+	})
+
+	t.Run("routes to table brokers", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			_, err := client.ExecuteSqlQuery(context.Background(), SqlQuery{Sql: "SELECT 1", TableName: "tableA"})
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), requestsA.Load())
+		assert.Equal(t, int32(0), requestsB.Load())
+
+		_, err := client.ExecuteSqlQuery(context.Background(), SqlQuery{Sql: "SELECT 1", TableName: "tableB"})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), requestsB.Load())
+		assert.Equal(t, int32(1), discoveryRequests.Load())
+	})
+
+	t.Run("unknown table", func(t *testing.T) {
+		_, err := client.ExecuteSqlQuery(context.Background(), SqlQuery{Sql: "SELECT 1", TableName: "tableC"})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(5), requestsA.Load()+requestsB.Load())
+	})
+
+	t.Run("refreshes stale brokers in background", func(t *testing.T) {
+		client.discovery.now = func() time.Time { return time.Now().Add(BrokerDiscoveryInterval) }
+		_, err := client.ExecuteSqlQuery(context.Background(), SqlQuery{Sql: "SELECT 1", TableName: "tableA"})
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool { return discoveryRequests.Load() == 2 }, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestPinotClient_BrokerDiscovery_ControllerDown(t *testing.T) {
+	var brokerRequests atomic.Int32
+	broker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		brokerRequests.Add(1)
+		_, _ = w.Write([]byte(`{"exceptions":[]}`))
+	}))
+	defer broker.Close()
+
+	var discoveryRequests atomic.Int32
+	release := make(chan struct{})
+	controller := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		discoveryRequests.Add(1)
+		<-release
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer controller.Close()
+
+	client := NewPinotClient(http.DefaultClient, ClientProperties{
+		ControllerUrl:   controller.URL,
+		BrokerUrl:       broker.URL,
+		BrokerDiscovery: true,
+	})
+	now := time.Now()
+	client.discovery.now = func() time.Time { return now }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.ExecuteSqlQuery(context.Background(), SqlQuery{Sql: fmt.Sprintf("SELECT %d", i), TableName: "tableA"})
+			assert.NoError(t, err)
+		}()
+	}
+	assert.Eventually(t, func() bool { return discoveryRequests.Load() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), discoveryRequests.Load())
+	assert.Equal(t, int32(5), brokerRequests.Load())
+
+	// Queries use any broker until the controller is tried again.
+	_, err := client.ExecuteSqlQuery(context.Background(), SqlQuery{Sql: "SELECT 5", TableName: "tableA"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), discoveryRequests.Load())
+
+	now = now.Add(BrokerDiscoveryRetryInterval)
+	_, err = client.ExecuteSqlQuery(context.Background(), SqlQuery{Sql: "SELECT 6", TableName: "tableA"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), discoveryRequests.Load())
+}
+
+func TestPinotClient_BrokerUrlsFor(t *testing.T) {
+	client := NewPinotClient(http.DefaultClient, ClientProperties{BrokerDiscovery: true})
+	client.discovery.tableBrokers = map[string][]string{
+		"tableA":           {"http://broker-a"},
+		"analytics.tableA": {"http://broker-b"},
+		".tableB":          {"http://broker-c"},
+	}
+	client.discovery.refreshedAt = time.Now()
+
+	testCases := []struct {
+		database string
+		table    string
+		want     []string
+	}{
+		{database: "", table: "tableA", want: []string{"http://broker-a"}},
+		{database: DefaultDatabase, table: "tableA", want: []string{"http://broker-a"}},
+		{database: "analytics", table: "tableA", want: []string{"http://broker-b"}},
+		{database: "", table: "tableB", want: nil},
+		{database: DefaultDatabase, table: "tableB", want: nil},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.database+"/"+tt.table, func(t *testing.T) {
+			assert.Equal(t, tt.want, client.WithDatabase(tt.database).brokerUrlsFor(context.Background(), tt.table))
+		})
+	}
+}
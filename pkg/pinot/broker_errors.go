@@ -0,0 +1,93 @@
+package pinot
+
+// BrokerErrorKind classifies broker exceptions by their error code.
+// Each kind is also an error, so that errors.Is(err, BrokerErrorKindQueryTimeout) matches a *BrokerExceptionError with a timeout exception.
+type BrokerErrorKind string
+
+const (
+	BrokerErrorKindQueryTimeout        BrokerErrorKind = "query_timeout"
+	BrokerErrorKindTableNotFound       BrokerErrorKind = "table_not_found"
+	BrokerErrorKindColumnNotFound      BrokerErrorKind = "column_not_found"
+	BrokerErrorKindSqlParse            BrokerErrorKind = "sql_parse"
+	BrokerErrorKindQuotaExceeded       BrokerErrorKind = "quota_exceeded"
+	BrokerErrorKindServerNotResponding BrokerErrorKind = "server_not_responding"
+	BrokerErrorKindUnknown             BrokerErrorKind = "unknown"
+)
+
+// Kinds caused by the query come first, since retrying will not fix them.
+var brokerErrorKindPriority = []BrokerErrorKind{
+	BrokerErrorKindSqlParse,
+	BrokerErrorKindTableNotFound,
+	BrokerErrorKindColumnNotFound,
+	BrokerErrorKindQueryTimeout,
+	BrokerErrorKindQuotaExceeded,
+	BrokerErrorKindServerNotResponding,
+}
+
+func (x BrokerErrorKind) String() string { return string(x) }
+
+func (x BrokerErrorKind) Error() string { return x.Message() }
+
+// Message returns a user-facing description of the error and how to fix it.
+func (x BrokerErrorKind) Message() string {
+	switch x {
+	case BrokerErrorKindQueryTimeout:
+		return "The query timed out. Narrow the time range, add filters, or increase the timeoutMs query option."
+	case BrokerErrorKindTableNotFound:
+		return "The table does not exist. Check the table name and the database of the data source."
+	case BrokerErrorKindColumnNotFound:
+		return "The query references a column that does not exist. Check the column names against the table schema."
+	case BrokerErrorKindSqlParse:
+		return "The query is not valid. Check the SQL syntax."
+	case BrokerErrorKindQuotaExceeded:
+		return "The query exceeded a quota or rate limit of the Pinot cluster. Try again later or reduce the number of queries."
+	case BrokerErrorKindServerNotResponding:
+		return "Pinot servers did not respond. The cluster may be unavailable or overloaded."
+	default:
+		return "The query failed."
+	}
+}
+
+// Kind returns the kind of the exception, based on the error codes of Pinot's QueryException.
+func (x BrokerException) Kind() BrokerErrorKind {
+	switch x.ErrorCode {
+	case 240, 250, 400:
+		return BrokerErrorKindQueryTimeout
+	case 190, 410:
+		return BrokerErrorKindTableNotFound
+	case 710:
+		return BrokerErrorKindColumnNotFound
+	case 150, 700:
+		return BrokerErrorKindSqlParse
+	case 211, 245, 429:
+		return BrokerErrorKindQuotaExceeded
+	case 210, 305, 420, 425, 427:
+		return BrokerErrorKindServerNotResponding
+	default:
+		return BrokerErrorKindUnknown
+	}
+}
+
+// Kind returns the kind of the most relevant exception.
+func (e *BrokerExceptionError) Kind() BrokerErrorKind {
+	for _, kind := range brokerErrorKindPriority {
+		if e.hasKind(kind) {
+			return kind
+		}
+	}
+	return BrokerErrorKindUnknown
+}
+
+func (e *BrokerExceptionError) Is(target error) bool {
+	kind, ok := target.(BrokerErrorKind)
+	return ok && e.hasKind(kind)
+}
+
+func (e *BrokerExceptionError) hasKind(kind BrokerErrorKind) bool {
+	for _, exception := range e.Exceptions {
+		if exception.Kind() == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package pinot
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBrokerException_Kind(t *testing.T) {
+	testCases := []struct {
+		code int
+		want BrokerErrorKind
+	}{
+		{code: 150, want: BrokerErrorKindSqlParse},
+		{code: 190, want: BrokerErrorKindTableNotFound},
+		{code: 250, want: BrokerErrorKindQueryTimeout},
+		{code: 305, want: BrokerErrorKindServerNotResponding},
+		{code: 400, want: BrokerErrorKindQueryTimeout},
+		{code: 427, want: BrokerErrorKindServerNotResponding},
+		{code: 429, want: BrokerErrorKindQuotaExceeded},
+		{code: 710, want: BrokerErrorKindColumnNotFound},
+		{code: 1, want: BrokerErrorKindUnknown},
+	}
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("code=%d", tt.code), func(t *testing.T) {
+			assert.Equal(t, tt.want, BrokerException{ErrorCode: tt.code}.Kind())
+		})
+	}
+}
+
+func TestBrokerExceptionError_Kind(t *testing.T) {
+	t.Run("query errors first", func(t *testing.T) {
+		err := NewBrokerExceptionError([]BrokerException{{ErrorCode: 427}, {ErrorCode: 710}})
+		assert.Equal(t, BrokerErrorKindColumnNotFound, err.Kind())
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		err := NewBrokerExceptionError([]BrokerException{{ErrorCode: 1}})
+		assert.Equal(t, BrokerErrorKindUnknown, err.Kind())
+	})
+}
+
+func TestBrokerExceptionError_Is(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", NewBrokerExceptionError([]BrokerException{{ErrorCode: 427}, {ErrorCode: 250}}))
+	assert.True(t, errors.Is(err, BrokerErrorKindServerNotResponding))
+	assert.True(t, errors.Is(err, BrokerErrorKindQueryTimeout))
+	assert.False(t, errors.Is(err, BrokerErrorKindTableNotFound))
+}
+
+func TestBrokerExceptionError_Error_WithKind(t *testing.T) {
+	err := NewBrokerExceptionError([]BrokerException{{Message: "Timed out", ErrorCode: 250}})
+	assert.Equal(t, BrokerErrorKindQueryTimeout.Message()+"\nBroker request completed with exceptions:\nCode 250: Timed out", err.Error())
+}
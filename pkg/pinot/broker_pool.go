@@ -0,0 +1,154 @@
+package pinot
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// BrokerDownDuration is how long a broker is skipped after a failed request.
+const BrokerDownDuration = 30 * time.Second
+
+type BrokerState struct {
+	Url       string    `json:"url"`
+	Healthy   bool      `json:"healthy"`
+	Failures  int       `json:"failures"`
+	DownUntil time.Time `json:"downUntil,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// brokerPool tracks the health of the configured brokers.
+// Requests are spread across healthy brokers round-robin.
+// Brokers that fail are marked down for BrokerDownDuration and only used when no healthy broker remains.
+type brokerPool struct {
+	mu         sync.Mutex
+	configured []string
+	brokers    []*BrokerState
+	next       int
+	now        func() time.Time
+}
+
+func newBrokerPool(urls []string) *brokerPool {
+	pool := brokerPool{configured: urls, now: time.Now}
+	pool.setDiscovered(nil)
+	return &pool
+}
+
+// setDiscovered replaces the discovered brokers while keeping the configured brokers.
+// The state of brokers that remain in the pool is preserved.
+func (x *brokerPool) setDiscovered(urls []string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	existing := make(map[string]*BrokerState, len(x.brokers))
+	for _, broker := range x.brokers {
+		existing[broker.Url] = broker
+	}
+
+	seen := make(map[string]bool)
+	brokers := make([]*BrokerState, 0, len(x.configured)+len(urls))
+	for _, url := range slices.Concat(x.configured, urls) {
+		if url == "" || seen[url] {
+			continue
+		}
+		seen[url] = true
+		if broker, ok := existing[url]; ok {
+			brokers = append(brokers, broker)
+		} else {
+			brokers = append(brokers, &BrokerState{Url: url, Healthy: true})
+		}
+	}
+	x.brokers = brokers
+}
+
+// candidates returns the broker urls in the order they should be tried.
+func (x *brokerPool) candidates() []string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	now := x.now()
+	var healthy, down []*BrokerState
+	for i := range x.brokers {
+		broker := x.brokers[(x.next+i)%len(x.brokers)]
+		if broker.DownUntil.After(now) {
+			down = append(down, broker)
+		} else {
+			healthy = append(healthy, broker)
+		}
+	}
+	if len(x.brokers) > 0 {
+		x.next = (x.next + 1) % len(x.brokers)
+	}
+
+	// Try the brokers that come back up soonest first.
+	slices.SortStableFunc(down, func(a, b *BrokerState) int { return a.DownUntil.Compare(b.DownUntil) })
+
+	urls := make([]string, 0, len(x.brokers))
+	for _, broker := range append(healthy, down...) {
+		urls = append(urls, broker.Url)
+	}
+	return urls
+}
+
+func (x *brokerPool) urls() []string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	urls := make([]string, len(x.brokers))
+	for i, broker := range x.brokers {
+		urls[i] = broker.Url
+	}
+	return urls
+}
+
+func (x *brokerPool) markUp(url string) {
+	x.update(url, func(broker *BrokerState) {
+		broker.Failures = 0
+		broker.DownUntil = time.Time{}
+		broker.LastError = ""
+	})
+}
+
+func (x *brokerPool) markDown(url string, err error) {
+	x.update(url, func(broker *BrokerState) {
+		broker.Failures++
+		broker.DownUntil = x.now().Add(BrokerDownDuration)
+		broker.LastError = err.Error()
+	})
+}
+
+func (x *brokerPool) update(url string, fn func(broker *BrokerState)) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for _, broker := range x.brokers {
+		if broker.Url == url {
+			fn(broker)
+		}
+	}
+}
+
+func (x *brokerPool) states() []BrokerState {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	now := x.now()
+	states := make([]BrokerState, len(x.brokers))
+	for i, broker := range x.brokers {
+		states[i] = *broker
+		states[i].Healthy = !broker.DownUntil.After(now)
+	}
+	return states
+}
+
+// isBrokerUnavailableStatus returns true if the status code indicates the broker itself cannot serve requests,
+// as opposed to the request being invalid.
+func isBrokerUnavailableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,111 @@
+package pinot
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBrokerPool_Candidates(t *testing.T) {
+	now := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	newPool := func() *brokerPool {
+		pool := newBrokerPool([]string{"http://broker-0", "http://broker-1", "", "http://broker-2", "http://broker-0"})
+		pool.now = func() time.Time { return now }
+		return pool
+	}
+
+	t.Run("round robin", func(t *testing.T) {
+		pool := newPool()
+		assert.Equal(t, []string{"http://broker-0", "http://broker-1", "http://broker-2"}, pool.candidates())
+		assert.Equal(t, []string{"http://broker-1", "http://broker-2", "http://broker-0"}, pool.candidates())
+		assert.Equal(t, []string{"http://broker-2", "http://broker-0", "http://broker-1"}, pool.candidates())
+		assert.Equal(t, []string{"http://broker-0", "http://broker-1", "http://broker-2"}, pool.candidates())
+	})
+
+	t.Run("down brokers last", func(t *testing.T) {
+		pool := newPool()
+		pool.markDown("http://broker-0", errors.New("connection refused"))
+		assert.Equal(t, []string{"http://broker-1", "http://broker-2", "http://broker-0"}, pool.candidates())
+	})
+
+	t.Run("down brokers recover", func(t *testing.T) {
+		pool := newPool()
+		pool.markDown("http://broker-0", errors.New("connection refused"))
+		pool.now = func() time.Time { return now.Add(BrokerDownDuration) }
+		assert.Equal(t, []string{"http://broker-0", "http://broker-1", "http://broker-2"}, pool.candidates())
+	})
+
+	t.Run("no brokers", func(t *testing.T) {
+		assert.Empty(t, newBrokerPool(nil).candidates())
+	})
+}
+
+func TestBrokerPool_States(t *testing.T) {
+	now := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	pool := newBrokerPool([]string{"http://broker-0", "http://broker-1"})
+	pool.now = func() time.Time { return now }
+
+	pool.markDown("http://broker-1", errors.New("connection refused"))
+	pool.markDown("http://broker-1", errors.New("connection refused"))
+	assert.Equal(t, []BrokerState{
+		{Url: "http://broker-0", Healthy: true},
+		{Url: "http://broker-1", Healthy: false, Failures: 2, DownUntil: now.Add(BrokerDownDuration), LastError: "connection refused"},
+	}, pool.states())
+
+	pool.markUp("http://broker-1")
+	assert.Equal(t, []BrokerState{
+		{Url: "http://broker-0", Healthy: true},
+		{Url: "http://broker-1", Healthy: true},
+	}, pool.states())
+}
+
+func TestPinotClient_BrokerFailover(t *testing.T) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	var requests int
+	available := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"resultTable":{"dataSchema":{"columnNames":["1"],"columnDataTypes":["INT"]},"rows":[[1]]}}`))
+	}))
+	defer available.Close()
+
+	client := NewPinotClient(http.DefaultClient, ClientProperties{
+		BrokerUrl:  unavailable.URL,
+		BrokerUrls: []string{available.URL},
+	})
+
+	t.Run("ExecuteSqlQuery", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			resp, err := client.ExecuteSqlQuery(context.Background(), NewSqlQuery("SELECT 1"))
+			assert.NoError(t, err)
+			assert.True(t, resp.HasData())
+		}
+		assert.Equal(t, 3, requests)
+
+		states := client.BrokerStates()
+		assert.False(t, states[0].Healthy)
+		assert.Equal(t, 1, states[0].Failures)
+		assert.True(t, states[1].Healthy)
+	})
+
+	t.Run("CheckBrokers", func(t *testing.T) {
+		states := client.CheckBrokers(context.Background())
+		assert.False(t, states[0].Healthy)
+		assert.Equal(t, 2, states[0].Failures)
+		assert.Contains(t, states[0].LastError, "(503)")
+		assert.True(t, states[1].Healthy)
+	})
+
+	t.Run("all brokers down", func(t *testing.T) {
+		client := NewPinotClient(http.DefaultClient, ClientProperties{BrokerUrl: unavailable.URL})
+		_, err := client.ExecuteSqlQuery(context.Background(), NewSqlQuery("SELECT 1"))
+		assert.ErrorContains(t, err, "(503)")
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -31,6 +33,35 @@ func TestPinotClient_ExecuteSqlQuery(t *testing.T) {
 	}, resp.ResultTable)
 }
 
+func TestPinotClient_ExecuteSqlQuery_UseMultistageEngine(t *testing.T) {
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/query/sql", r.URL.Path)
+		gotBody = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		_, _ = w.Write([]byte(`{"exceptions":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewPinotClient(http.DefaultClient, ClientProperties{BrokerUrl: server.URL})
+
+	t.Run("enabled", func(t *testing.T) {
+		query := NewSqlQuery("SELECT 1")
+		query.UseMultistageEngine = true
+		_, err := client.ExecuteSqlQuery(context.Background(), query)
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT 1", gotBody["sql"])
+		assert.Regexp(t, `^useMultistageEngine=true;clientQueryId=grafana-\w+$`, gotBody["queryOptions"])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		_, err := client.ExecuteSqlQuery(context.Background(), NewSqlQuery("SELECT 1"))
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT 1", gotBody["sql"])
+		assert.Regexp(t, `^clientQueryId=grafana-\w+$`, gotBody["queryOptions"])
+	})
+}
+
 func TestNewBrokerExceptionError(t *testing.T) {
 	got := NewBrokerExceptionError([]BrokerException{{Message: "this is a broker exception", ErrorCode: 1}})
 	assert.Equal(t, &BrokerExceptionError{
@@ -66,4 +97,12 @@ SET enableNullHandling=true;
 SET maxExecutionThreads=5;
 SET useMultistageEngine=true;
 SET timeoutMs=100;`, got)
+
+	t.Run("query options override client options", func(t *testing.T) {
+		query := SqlQuery{Sql: `select * from benchmark`, QueryOptions: []QueryOption{{Name: "timeoutMs", Value: "5000"}}}
+		assert.Equal(t, `select * from benchmark;
+
+SET timeoutMs=5000;
+SET useMultistageEngine=true;`, client.RenderSql(query))
+	})
 }
@@ -0,0 +1,214 @@
+package pinot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// DefaultCapabilitiesRefreshInterval is how long detected cluster capabilities are used before they are detected again.
+const DefaultCapabilitiesRefreshInterval = 10 * time.Minute
+
+// Capabilities are the optional features supported by the Pinot cluster.
+type Capabilities struct {
+	Version          string `json:"version,omitempty"`
+	MultistageEngine bool   `json:"multistageEngine"`
+	TimeseriesApi    bool   `json:"timeseriesApi"`
+	MyTablesApi      bool   `json:"myTablesApi"`
+	Databases        bool   `json:"databases"`
+	Cursors          bool   `json:"cursors"`
+	// Unknown are the features whose probe failed. They are reported as not supported until a probe succeeds.
+	Unknown []string `json:"unknown,omitempty"`
+	// DetectedAt is when the oldest of the probes succeeded.
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// IsUnknown returns true if the probe of the feature failed. Features are named like the json fields of Capabilities.
+func (x Capabilities) IsUnknown(feature string) bool {
+	return slices.Contains(x.Unknown, feature)
+}
+
+type capabilityProbeResult struct {
+	supported  bool
+	version    string
+	detectedAt time.Time
+}
+
+// capabilityProbes detect the features of the cluster. Each probe's result is cached separately,
+// so a probe that fails is retried on the next call without discarding the results of the others.
+var capabilityProbes = []struct {
+	feature string
+	probe   func(p *Client, ctx context.Context) (capabilityProbeResult, error)
+	set     func(caps *Capabilities, result capabilityProbeResult)
+}{
+	{
+		feature: "version",
+		probe: func(p *Client, ctx context.Context) (capabilityProbeResult, error) {
+			return capabilityProbeResult{version: p.detectVersion(ctx)}, nil
+		},
+		set: func(caps *Capabilities, result capabilityProbeResult) { caps.Version = result.version },
+	},
+	{
+		feature: "myTablesApi",
+		probe: func(p *Client, ctx context.Context) (capabilityProbeResult, error) {
+			return probeResultOf(p.probeControllerEndpoint(ctx, http.MethodHead, "/mytables"))
+		},
+		set: func(caps *Capabilities, result capabilityProbeResult) { caps.MyTablesApi = result.supported },
+	},
+	{
+		feature: "databases",
+		probe: func(p *Client, ctx context.Context) (capabilityProbeResult, error) {
+			return probeResultOf(p.probeControllerEndpoint(ctx, http.MethodGet, "/databases"))
+		},
+		set: func(caps *Capabilities, result capabilityProbeResult) { caps.Databases = result.supported },
+	},
+	{
+		feature: "timeseriesApi",
+		probe: func(p *Client, ctx context.Context) (capabilityProbeResult, error) {
+			return probeResultOf(p.probeBrokerEndpoint(ctx, http.MethodHead, TimeSeriesEndpoint+"/query_range"))
+		},
+		set: func(caps *Capabilities, result capabilityProbeResult) { caps.TimeseriesApi = result.supported },
+	},
+	{
+		feature: "cursors",
+		probe: func(p *Client, ctx context.Context) (capabilityProbeResult, error) {
+			return probeResultOf(p.probeBrokerEndpoint(ctx, http.MethodGet, "/responseStore"))
+		},
+		set: func(caps *Capabilities, result capabilityProbeResult) { caps.Cursors = result.supported },
+	},
+	{
+		feature: "multistageEngine",
+		probe: func(p *Client, ctx context.Context) (capabilityProbeResult, error) {
+			return probeResultOf(p.detectMultistageEngine(ctx))
+		},
+		set: func(caps *Capabilities, result capabilityProbeResult) { caps.MultistageEngine = result.supported },
+	},
+}
+
+func probeResultOf(supported bool, err error) (capabilityProbeResult, error) {
+	return capabilityProbeResult{supported: supported}, err
+}
+
+// Capabilities returns the capabilities of the cluster.
+// Each capability is detected once and refreshed in the background when it is older than the refresh interval.
+// A probe that fails without a response is not mistaken for a missing feature. The feature is reported as unknown instead,
+// and probed again on the next call. An error is returned only when all probes fail.
+func (p *Client) Capabilities(ctx context.Context) (Capabilities, error) {
+	var caps Capabilities
+	var errs []error
+	for _, probe := range capabilityProbes {
+		key := p.capabilitiesKeyOf(probe.feature)
+		result, err := p.capabilities.get(ctx, key, func(ctx context.Context) (capabilityProbeResult, error) {
+			result, err := probe.probe(p, ctx)
+			result.detectedAt = time.Now()
+			return result, err
+		}, func(err error) {
+			p.logger.Error("pinot/http: Failed to refresh cluster capability.", "feature", probe.feature, "error", err)
+		})
+		if err != nil {
+			p.logger.Error("pinot/http: Failed to detect cluster capability.", "feature", probe.feature, "error", err)
+			caps.Unknown = append(caps.Unknown, probe.feature)
+			errs = append(errs, err)
+			continue
+		}
+		probe.set(&caps, result)
+		if caps.DetectedAt.IsZero() || result.detectedAt.Before(caps.DetectedAt) {
+			caps.DetectedAt = result.detectedAt
+		}
+	}
+
+	// The version probe does not fail, so all probes failed when only it succeeded.
+	if len(errs) == len(capabilityProbes)-1 {
+		return Capabilities{}, errors.Join(errs...)
+	}
+	return caps, nil
+}
+
+// RefreshCapabilities detects the capabilities of the cluster again, instead of returning the cached capabilities.
+func (p *Client) RefreshCapabilities(ctx context.Context) (Capabilities, error) {
+	p.capabilities.invalidate("")
+	return p.Capabilities(ctx)
+}
+
+// capabilitiesKeyOf returns the cache key of the feature.
+// With OAuth pass-through, capabilities are detected with the authorization of each user, since users can have different permissions.
+func (p *Client) capabilitiesKeyOf(feature string) string {
+	if !p.properties.OAuthPassThru {
+		return feature
+	}
+	hash := sha256.Sum256([]byte(p.properties.Authorization))
+	return hex.EncodeToString(hash[:]) + "/" + feature
+}
+
+// detectVersion returns the version reported by the controller, or an empty string if it is unknown.
+func (p *Client) detectVersion(ctx context.Context) string {
+	req, err := p.newControllerGetRequest(ctx, "/version")
+	if err != nil {
+		return ""
+	}
+
+	var versions map[string]string
+	if err = p.doRequestAndDecodeResponse(req, &versions); err != nil {
+		return ""
+	}
+	if version, ok := versions["pinot-controller"]; ok {
+		return version
+	}
+
+	components := make([]string, 0, len(versions))
+	for component := range versions {
+		components = append(components, component)
+	}
+	slices.Sort(components)
+	if len(components) == 0 {
+		return ""
+	}
+	return versions[components[0]]
+}
+
+func (p *Client) detectMultistageEngine(ctx context.Context) (bool, error) {
+	query := NewSqlQuery("SELECT 1")
+	query.UseMultistageEngine = true
+	query.SkipResultCache = true
+	// The query policy looks up tables, which depends on the capabilities being detected.
+	resp, err := p.doSqlQuery(ctx, query)
+	var statusErr *HttpStatusError
+	switch {
+	case err == nil:
+		return !resp.HasExceptions(), nil
+	case errors.As(err, &statusErr):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// probeControllerEndpoint returns true if the controller serves the endpoint.
+func (p *Client) probeControllerEndpoint(ctx context.Context, method string, endpoint string) (bool, error) {
+	req, err := p.newRequest(ctx, method, p.properties.ControllerUrl+endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := p.doRequest(req)
+	if err != nil {
+		return false, err
+	}
+	defer p.closeResponseBody(ctx, resp)
+	return resp.StatusCode != http.StatusNotFound, nil
+}
+
+// probeBrokerEndpoint returns true if the brokers serve the endpoint.
+func (p *Client) probeBrokerEndpoint(ctx context.Context, method string, endpoint string) (bool, error) {
+	resp, err := p.doBrokerRequest(ctx, "", func(brokerUrl string) (*http.Request, error) {
+		return p.newRequest(ctx, method, brokerUrl+endpoint, nil)
+	})
+	if err != nil {
+		return false, err
+	}
+	defer p.closeResponseBody(ctx, resp)
+	return resp.StatusCode != http.StatusNotFound, nil
+}
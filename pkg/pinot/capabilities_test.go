@@ -0,0 +1,158 @@
+package pinot
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPinotClient_Capabilities(t *testing.T) {
+	newServer := func(t *testing.T, requests *atomic.Int32, routes map[string]string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			body, ok := routes[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	ctx := context.Background()
+
+	t.Run("all supported", func(t *testing.T) {
+		var requests atomic.Int32
+		server := newServer(t, &requests, map[string]string{
+			"/version":                          `{"pinot-controller":"1.3.0","pinot-broker":"1.2.0"}`,
+			"/mytables":                         `{"tables":[]}`,
+			"/databases":                        `["default"]`,
+			TimeSeriesEndpoint + "/query_range": `{}`,
+			"/responseStore":                    `[]`,
+			"/query/sql":                        `{"resultTable":{"dataSchema":{"columnNames":["EXPR$0"],"columnDataTypes":["INT"]},"rows":[[1]]}}`,
+		})
+		client := NewPinotClient(http.DefaultClient, ClientProperties{ControllerUrl: server.URL, BrokerUrl: server.URL})
+		t.Cleanup(client.Close)
+
+		got, err := client.Capabilities(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "1.3.0", got.Version)
+		assert.True(t, got.MyTablesApi)
+		assert.True(t, got.Databases)
+		assert.True(t, got.TimeseriesApi)
+		assert.True(t, got.Cursors)
+		assert.True(t, got.MultistageEngine)
+		assert.False(t, got.DetectedAt.IsZero())
+
+		// Capabilities are detected once.
+		numRequests := requests.Load()
+		_, err = client.Capabilities(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, numRequests, requests.Load())
+	})
+
+	t.Run("none supported", func(t *testing.T) {
+		var requests atomic.Int32
+		server := newServer(t, &requests, map[string]string{
+			"/query/sql": `{"exceptions":[{"errorCode":200,"message":"multi-stage engine is disabled"}]}`,
+		})
+		client := NewPinotClient(http.DefaultClient, ClientProperties{ControllerUrl: server.URL, BrokerUrl: server.URL})
+		t.Cleanup(client.Close)
+
+		got, err := client.Capabilities(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, Capabilities{DetectedAt: got.DetectedAt}, got)
+
+		databases, err := client.ListDatabases(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, databases)
+	})
+
+	t.Run("probe fails", func(t *testing.T) {
+		var requests atomic.Int32
+		var failCursors atomic.Bool
+		failCursors.Store(true)
+		routes := map[string]string{
+			"/version":                          `{"pinot-controller":"1.3.0"}`,
+			"/databases":                        `["default"]`,
+			TimeSeriesEndpoint + "/query_range": `{}`,
+			"/responseStore":                    `[]`,
+			"/query/sql":                        `{"resultTable":{"dataSchema":{"columnNames":["EXPR$0"],"columnDataTypes":["INT"]},"rows":[[1]]}}`,
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if r.URL.Path == "/responseStore" && failCursors.Load() {
+				// Closes the connection without a response.
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				_ = conn.Close()
+				return
+			}
+			body, ok := routes[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		client := NewPinotClient(http.DefaultClient, ClientProperties{ControllerUrl: server.URL, BrokerUrl: server.URL})
+		t.Cleanup(client.Close)
+
+		got, err := client.Capabilities(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, Capabilities{
+			Version:          "1.3.0",
+			MultistageEngine: true,
+			TimeseriesApi:    true,
+			Databases:        true,
+			Unknown:          []string{"cursors"},
+			DetectedAt:       got.DetectedAt,
+		}, got)
+
+		// Only the failed probe is sent again.
+		failCursors.Store(false)
+		numRequests := requests.Load()
+		got, err = client.Capabilities(ctx)
+		require.NoError(t, err)
+		assert.True(t, got.Cursors)
+		assert.Empty(t, got.Unknown)
+		assert.Equal(t, numRequests+1, requests.Load())
+	})
+
+	t.Run("oauth pass-through", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/databases" && r.Header.Get("Authorization") == "Bearer admin" {
+				_, _ = w.Write([]byte(`["default"]`))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		t.Cleanup(server.Close)
+		client := NewPinotClient(http.DefaultClient, ClientProperties{ControllerUrl: server.URL, BrokerUrl: server.URL, OAuthPassThru: true})
+		t.Cleanup(client.Close)
+
+		got, err := client.WithAuthorization("Bearer admin").Capabilities(ctx)
+		require.NoError(t, err)
+		assert.True(t, got.Databases)
+
+		got, err = client.WithAuthorization("Bearer viewer").Capabilities(ctx)
+		require.NoError(t, err)
+		assert.False(t, got.Databases)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+		client := NewPinotClient(http.DefaultClient, ClientProperties{ControllerUrl: server.URL, BrokerUrl: server.URL})
+		t.Cleanup(client.Close)
+
+		_, err := client.Capabilities(ctx)
+		assert.Error(t, err)
+	})
+}
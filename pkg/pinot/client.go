@@ -10,6 +10,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 )
 
 const (
@@ -21,14 +22,49 @@ type Client struct {
 	headers    map[string]string
 	httpClient *http.Client
 	logger     Logger
+	brokers    *brokerPool
+	discovery  *brokerDiscovery
+
+	// tokenSource is set when the client authenticates with oauth2 client credentials.
+	tokenSource *oauth2TokenSource
+
+	metadataCache    *metadataCache
+	queryResultCache *queryResultCache
+	capabilities     *ttlCache[capabilityProbeResult]
+	docCounts        *docCountCache
+
+	sqlQueryFlights    *flightGroup[[]byte]
+	schemaFlights      *flightGroup[TableSchema]
+	tableConfigFlights *flightGroup[ListTableConfigsResponse]
 }
 
 type ClientProperties struct {
-	ControllerUrl string
-	BrokerUrl     string
-	DatabaseName  string
-	Authorization string
-	QueryOptions  []QueryOption
+	ControllerUrl   string
+	BrokerUrl       string
+	BrokerUrls      []string
+	BrokerDiscovery bool
+	DatabaseName    string
+	Authorization   string
+	QueryOptions    []QueryOption
+	RetryPolicy     RetryPolicy
+
+	// OAuthPassThru is set when requests carry the authorization of the Grafana user that made them.
+	// Concurrent requests are then not coalesced, since each user can see different data.
+	OAuthPassThru bool
+
+	// OAuth2ClientCredentials, when set, replace the static authorization with bearer tokens fetched from the token endpoint.
+	OAuth2ClientCredentials *ClientCredentials
+
+	// MetadataCacheTTL is how long table lists, schemas, configs and metadata are cached. Zero disables caching.
+	MetadataCacheTTL time.Duration
+
+	// QueryResultCacheTTL is how long sql query results are cached. Zero disables caching.
+	QueryResultCacheTTL time.Duration
+	// QueryResultCacheMaxBytes bounds the size of cached query results. Defaults to DefaultQueryResultCacheMaxBytes.
+	QueryResultCacheMaxBytes int64
+
+	// QueryPolicy restricts the sql queries sent to Pinot.
+	QueryPolicy QueryPolicy
 }
 
 type QueryOption struct {
@@ -44,27 +80,86 @@ type Logger interface {
 func NewPinotClient(httpClient *http.Client, properties ClientProperties) *Client {
 	properties.BrokerUrl = strings.TrimSuffix(properties.BrokerUrl, "/")
 	properties.ControllerUrl = strings.TrimSuffix(properties.ControllerUrl, "/")
+	if properties.BrokerUrls != nil {
+		brokerUrls := make([]string, len(properties.BrokerUrls))
+		for i := range properties.BrokerUrls {
+			brokerUrls[i] = strings.TrimSuffix(properties.BrokerUrls[i], "/")
+		}
+		properties.BrokerUrls = brokerUrls
+	}
 
-	headers := make(map[string]string)
-	if properties.Authorization != "" {
-		headers["Authorization"] = properties.Authorization
+	var discovery *brokerDiscovery
+	if properties.BrokerDiscovery {
+		discovery = newBrokerDiscovery()
 	}
-	if properties.DatabaseName != "" && properties.DatabaseName != DefaultDatabase {
-		headers["Database"] = properties.DatabaseName
+
+	var tokenSource *oauth2TokenSource
+	if properties.OAuth2ClientCredentials != nil {
+		tokenSource = newOAuth2TokenSource(httpClient, *properties.OAuth2ClientCredentials)
 	}
 
-	return &Client{
+	client := &Client{
 		properties: properties,
-		headers:    headers,
 		httpClient: httpClient,
 		logger:     slog.Default(),
+		brokers:    newBrokerPool(append([]string{properties.BrokerUrl}, properties.BrokerUrls...)),
+		discovery:  discovery,
+
+		tokenSource: tokenSource,
+
+		capabilities: newTtlCache[capabilityProbeResult](DefaultCapabilitiesRefreshInterval),
+	}
+	client.init()
+	return client
+}
+
+// derive returns a client with the properties that shares the brokers, credentials and capabilities of this client.
+// The client has its own headers, caches and flights. Shared capabilities are keyed by authorization under OAuth pass-through.
+func (p *Client) derive(properties ClientProperties) *Client {
+	client := &Client{
+		properties: properties,
+		httpClient: p.httpClient,
+		logger:     p.logger,
+		brokers:    p.brokers,
+		discovery:  p.discovery,
+
+		tokenSource: p.tokenSource,
+
+		capabilities: p.capabilities,
+	}
+	client.init()
+	return client
+}
+
+// init sets up the parts of the client that depend on its properties.
+func (p *Client) init() {
+	p.headers = headersOf(p.properties)
+	p.metadataCache = newMetadataCache(p.properties.MetadataCacheTTL)
+	p.queryResultCache = newQueryResultCache(p.properties.QueryResultCacheTTL, p.properties.QueryResultCacheMaxBytes)
+	p.docCounts = newDocCountCache()
+
+	if !p.properties.OAuthPassThru {
+		p.sqlQueryFlights = newFlightGroup[[]byte]()
+		p.schemaFlights = newFlightGroup[TableSchema]()
+		p.tableConfigFlights = newFlightGroup[ListTableConfigsResponse]()
+	}
+}
+
+func headersOf(properties ClientProperties) map[string]string {
+	headers := make(map[string]string)
+	if properties.Authorization != "" {
+		headers["Authorization"] = properties.Authorization
+	}
+	if properties.DatabaseName != "" && properties.DatabaseName != DefaultDatabase {
+		headers["Database"] = properties.DatabaseName
 	}
+	return headers
 }
 
 func (p *Client) WithAuthorization(authorization string) *Client {
 	properties := p.Properties()
 	properties.Authorization = authorization
-	return NewPinotClient(p.httpClient, properties)
+	return p.derive(properties)
 }
 
 func (p *Client) WithLogger(logger Logger) *Client {
@@ -73,7 +168,60 @@ func (p *Client) WithLogger(logger Logger) *Client {
 		headers:    p.headers,
 		httpClient: p.httpClient,
 		logger:     logger,
+		brokers:    p.brokers,
+		discovery:  p.discovery,
+
+		tokenSource: p.tokenSource,
+
+		metadataCache:    p.metadataCache,
+		queryResultCache: p.queryResultCache,
+		capabilities:     p.capabilities,
+		docCounts:        p.docCounts,
+
+		sqlQueryFlights:    p.sqlQueryFlights,
+		schemaFlights:      p.schemaFlights,
+		tableConfigFlights: p.tableConfigFlights,
+	}
+}
+
+// WithDatabase returns a client that sends requests to the database instead of the configured one.
+// The client shares the brokers, credentials, caches and flights of this client.
+// Cached metadata and query results are keyed by database, so no state is kept per database name.
+func (p *Client) WithDatabase(database string) *Client {
+	if database == "" || database == p.properties.DatabaseName {
+		return p
 	}
+	client := p.WithLogger(p.logger)
+	client.properties.DatabaseName = database
+	client.headers = headersOf(client.properties)
+	return client
+}
+
+// cacheKeyOf qualifies the cache or flight key with the database of the client.
+func (p *Client) cacheKeyOf(key string) string {
+	return p.properties.DatabaseName + "/" + key
+}
+
+// InvalidateMetadataCache removes the cached metadata of the table, or all cached metadata if the table is empty.
+func (p *Client) InvalidateMetadataCache(table string) {
+	if p.metadataCache == nil {
+		return
+	}
+	if table == "" {
+		p.metadataCache.invalidate("")
+	} else {
+		p.metadataCache.invalidate(p.cacheKeyOf(table))
+	}
+}
+
+func (p *Client) Close() {
+	if p.metadataCache != nil {
+		p.metadataCache.close()
+	}
+	if p.queryResultCache != nil {
+		p.queryResultCache.close()
+	}
+	p.capabilities.close()
 }
 
 func (p *Client) Properties() ClientProperties { return p.properties }
@@ -88,23 +236,56 @@ func (p *Client) newRequest(ctx context.Context, method string, url string, body
 	for k, v := range p.headers {
 		req.Header.Set(k, v)
 	}
+	if p.tokenSource != nil {
+		if err = p.setBearerToken(req); err != nil {
+			return nil, err
+		}
+	}
 	return req, nil
 }
 
 func (p *Client) doRequestAndDecodeResponse(req *http.Request, dest interface{}) error {
-	resp, err := p.doRequest(req)
+	var resp *http.Response
+	var err error
+	if isIdempotentRequest(req) {
+		resp, err = p.doWithRetries(req.Context(), retryTargetController, func() (*http.Response, error) {
+			return p.doRequest(req)
+		})
+	} else {
+		resp, err = p.doRequest(req)
+	}
 	if err != nil {
 		return err
 	}
-	defer p.closeResponseBody(req.Context(), resp)
+	return p.decodeResponse(req.Context(), resp, dest)
+}
+
+func (p *Client) decodeResponse(ctx context.Context, resp *http.Response, dest interface{}) error {
+	defer p.closeResponseBody(ctx, resp)
 
 	if resp.StatusCode != http.StatusOK {
-		return p.newErrorFromResponseBody(req.Context(), resp)
+		return p.newErrorFromResponseBody(ctx, resp)
+	}
+	return decodeJson(resp.Body, dest)
+}
+
+func (p *Client) readResponseBody(ctx context.Context, resp *http.Response) ([]byte, error) {
+	defer p.closeResponseBody(ctx, resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, p.newErrorFromResponseBody(ctx, resp)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("pinot/http: Failed to read response body: %w", err)
 	}
+	return body, nil
+}
 
-	decoder := json.NewDecoder(resp.Body)
+func decodeJson(reader io.Reader, dest interface{}) error {
+	decoder := json.NewDecoder(reader)
 	decoder.UseNumber()
-	if err = decoder.Decode(&dest); err != nil {
+	if err := decoder.Decode(&dest); err != nil {
 		return fmt.Errorf("pinot/http: Failed to decode response json: %w", err)
 	}
 	return nil
@@ -115,6 +296,11 @@ func (p *Client) doRequest(req *http.Request) (*http.Response, error) {
 	if err != nil {
 		return nil, fmt.Errorf("pinot/http: Request failed: %s %s %w", req.Method, req.URL.String(), err)
 	}
+	if resp.StatusCode == http.StatusUnauthorized && p.tokenSource != nil {
+		if resp, err = p.retryWithFreshToken(req, resp); err != nil {
+			return nil, fmt.Errorf("pinot/http: Request failed: %s %s %w", req.Method, req.URL.String(), err)
+		}
+	}
 	p.logger.Info("pinot/http: Outgoing http request completed.", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode)
 	return resp, err
 }
@@ -130,12 +316,16 @@ func (p *Client) newErrorFromResponseBody(ctx context.Context, resp *http.Respon
 	if _, err := body.ReadFrom(resp.Body); err != nil {
 		p.logger.Error("pinot/http: Failed to read response body.", "error", err)
 	}
-	return newHttpStatusError(resp.StatusCode, body.String())
+	statusErr := newHttpStatusError(resp.StatusCode, body.String())
+	statusErr.header = resp.Header
+	return statusErr
 }
 
 type HttpStatusError struct {
 	StatusCode int
 	Body       string
+
+	header http.Header
 }
 
 func (x *HttpStatusError) Error() string {
@@ -158,6 +348,14 @@ func IsStatusForbiddenError(err error) bool {
 	return false
 }
 
+func IsStatusUnauthorizedError(err error) bool {
+	var statusErr *HttpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusUnauthorized
+	}
+	return false
+}
+
 func newHttpStatusError(statusCode int, body string) *HttpStatusError {
 	return &HttpStatusError{
 		StatusCode: statusCode,
@@ -1,12 +1,17 @@
 package pinot
 
 import (
+	"context"
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot/pinottest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"log/slog"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewPinotClient(t *testing.T) {
@@ -37,10 +42,12 @@ func TestNewPinotClient(t *testing.T) {
 }
 
 func TestPinotClient_WithAuthorization(t *testing.T) {
-	got := NewPinotClient(http.DefaultClient, ClientProperties{
+	client := NewPinotClient(http.DefaultClient, ClientProperties{
 		ControllerUrl: "http://localhost:9000",
 		BrokerUrl:     "http://localhost:8000",
-	}).WithAuthorization("Bearer test_token")
+	})
+	t.Cleanup(client.Close)
+	got := client.WithAuthorization("Bearer test_token")
 
 	assert.Equal(t, ClientProperties{
 		ControllerUrl: "http://localhost:9000",
@@ -48,6 +55,9 @@ func TestPinotClient_WithAuthorization(t *testing.T) {
 		Authorization: "Bearer test_token",
 	}, got.properties)
 	assert.Equal(t, map[string]string{"Authorization": "Bearer test_token"}, got.headers)
+	assert.Same(t, client.brokers, got.brokers)
+	assert.Same(t, client.capabilities, got.capabilities)
+	assert.NotSame(t, client.sqlQueryFlights, got.sqlQueryFlights)
 }
 
 func TestPinotClient_WithLogger(t *testing.T) {
@@ -60,6 +70,49 @@ func TestPinotClient_WithLogger(t *testing.T) {
 	assert.Equal(t, logger, got.logger)
 }
 
+func TestPinotClient_WithDatabase(t *testing.T) {
+	var mu sync.Mutex
+	var databases []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		databases = append(databases, r.Header.Get("Database"))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"schemaName":"orders"}`))
+	}))
+	t.Cleanup(server.Close)
+	getDatabases := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), databases...)
+	}
+
+	ctx := context.Background()
+	client := NewPinotClient(http.DefaultClient, ClientProperties{
+		ControllerUrl:    server.URL,
+		BrokerUrl:        server.URL,
+		DatabaseName:     "default_db",
+		MetadataCacheTTL: time.Minute,
+	})
+	t.Cleanup(client.Close)
+
+	assert.Same(t, client, client.WithDatabase(""))
+	assert.Same(t, client, client.WithDatabase("default_db"))
+
+	other := client.WithDatabase("other_db")
+	assert.Equal(t, "other_db", other.Properties().DatabaseName)
+	assert.Equal(t, map[string]string{"Database": "other_db"}, other.headers)
+	assert.Same(t, client.metadataCache, other.metadataCache)
+	assert.Same(t, client.queryResultCache, other.queryResultCache)
+
+	_, err := client.GetTableSchema(ctx, "orders")
+	require.NoError(t, err)
+	_, err = other.GetTableSchema(ctx, "orders")
+	require.NoError(t, err)
+	_, err = client.WithDatabase("other_db").GetTableSchema(ctx, "orders")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default_db", "other_db"}, getDatabases())
+}
+
 func setupPinotAndCreateClient(t *testing.T) *Client {
 	pinottest.CreateTestTables()
 	pinotClient := NewPinotClient(http.DefaultClient, ClientProperties{
@@ -1,14 +1,12 @@
 package pinot
 
-import (
-	"fmt"
-	"strings"
-)
+import "fmt"
 
+// ColumnFilter matches rows where the column compares to any of the values.
 type ColumnFilter struct {
 	ColumnName string
 	ColumnKey  string
-	ValueExprs []string
+	Values     []FilterValue
 	Operator   FilterOperator
 }
 
@@ -29,54 +27,70 @@ const (
 	FilterOpNotIn              FilterOperator = "not in"
 )
 
+// Validate returns an error if a value cannot be used with the operator.
+func (x ColumnFilter) Validate() error {
+	for _, val := range x.Values {
+		switch {
+		case val.Type == FilterValueTypeList && x.Operator != FilterOpIn && x.Operator != FilterOpNotIn:
+			return fmt.Errorf("operator `%s` does not accept a list value", x.Operator)
+		case val.Type == FilterValueTypeNull && x.Operator != FilterOpEquals && x.Operator != FilterOpNotEquals:
+			return fmt.Errorf("operator `%s` does not accept a null value", x.Operator)
+		case (x.Operator == FilterOpLike || x.Operator == FilterOpNotLike) && val.Type != FilterValueTypeString:
+			return fmt.Errorf("operator `%s` requires a string value, got %s", x.Operator, val.Type)
+		}
+	}
+	return nil
+}
+
 func ColumnFilterExpr(filter ColumnFilter) SqlExpr {
-	if filter.ColumnName == "" || filter.Operator == "" || len(filter.ValueExprs) == 0 {
+	if filter.ColumnName == "" || filter.Operator == "" || len(filter.Values) == 0 {
 		return ""
 	}
 
-	columnExpr := ComplexFieldExpr(filter.ColumnName, filter.ColumnKey)
-	format := func(valueExpr string) string {
-		switch filter.Operator {
-		case FilterOpEquals:
-			return fmt.Sprintf(`%s = %s`, columnExpr, valueExpr)
-		case FilterOpNotEquals:
-			return fmt.Sprintf(`%s != %s`, columnExpr, valueExpr)
-		case FilterOpContains:
-			return fmt.Sprintf(`%s contains %s`, columnExpr, valueExpr)
-		case FilterOpNotContains:
-			return fmt.Sprintf(`not %s contains %s`, columnExpr, valueExpr)
-		case FilterOpLike:
-			return fmt.Sprintf(`%s like %s`, columnExpr, valueExpr)
-		case FilterOpNotLike:
-			return fmt.Sprintf(`not %s like %s`, columnExpr, valueExpr)
-		case FilterOpGreaterThan:
-			return fmt.Sprintf(`%s > %s`, columnExpr, valueExpr)
-		case FilterOpLessThan:
-			return fmt.Sprintf(`%s < %s`, columnExpr, valueExpr)
-		case FilterOpGreaterThanOrEqual:
-			return fmt.Sprintf(`%s >= %s`, columnExpr, valueExpr)
-		case FilterOpLessThanOrEqual:
-			return fmt.Sprintf(`%s <= %s`, columnExpr, valueExpr)
-		case FilterOpIn:
-			return fmt.Sprintf(`%s in %s`, columnExpr, valueExpr)
-		case FilterOpNotIn:
-			return fmt.Sprintf(`%s not in %s`, columnExpr, valueExpr)
-		default:
-			return ""
-		}
+	var columnNode SqlNode = Identifier(filter.ColumnName)
+	if filter.ColumnKey != "" {
+		columnNode = ItemAccess{Column: columnNode, Key: StringLiteral(filter.ColumnKey)}
 	}
 
-	exprs := make([]string, 0, len(filter.ValueExprs))
-	for _, expr := range filter.ValueExprs {
-		filterExpr := format(expr)
-		if filterExpr == "" {
-			continue
+	nodes := make(OrExpr, 0, len(filter.Values))
+	for _, val := range filter.Values {
+		if node := columnFilterNode(columnNode, filter.Operator, val); node != nil {
+			nodes = append(nodes, node)
 		}
-		exprs = append(exprs, format(expr))
 	}
-	if len(exprs) == 0 {
+	if len(nodes) == 0 {
 		return ""
 	}
+	return nodes.Expr()
+}
 
-	return SqlExpr(fmt.Sprintf(`(%s)`, strings.Join(exprs, " OR ")))
+func columnFilterNode(column SqlNode, operator FilterOperator, val FilterValue) SqlNode {
+	switch {
+	case val.Type == FilterValueTypeNull && operator == FilterOpEquals:
+		return BinaryExpr{Left: column, Operator: "IS", Right: NullLiteral{}}
+	case val.Type == FilterValueTypeNull && operator == FilterOpNotEquals:
+		return BinaryExpr{Left: column, Operator: "IS NOT", Right: NullLiteral{}}
+	case val.Type == FilterValueTypeNull:
+		return nil
+	case operator == FilterOpIn || operator == FilterOpNotIn:
+		if val.Type != FilterValueTypeList {
+			val = ListFilterValue(val)
+		}
+		return BinaryExpr{Left: column, Operator: Keyword(operator), Right: val.Node()}
+	case val.Type == FilterValueTypeList:
+		return nil
+	}
+
+	switch operator {
+	case FilterOpEquals, FilterOpNotEquals, FilterOpGreaterThan, FilterOpLessThan, FilterOpGreaterThanOrEqual,
+		FilterOpLessThanOrEqual, FilterOpContains, FilterOpLike:
+		// The operator is one of the known constants, so it is safe to render verbatim.
+		return BinaryExpr{Left: column, Operator: Keyword(operator), Right: val.Node()}
+	case FilterOpNotContains:
+		return NotExpr{Operand: BinaryExpr{Left: column, Operator: Keyword(FilterOpContains), Right: val.Node()}}
+	case FilterOpNotLike:
+		return NotExpr{Operand: BinaryExpr{Left: column, Operator: Keyword(FilterOpLike), Right: val.Node()}}
+	default:
+		return nil
+	}
 }
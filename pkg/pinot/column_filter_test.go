@@ -2,6 +2,7 @@ package pinot
 
 import (
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -20,8 +21,8 @@ func TestColumnFilterExpr(t *testing.T) {
 		{FilterOpLessThan, `("dim"['key'] < 'val1' OR "dim"['key'] < 'val2')`},
 		{FilterOpGreaterThanOrEqual, `("dim"['key'] >= 'val1' OR "dim"['key'] >= 'val2')`},
 		{FilterOpLessThanOrEqual, `("dim"['key'] <= 'val1' OR "dim"['key'] <= 'val2')`},
-		{FilterOpIn, `("dim"['key'] in 'val1' OR "dim"['key'] in 'val2')`},
-		{FilterOpNotIn, `("dim"['key'] not in 'val1' OR "dim"['key'] not in 'val2')`},
+		{FilterOpIn, `("dim"['key'] in ('val1') OR "dim"['key'] in ('val2'))`},
+		{FilterOpNotIn, `("dim"['key'] not in ('val1') OR "dim"['key'] not in ('val2'))`},
 	}
 	for _, args := range testArgs {
 		t.Run(string(args.operator), func(t *testing.T) {
@@ -29,8 +30,63 @@ func TestColumnFilterExpr(t *testing.T) {
 				ColumnName: "dim",
 				ColumnKey:  "key",
 				Operator:   args.operator,
-				ValueExprs: []string{`'val1'`, `'val2'`},
+				Values:     []FilterValue{StringFilterValue("val1"), StringFilterValue("val2")},
 			}))
 		})
 	}
 }
+
+func TestColumnFilterExpr_Escaping(t *testing.T) {
+	assert.Equal(t, SqlExpr(`("my""dim"['it''s'] = 'val')`), ColumnFilterExpr(ColumnFilter{
+		ColumnName: `my"dim`,
+		ColumnKey:  `it's`,
+		Operator:   FilterOpEquals,
+		Values:     []FilterValue{StringFilterValue("val")},
+	}))
+}
+
+func FuzzColumnFilterExpr(f *testing.F) {
+	f.Add("dim", "key")
+	f.Add(`my"dim`, `it's`)
+	f.Add(`dim" = 1 OR "x`, `'] = 1 OR ['`)
+	f.Fuzz(func(t *testing.T, column string, key string) {
+		if column == "" {
+			t.Skip("filters without a column are dropped")
+		}
+		want := SqlExpr("(" + ComplexFieldExpr(column, key) + ` = 'val')`)
+		got := ColumnFilterExpr(ColumnFilter{
+			ColumnName: column,
+			ColumnKey:  key,
+			Operator:   FilterOpEquals,
+			Values:     []FilterValue{StringFilterValue("val")},
+		})
+		assert.Equal(t, want, got)
+		if key == "" {
+			assert.Equal(t, column, UnquoteObjectName(strings.TrimSuffix(strings.TrimPrefix(got.String(), "("), ` = 'val')`)))
+		}
+	})
+}
+
+func TestColumnFilterExpr_Values(t *testing.T) {
+	testCases := []struct {
+		name     string
+		operator FilterOperator
+		values   []FilterValue
+		expected SqlExpr
+	}{
+		{"number", FilterOpGreaterThan, []FilterValue{NumberFilterValue("-1.5")}, `("dim" > -1.5)`},
+		{"boolean", FilterOpEquals, []FilterValue{BooleanFilterValue(false)}, `("dim" = FALSE)`},
+		{"is null", FilterOpEquals, []FilterValue{NullFilterValue()}, `("dim" IS NULL)`},
+		{"is not null", FilterOpNotEquals, []FilterValue{NullFilterValue()}, `("dim" IS NOT NULL)`},
+		{"in list", FilterOpIn, []FilterValue{ListFilterValue(StringFilterValue("a"), NumberFilterValue("1"))}, `("dim" in ('a', 1))`},
+		{"not in list", FilterOpNotIn, []FilterValue{ListFilterValue(StringFilterValue("a'b"))}, `("dim" not in ('a''b'))`},
+		{"list with equals", FilterOpEquals, []FilterValue{ListFilterValue(StringFilterValue("a"))}, ``},
+		{"null with like", FilterOpLike, []FilterValue{NullFilterValue()}, ``},
+		{"invalid number", FilterOpEquals, []FilterValue{NumberFilterValue("1 OR 1=1")}, `("dim" = NULL)`},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ColumnFilterExpr(ColumnFilter{ColumnName: "dim", Operator: tt.operator, Values: tt.values}))
+		})
+	}
+}
@@ -5,10 +5,15 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 )
 
 func (p *Client) ListDatabases(ctx context.Context) ([]string, error) {
+	if caps, err := p.Capabilities(ctx); err == nil && !caps.Databases {
+		return []string{}, nil
+	}
+
 	req, err := p.newControllerGetRequest(ctx, "/databases")
 	if err != nil {
 		return nil, err
@@ -24,7 +29,23 @@ func (p *Client) ListDatabases(ctx context.Context) ([]string, error) {
 	return databases, nil
 }
 
+// ListTables returns the tables that the query policy allows.
 func (p *Client) ListTables(ctx context.Context) ([]string, error) {
+	tables, err := p.listCachedTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(tables, func(table string) bool {
+		return p.properties.QueryPolicy.checkTable(table) != nil
+	}), nil
+}
+
+func (p *Client) listCachedTables(ctx context.Context) ([]string, error) {
+	tables, err := cachedMetadata(ctx, p, func(x *metadataCache) *ttlCache[[]string] { return x.tables }, "", p.listTables)
+	return slices.Clone(tables), err
+}
+
+func (p *Client) listTables(ctx context.Context) ([]string, error) {
 	endpoint := p.listTablesEndpoint(ctx)
 	req, err := p.newControllerGetRequest(ctx, endpoint)
 	if err != nil {
@@ -46,17 +67,12 @@ func (p *Client) ListTables(ctx context.Context) ([]string, error) {
 	return tables, nil
 }
 
+// listTablesEndpoint returns /mytables, which lists only the tables visible to the user, when the controller supports it.
 func (p *Client) listTablesEndpoint(ctx context.Context) string {
-	req, err := p.newControllerHeadRequest(ctx, "/mytables")
-	if err != nil {
-		return "/tables"
-	}
-
-	resp, err := p.doRequest(req)
-	if err != nil || resp.StatusCode == http.StatusNotFound {
-		return "/tables"
+	if caps, err := p.Capabilities(ctx); err == nil && caps.MyTablesApi {
+		return "/mytables"
 	}
-	return "/mytables"
+	return "/tables"
 }
 
 type TableType string
@@ -85,6 +101,18 @@ type TransformConfig struct {
 }
 
 func (p *Client) ListTableConfigs(ctx context.Context, table string) (ListTableConfigsResponse, error) {
+	if err := p.properties.QueryPolicy.checkTable(table); err != nil {
+		return ListTableConfigsResponse{}, err
+	}
+	return cachedMetadata(ctx, p, func(x *metadataCache) *ttlCache[ListTableConfigsResponse] { return x.tableConfigs }, table,
+		func(ctx context.Context) (ListTableConfigsResponse, error) {
+			return p.tableConfigFlights.do(ctx, p.cacheKeyOf(table), func(ctx context.Context) (ListTableConfigsResponse, error) {
+				return p.listTableConfigs(ctx, table)
+			})
+		})
+}
+
+func (p *Client) listTableConfigs(ctx context.Context, table string) (ListTableConfigsResponse, error) {
 	req, err := p.newControllerGetRequest(ctx, "/tables/"+url.PathEscape(table))
 	if err != nil {
 		return ListTableConfigsResponse{}, err
@@ -144,6 +172,18 @@ type ChildFieldSpec struct {
 }
 
 func (p *Client) GetTableSchema(ctx context.Context, table string) (TableSchema, error) {
+	if err := p.properties.QueryPolicy.checkTable(table); err != nil {
+		return TableSchema{}, err
+	}
+	return cachedMetadata(ctx, p, func(x *metadataCache) *ttlCache[TableSchema] { return x.schemas }, table,
+		func(ctx context.Context) (TableSchema, error) {
+			return p.schemaFlights.do(ctx, p.cacheKeyOf(table), func(ctx context.Context) (TableSchema, error) {
+				return p.getTableSchema(ctx, table)
+			})
+		})
+}
+
+func (p *Client) getTableSchema(ctx context.Context, table string) (TableSchema, error) {
 	req, err := p.newControllerGetRequest(ctx, "/tables/"+url.PathEscape(table)+"/schema")
 	if err != nil {
 		return TableSchema{}, err
@@ -164,6 +204,14 @@ type TableMetadata struct {
 }
 
 func (p *Client) GetTableMetadata(ctx context.Context, table string) (TableMetadata, error) {
+	if err := p.properties.QueryPolicy.checkTable(table); err != nil {
+		return TableMetadata{}, err
+	}
+	return cachedMetadata(ctx, p, func(x *metadataCache) *ttlCache[TableMetadata] { return x.metadata }, table,
+		func(ctx context.Context) (TableMetadata, error) { return p.getTableMetadata(ctx, table) })
+}
+
+func (p *Client) getTableMetadata(ctx context.Context, table string) (TableMetadata, error) {
 	req, err := p.newControllerGetRequest(ctx, "/tables/"+url.PathEscape(table)+"/metadata")
 	if err != nil {
 		return TableMetadata{}, err
@@ -0,0 +1,214 @@
+package pinot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// https://docs.pinot.apache.org/users/user-guide-query/explain-plan
+
+const (
+	IndexTypeInverted = "inverted"
+	IndexTypeSorted   = "sorted"
+	IndexTypeRange    = "range"
+	IndexTypeStarTree = "star-tree"
+	IndexTypeJson     = "json"
+	IndexTypeText     = "text"
+	IndexTypeH3       = "h3"
+	IndexTypeVector   = "vector"
+	IndexTypeFullScan = "full-scan"
+)
+
+// The filter operators in an explain plan and the index used by each.
+var explainPlanFilterIndexTypes = []struct {
+	prefix    string
+	indexType string
+}{
+	{"FILTER_STARTREE_INDEX", IndexTypeStarTree},
+	{"FILTER_INVERTED_INDEX", IndexTypeInverted},
+	{"FILTER_SORTED_INDEX", IndexTypeSorted},
+	{"FILTER_RANGE_INDEX", IndexTypeRange},
+	{"FILTER_JSON_INDEX", IndexTypeJson},
+	{"FILTER_TEXT_INDEX", IndexTypeText},
+	{"FILTER_H3_INDEX", IndexTypeH3},
+	{"FILTER_VECTOR_SIMILARITY_INDEX", IndexTypeVector},
+	{"FILTER_FULL_SCAN", IndexTypeFullScan},
+}
+
+type ExplainPlan struct {
+	Nodes []*ExplainPlanNode `json:"nodes"`
+	// IndexTypes are the distinct index types used anywhere in the plan.
+	IndexTypes []string `json:"indexTypes"`
+}
+
+type ExplainPlanNode struct {
+	Operator  string             `json:"operator"`
+	IndexType string             `json:"indexType,omitempty"`
+	Children  []*ExplainPlanNode `json:"children,omitempty"`
+}
+
+// ExplainPlan runs EXPLAIN PLAN FOR the query and returns the plan as a tree.
+func (p *Client) ExplainPlan(ctx context.Context, query SqlQuery) (*ExplainPlan, error) {
+	query.Sql = "EXPLAIN PLAN FOR " + strings.TrimSpace(query.Sql)
+	query.Trace = false
+	query.SkipResultCache = true
+
+	// Explaining a query does not scan any data, so only the tables of the query are checked.
+	if _, err := p.checkQueriedTables(ctx, query); err != nil {
+		return nil, err
+	}
+	resp, err := p.doSqlQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	} else if resp.HasExceptions() {
+		return nil, NewBrokerExceptionError(resp.Exceptions)
+	} else if resp.ResultTable == nil {
+		return nil, errors.New("explain plan returned no results")
+	}
+	return ExplainPlanFrom(resp.ResultTable)
+}
+
+// ExplainPlanFrom decodes the results of an EXPLAIN PLAN query.
+// The single-stage engine returns one row per operator with the ids of the operator and its parent.
+// The multi-stage engine returns the plan as indented text.
+func ExplainPlanFrom(results *ResultTable) (*ExplainPlan, error) {
+	var nodes []*ExplainPlanNode
+	var err error
+	if _, idErr := GetColumnIdx(results, "Operator_Id"); idErr == nil {
+		nodes, err = explainPlanNodesFromOperators(results)
+	} else {
+		nodes, err = explainPlanNodesFromText(results)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plan := ExplainPlan{Nodes: nodes, IndexTypes: []string{}}
+	walkExplainPlan(nodes, func(node *ExplainPlanNode) {
+		if node.IndexType != "" && !slices.Contains(plan.IndexTypes, node.IndexType) {
+			plan.IndexTypes = append(plan.IndexTypes, node.IndexType)
+		}
+	})
+	slices.Sort(plan.IndexTypes)
+	return &plan, nil
+}
+
+func explainPlanNodesFromOperators(results *ResultTable) ([]*ExplainPlanNode, error) {
+	operators, err := extractNamedColumnAsStrings(results, "Operator")
+	if err != nil {
+		return nil, err
+	}
+	operatorIds, err := extractNamedColumnAsDoubles(results, "Operator_Id")
+	if err != nil {
+		return nil, err
+	}
+	parentIds, err := extractNamedColumnAsDoubles(results, "Parent_Id")
+	if err != nil {
+		return nil, err
+	}
+
+	// Operator ids repeat across the plans of different segment groups, so parents resolve to the latest operator with the id.
+	// Each of these plans starts with a PLAN_START operator without ids, which is attached to the parent of the next operator.
+	var roots []*ExplainPlanNode
+	nodesById := make(map[int]*ExplainPlanNode)
+	var planStart *ExplainPlanNode
+	for i := range operators {
+		node := newExplainPlanNode(operators[i])
+		operatorId, parentId := int(operatorIds[i]), int(parentIds[i])
+		if operatorId < 0 {
+			planStart = node
+			continue
+		}
+
+		parent, ok := nodesById[parentId]
+		if planStart != nil {
+			if ok {
+				parent.Children = append(parent.Children, planStart)
+			} else {
+				roots = append(roots, planStart)
+			}
+			parent, ok = planStart, true
+			planStart = nil
+		}
+		if ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+		nodesById[operatorId] = node
+	}
+	return roots, nil
+}
+
+func explainPlanNodesFromText(results *ResultTable) ([]*ExplainPlanNode, error) {
+	plans, err := extractNamedColumnAsStrings(results, "PLAN")
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []*ExplainPlanNode
+	type level struct {
+		indent int
+		node   *ExplainPlanNode
+	}
+	for _, plan := range plans {
+		var stack []level
+		for _, line := range strings.Split(plan, "\n") {
+			operator := strings.TrimSpace(line)
+			if operator == "" {
+				continue
+			}
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+				stack = stack[:len(stack)-1]
+			}
+
+			node := newExplainPlanNode(operator)
+			if len(stack) == 0 {
+				roots = append(roots, node)
+			} else {
+				parent := stack[len(stack)-1].node
+				parent.Children = append(parent.Children, node)
+			}
+			stack = append(stack, level{indent: indent, node: node})
+		}
+	}
+	return roots, nil
+}
+
+func newExplainPlanNode(operator string) *ExplainPlanNode {
+	node := ExplainPlanNode{Operator: operator}
+	for _, filter := range explainPlanFilterIndexTypes {
+		if strings.HasPrefix(operator, filter.prefix) {
+			node.IndexType = filter.indexType
+			break
+		}
+	}
+	return &node
+}
+
+func walkExplainPlan(nodes []*ExplainPlanNode, visit func(node *ExplainPlanNode)) {
+	for _, node := range nodes {
+		visit(node)
+		walkExplainPlan(node.Children, visit)
+	}
+}
+
+func extractNamedColumnAsStrings(results *ResultTable, colName string) ([]string, error) {
+	colIdx, err := GetColumnIdx(results, colName)
+	if err != nil {
+		return nil, fmt.Errorf("could not extract explain plan: %w", err)
+	}
+	return ExtractColumnAsStrings(results, colIdx)
+}
+
+func extractNamedColumnAsDoubles(results *ResultTable, colName string) ([]float64, error) {
+	colIdx, err := GetColumnIdx(results, colName)
+	if err != nil {
+		return nil, fmt.Errorf("could not extract explain plan: %w", err)
+	}
+	return ExtractColumnAsDoubles(results, colIdx)
+}
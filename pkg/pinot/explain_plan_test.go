@@ -0,0 +1,136 @@
+package pinot
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExplainPlanFrom(t *testing.T) {
+	decodeResultTable := func(t *testing.T, body string) *ResultTable {
+		var resultTable ResultTable
+		require.NoError(t, decodeJson(strings.NewReader(body), &resultTable))
+		return &resultTable
+	}
+
+	t.Run("single-stage", func(t *testing.T) {
+		results := decodeResultTable(t, `{
+			"dataSchema": {"columnNames": ["Operator", "Operator_Id", "Parent_Id"], "columnDataTypes": ["STRING", "INT", "INT"]},
+			"rows": [
+				["BROKER_REDUCE(limit:10)", 1, 0],
+				["COMBINE_AGGREGATE", 2, 1],
+				["PLAN_START(numSegmentsForThisPlan:2)", -1, -1],
+				["AGGREGATE(aggregations:sum(value))", 3, 2],
+				["PROJECT(value)", 4, 3],
+				["DOC_ID_SET", 5, 4],
+				["FILTER_STARTREE_INDEX(predicate:fabric = 'a')", 6, 5],
+				["PLAN_START(numSegmentsForThisPlan:1)", -1, -1],
+				["AGGREGATE(aggregations:sum(value))", 3, 2],
+				["PROJECT(value)", 4, 3],
+				["DOC_ID_SET", 5, 4],
+				["FILTER_INVERTED_INDEX(indexLookUp:inverted_index,operator:EQ,predicate:fabric = 'a')", 6, 5]
+			]
+		}`)
+
+		got, err := ExplainPlanFrom(results)
+		require.NoError(t, err)
+
+		segmentPlan := func(filter *ExplainPlanNode) []*ExplainPlanNode {
+			return []*ExplainPlanNode{{
+				Operator: "AGGREGATE(aggregations:sum(value))",
+				Children: []*ExplainPlanNode{{
+					Operator: "PROJECT(value)",
+					Children: []*ExplainPlanNode{{
+						Operator: "DOC_ID_SET",
+						Children: []*ExplainPlanNode{filter},
+					}},
+				}},
+			}}
+		}
+		assert.Equal(t, &ExplainPlan{
+			Nodes: []*ExplainPlanNode{{
+				Operator: "BROKER_REDUCE(limit:10)",
+				Children: []*ExplainPlanNode{{
+					Operator: "COMBINE_AGGREGATE",
+					Children: []*ExplainPlanNode{
+						{
+							Operator: "PLAN_START(numSegmentsForThisPlan:2)",
+							Children: segmentPlan(&ExplainPlanNode{
+								Operator:  "FILTER_STARTREE_INDEX(predicate:fabric = 'a')",
+								IndexType: IndexTypeStarTree,
+							}),
+						},
+						{
+							Operator: "PLAN_START(numSegmentsForThisPlan:1)",
+							Children: segmentPlan(&ExplainPlanNode{
+								Operator:  "FILTER_INVERTED_INDEX(indexLookUp:inverted_index,operator:EQ,predicate:fabric = 'a')",
+								IndexType: IndexTypeInverted,
+							}),
+						},
+					},
+				}},
+			}},
+			IndexTypes: []string{IndexTypeInverted, IndexTypeStarTree},
+		}, got)
+	})
+
+	t.Run("multi-stage", func(t *testing.T) {
+		results := decodeResultTable(t, `{
+			"dataSchema": {"columnNames": ["SQL", "PLAN"], "columnDataTypes": ["STRING", "STRING"]},
+			"rows": [
+				["SELECT 1", "Execution Plan\nLogicalProject(EXPR$0=[1])\n  LogicalFilter(condition=[true])\n    PinotLogicalTableScan(table=[[default, benchmark]])\n  LogicalValues(tuples=[[]])\n"]
+			]
+		}`)
+
+		got, err := ExplainPlanFrom(results)
+		require.NoError(t, err)
+		assert.Equal(t, &ExplainPlan{
+			Nodes: []*ExplainPlanNode{
+				{Operator: "Execution Plan"},
+				{
+					Operator: "LogicalProject(EXPR$0=[1])",
+					Children: []*ExplainPlanNode{
+						{
+							Operator: "LogicalFilter(condition=[true])",
+							Children: []*ExplainPlanNode{{Operator: "PinotLogicalTableScan(table=[[default, benchmark]])"}},
+						},
+						{Operator: "LogicalValues(tuples=[[]])"},
+					},
+				},
+			},
+			IndexTypes: []string{},
+		}, got)
+	})
+
+	t.Run("unknown columns", func(t *testing.T) {
+		results := decodeResultTable(t, `{"dataSchema": {"columnNames": ["a"], "columnDataTypes": ["STRING"]}, "rows": []}`)
+		_, err := ExplainPlanFrom(results)
+		assert.Error(t, err)
+	})
+}
+
+func TestPinotClient_ExplainPlan(t *testing.T) {
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		_, _ = w.Write([]byte(`{"resultTable":{
+			"dataSchema":{"columnNames":["Operator","Operator_Id","Parent_Id"],"columnDataTypes":["STRING","INT","INT"]},
+			"rows":[["BROKER_REDUCE(limit:10)",1,0],["FILTER_RANGE_INDEX(predicate:ts > 0)",2,1]]
+		}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewPinotClient(http.DefaultClient, ClientProperties{BrokerUrl: server.URL})
+	got, err := client.ExplainPlan(context.Background(), NewSqlQuery("SELECT * FROM benchmark"))
+	require.NoError(t, err)
+	assert.Contains(t, gotBody, `"sql":"EXPLAIN PLAN FOR SELECT * FROM benchmark"`)
+	assert.Equal(t, []string{IndexTypeRange}, got.IndexTypes)
+	require.Len(t, got.Nodes, 1)
+	assert.Equal(t, "BROKER_REDUCE(limit:10)", got.Nodes[0].Operator)
+}
@@ -73,6 +73,40 @@ func GetTimeColumnFormat(tableSchema TableSchema, timeColumn string) (DateTimeFo
 	return DateTimeFormat{}, fmt.Errorf("column `%s` is not a date time column", timeColumn)
 }
 
+// GetColumnDataType returns the data type of the column, or of the map values if a key is given.
+func GetColumnDataType(tableSchema TableSchema, column string, key string) (string, bool) {
+	if key != "" {
+		for _, spec := range tableSchema.ComplexFieldSpecs {
+			if spec.Name == column {
+				return spec.ChildFieldSpecs.Value.DataType, true
+			}
+		}
+		return "", false
+	}
+
+	for _, spec := range tableSchema.DimensionFieldSpecs {
+		if spec.Name == column {
+			return spec.DataType, true
+		}
+	}
+	for _, spec := range tableSchema.MetricFieldSpecs {
+		if spec.Name == column {
+			return spec.DataType, true
+		}
+	}
+	for _, spec := range tableSchema.DateTimeFieldSpecs {
+		if spec.Name == column {
+			return spec.DataType, true
+		}
+	}
+	for _, spec := range tableSchema.ComplexFieldSpecs {
+		if spec.Name == column {
+			return spec.DataType, true
+		}
+	}
+	return "", false
+}
+
 // ExtractColumn extracts a column from the table.
 // The column data type is mapped to the corresponding golang type.
 func ExtractColumn(results *ResultTable, colIdx int) (any, error) {
@@ -0,0 +1,229 @@
+package pinot
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type FilterValueType string
+
+const (
+	FilterValueTypeString  FilterValueType = "string"
+	FilterValueTypeNumber  FilterValueType = "number"
+	FilterValueTypeBoolean FilterValueType = "boolean"
+	FilterValueTypeList    FilterValueType = "list"
+	FilterValueTypeNull    FilterValueType = "null"
+)
+
+// FilterValue is a typed value of a column filter.
+// Values are always rendered as literals, so they cannot change the structure of the query.
+// The JSON form is {"type": "string", "value": "abc"}. Lists hold their items in value, and nulls have no value.
+type FilterValue struct {
+	Type    FilterValueType
+	String  string
+	Number  json.Number
+	Boolean bool
+	List    []FilterValue
+}
+
+func StringFilterValue(val string) FilterValue {
+	return FilterValue{Type: FilterValueTypeString, String: val}
+}
+
+func NumberFilterValue(val json.Number) FilterValue {
+	return FilterValue{Type: FilterValueTypeNumber, Number: val}
+}
+
+func BooleanFilterValue(val bool) FilterValue {
+	return FilterValue{Type: FilterValueTypeBoolean, Boolean: val}
+}
+
+func ListFilterValue(vals ...FilterValue) FilterValue {
+	return FilterValue{Type: FilterValueTypeList, List: vals}
+}
+
+func NullFilterValue() FilterValue {
+	return FilterValue{Type: FilterValueTypeNull}
+}
+
+var numberLiteralRegex = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+func (x *FilterValue) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Type  FilterValueType `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	val := FilterValue{Type: raw.Type}
+	var err error
+	switch raw.Type {
+	case FilterValueTypeString:
+		err = json.Unmarshal(raw.Value, &val.String)
+	case FilterValueTypeNumber:
+		// Numbers are kept as text, so that large longs and decimals keep their precision.
+		var num json.Number
+		if err = json.Unmarshal(raw.Value, &num); err == nil && !numberLiteralRegex.MatchString(num.String()) {
+			err = fmt.Errorf("invalid number `%s`", num)
+		}
+		val.Number = num
+	case FilterValueTypeBoolean:
+		err = json.Unmarshal(raw.Value, &val.Boolean)
+	case FilterValueTypeList:
+		err = json.Unmarshal(raw.Value, &val.List)
+		for _, item := range val.List {
+			if item.Type == FilterValueTypeList {
+				err = fmt.Errorf("lists cannot be nested")
+			}
+		}
+	case FilterValueTypeNull:
+	default:
+		err = fmt.Errorf("unknown type `%s`", raw.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid filter value: %w", err)
+	}
+	*x = val
+	return nil
+}
+
+func (x FilterValue) MarshalJSON() ([]byte, error) {
+	var value any
+	switch x.Type {
+	case FilterValueTypeString:
+		value = x.String
+	case FilterValueTypeNumber:
+		value = x.Number
+	case FilterValueTypeBoolean:
+		value = x.Boolean
+	case FilterValueTypeList:
+		value = x.List
+	}
+	return json.Marshal(struct {
+		Type  FilterValueType `json:"type"`
+		Value any             `json:"value,omitempty"`
+	}{Type: x.Type, Value: value})
+}
+
+// Node returns the value as a SQL literal.
+func (x FilterValue) Node() SqlNode {
+	switch x.Type {
+	case FilterValueTypeString:
+		return StringLiteral(x.String)
+	case FilterValueTypeNumber:
+		if !numberLiteralRegex.MatchString(x.Number.String()) {
+			return NullLiteral{}
+		}
+		// The number was validated above, so the text is a plain numeric literal.
+		return Raw(x.Number)
+	case FilterValueTypeBoolean:
+		return BoolLiteral(x.Boolean)
+	case FilterValueTypeList:
+		list := make(ListExpr, len(x.List))
+		for i := range x.List {
+			list[i] = x.List[i].Node()
+		}
+		return list
+	default:
+		return NullLiteral{}
+	}
+}
+
+func (x FilterValue) Display() string {
+	switch x.Type {
+	case FilterValueTypeList:
+		items := make([]string, len(x.List))
+		for i := range x.List {
+			items[i] = x.List[i].Display()
+		}
+		return "(" + strings.Join(items, ", ") + ")"
+	default:
+		return x.Node().Expr().String()
+	}
+}
+
+// CheckDataType returns an error if the value cannot be compared with a column of the given Pinot data type.
+// Columns with an unknown data type accept any value.
+func (x FilterValue) CheckDataType(dataType string) error {
+	var ok bool
+	switch x.Type {
+	case FilterValueTypeNull:
+		ok = true
+	case FilterValueTypeList:
+		for _, item := range x.List {
+			if err := item.CheckDataType(dataType); err != nil {
+				return err
+			}
+		}
+		ok = true
+	case FilterValueTypeString:
+		switch dataType {
+		case DataTypeString, DataTypeJson, DataTypeBytes, DataTypeBigDecimal, DataTypeTimestamp:
+			ok = true
+		}
+	case FilterValueTypeNumber:
+		switch dataType {
+		case DataTypeInt:
+			_, err := strconv.ParseInt(x.Number.String(), 10, 32)
+			ok = err == nil
+		case DataTypeLong, DataTypeTimestamp:
+			_, err := strconv.ParseInt(x.Number.String(), 10, 64)
+			ok = err == nil
+		case DataTypeFloat, DataTypeDouble:
+			val, err := strconv.ParseFloat(x.Number.String(), 64)
+			ok = err == nil && !math.IsInf(val, 0)
+		case DataTypeBigDecimal:
+			ok = true
+		}
+	case FilterValueTypeBoolean:
+		ok = dataType == DataTypeBoolean
+	}
+
+	switch {
+	case ok:
+		return nil
+	case !isKnownDataType(dataType):
+		return nil
+	default:
+		return fmt.Errorf("%s value %s does not match column data type %s", x.Type, x.Display(), dataType)
+	}
+}
+
+func isKnownDataType(dataType string) bool {
+	switch dataType {
+	case DataTypeInt, DataTypeLong, DataTypeFloat, DataTypeDouble, DataTypeBigDecimal, DataTypeBoolean,
+		DataTypeTimestamp, DataTypeString, DataTypeJson, DataTypeBytes:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseFilterValueExpr parses a literal SQL expression, as rendered by ExtractColumnAsExprs, into a filter value.
+// Anything other than a single string, number, boolean or null literal is rejected.
+func ParseFilterValueExpr(expr string) (FilterValue, error) {
+	expr = strings.TrimSpace(expr)
+	switch {
+	case len(expr) >= 2 && strings.HasPrefix(expr, "'") && strings.HasSuffix(expr, "'"):
+		inner := expr[1 : len(expr)-1]
+		if strings.Contains(strings.ReplaceAll(inner, "''", ""), "'") {
+			break
+		}
+		return StringFilterValue(UnquoteStringLiteral(expr)), nil
+	case numberLiteralRegex.MatchString(expr):
+		return NumberFilterValue(json.Number(expr)), nil
+	case strings.EqualFold(expr, "true"):
+		return BooleanFilterValue(true), nil
+	case strings.EqualFold(expr, "false"):
+		return BooleanFilterValue(false), nil
+	case strings.EqualFold(expr, "null"):
+		return NullFilterValue(), nil
+	}
+	return FilterValue{}, fmt.Errorf("value expression `%s` is not a literal", expr)
+}
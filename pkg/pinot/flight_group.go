@@ -0,0 +1,85 @@
+package pinot
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup coalesces concurrent calls with the same key into a single call.
+// The shared call runs detached from the caller that started it, and it is canceled only once every waiting caller has given up.
+type flightGroup[V any] struct {
+	mu      sync.Mutex
+	flights map[string]*flight[V]
+}
+
+type flight[V any] struct {
+	done    chan struct{}
+	value   V
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func newFlightGroup[V any]() *flightGroup[V] {
+	return &flightGroup[V]{flights: make(map[string]*flight[V])}
+}
+
+// do returns the result of fn, sharing the result with concurrent callers of the same key.
+// A nil group does not share results, and calls fn for every caller.
+func (x *flightGroup[V]) do(ctx context.Context, key string, fn func(ctx context.Context) (V, error)) (V, error) {
+	if x == nil {
+		return fn(ctx)
+	}
+
+	x.mu.Lock()
+	f, ok := x.flights[key]
+	if !ok {
+		flightCtx, cancel := detachedContext(ctx)
+		f = &flight[V]{done: make(chan struct{}), cancel: cancel}
+		x.flights[key] = f
+		go func() {
+			defer close(f.done)
+			defer cancel()
+			f.value, f.err = fn(flightCtx)
+			x.remove(key, f)
+		}()
+	}
+	f.waiters++
+	x.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		x.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			x.removeLocked(key, f)
+		}
+		x.mu.Unlock()
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (x *flightGroup[V]) remove(key string, f *flight[V]) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.removeLocked(key, f)
+}
+
+func (x *flightGroup[V]) removeLocked(key string, f *flight[V]) {
+	if x.flights[key] == f {
+		delete(x.flights, key)
+	}
+}
+
+// detachedContext returns a context that keeps the values and deadline of ctx but is not canceled with it.
+func detachedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detached, deadline)
+	}
+	return context.WithCancel(detached)
+}
@@ -0,0 +1,209 @@
+package pinot
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlightGroup_Do(t *testing.T) {
+	t.Run("shared", func(t *testing.T) {
+		group := newFlightGroup[int]()
+		release := make(chan struct{})
+		var calls atomic.Int32
+		fn := func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			<-release
+			return 42, nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([]int, 5)
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], _ = group.do(context.Background(), "key", fn)
+			}()
+		}
+		assert.Eventually(t, func() bool {
+			group.mu.Lock()
+			defer group.mu.Unlock()
+			return group.flights["key"] != nil && group.flights["key"].waiters == len(results)
+		}, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, []int{42, 42, 42, 42, 42}, results)
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Empty(t, group.flights)
+	})
+
+	t.Run("canceled when all callers give up", func(t *testing.T) {
+		group := newFlightGroup[int]()
+		canceled := make(chan struct{})
+		fn := func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			close(canceled)
+			return 0, ctx.Err()
+		}
+
+		ctx1, cancel1 := context.WithCancel(context.Background())
+		ctx2, cancel2 := context.WithCancel(context.Background())
+		errs := make(chan error, 2)
+		go func() { _, err := group.do(ctx1, "key", fn); errs <- err }()
+		go func() { _, err := group.do(ctx2, "key", fn); errs <- err }()
+		assert.Eventually(t, func() bool {
+			group.mu.Lock()
+			defer group.mu.Unlock()
+			return group.flights["key"] != nil && group.flights["key"].waiters == 2
+		}, time.Second, time.Millisecond)
+
+		cancel1()
+		assert.ErrorIs(t, <-errs, context.Canceled)
+		select {
+		case <-canceled:
+			t.Fatal("shared call canceled while a caller is still waiting")
+		default:
+		}
+
+		cancel2()
+		assert.ErrorIs(t, <-errs, context.Canceled)
+		<-canceled
+	})
+
+	t.Run("keeps deadline", func(t *testing.T) {
+		group := newFlightGroup[bool]()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		got, err := group.do(ctx, "key", func(ctx context.Context) (bool, error) {
+			_, ok := ctx.Deadline()
+			return ok, nil
+		})
+		require.NoError(t, err)
+		assert.True(t, got)
+	})
+}
+
+func TestPinotClient_ExecuteSqlQuery_Coalesced(t *testing.T) {
+	release := make(chan struct{})
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"resultTable":{"dataSchema":{"columnNames":["a"],"columnDataTypes":["INT"]},"rows":[[1]]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewPinotClient(http.DefaultClient, ClientProperties{BrokerUrl: server.URL})
+
+	var wg sync.WaitGroup
+	responses := make([]*BrokerResponse, 3)
+	for i := range responses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.ExecuteSqlQuery(context.Background(), NewSqlQuery("SELECT 1"))
+			assert.NoError(t, err)
+			responses[i] = resp
+		}()
+	}
+	assert.Eventually(t, func() bool {
+		client.sqlQueryFlights.mu.Lock()
+		defer client.sqlQueryFlights.mu.Unlock()
+		for _, f := range client.sqlQueryFlights.flights {
+			return f.waiters == len(responses)
+		}
+		return false
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), requests.Load())
+	for _, resp := range responses {
+		require.NotNil(t, resp)
+		assert.Equal(t, 1, resp.ResultTable.RowCount())
+	}
+	// Each caller gets its own copy of the response.
+	assert.NotSame(t, responses[0].ResultTable, responses[1].ResultTable)
+}
+
+func TestPinotClient_OAuthPassThru_NotCoalesced(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		wait := release
+		mu.Unlock()
+		<-wait
+		if r.URL.Path == "/query/sql" {
+			_, _ = w.Write([]byte(`{"resultTable":{"dataSchema":{"columnNames":["a"],"columnDataTypes":["INT"]},"rows":[[1]]}}`))
+		} else {
+			_, _ = w.Write([]byte(`{"schemaName":"tbl"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	// Forward the authorization of the caller, like the Grafana http client does under oauth pass-through.
+	httpClient := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req.Header.Set("Authorization", req.Context().Value(authorizationKey{}).(string))
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	client := NewPinotClient(httpClient, ClientProperties{BrokerUrl: server.URL, ControllerUrl: server.URL, OAuthPassThru: true})
+
+	testCases := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{name: "sql query", call: func(ctx context.Context) error {
+			_, err := client.ExecuteSqlQuery(ctx, NewSqlQuery("SELECT 1"))
+			return err
+		}},
+		{name: "table schema", call: func(ctx context.Context) error {
+			_, err := client.GetTableSchema(ctx, "tbl")
+			return err
+		}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			tokens = nil
+			release = make(chan struct{})
+			mu.Unlock()
+
+			var wg sync.WaitGroup
+			for _, token := range []string{"Bearer user-a", "Bearer user-b"} {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					assert.NoError(t, tt.call(context.WithValue(context.Background(), authorizationKey{}, token)))
+				}()
+			}
+			assert.Eventually(t, func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(tokens) == 2
+			}, time.Second, time.Millisecond)
+			mu.Lock()
+			close(release)
+			mu.Unlock()
+			wg.Wait()
+
+			assert.ElementsMatch(t, []string{"Bearer user-a", "Bearer user-b"}, tokens)
+		})
+	}
+}
+
+type authorizationKey struct{}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
@@ -0,0 +1,164 @@
+package pinot
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultMetadataCacheTTL is how long controller metadata is served from the cache before it is refreshed.
+const DefaultMetadataCacheTTL = time.Minute
+
+// metadataCache caches the controller metadata used to render queries.
+type metadataCache struct {
+	tables       *ttlCache[[]string]
+	tableConfigs *ttlCache[ListTableConfigsResponse]
+	schemas      *ttlCache[TableSchema]
+	metadata     *ttlCache[TableMetadata]
+}
+
+func newMetadataCache(ttl time.Duration) *metadataCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &metadataCache{
+		tables:       newTtlCache[[]string](ttl),
+		tableConfigs: newTtlCache[ListTableConfigsResponse](ttl),
+		schemas:      newTtlCache[TableSchema](ttl),
+		metadata:     newTtlCache[TableMetadata](ttl),
+	}
+}
+
+// invalidate removes the cached metadata of the table, or all cached metadata if the table is empty.
+// The table list is always removed, since invalidation usually follows adding or dropping a table.
+func (x *metadataCache) invalidate(table string) {
+	x.tables.invalidate("")
+	x.tableConfigs.invalidate(table)
+	x.schemas.invalidate(table)
+	x.metadata.invalidate(table)
+}
+
+func (x *metadataCache) close() {
+	x.tables.close()
+	x.tableConfigs.close()
+	x.schemas.close()
+	x.metadata.close()
+}
+
+// cachedMetadata returns the cached value, or loads it when caching is disabled.
+func cachedMetadata[V any](ctx context.Context, p *Client, cache func(x *metadataCache) *ttlCache[V], key string, load func(ctx context.Context) (V, error)) (V, error) {
+	if p.metadataCache == nil {
+		return load(ctx)
+	}
+	key = p.cacheKeyOf(key)
+	return cache(p.metadataCache).get(ctx, key, load, func(err error) {
+		p.logger.Error("pinot/http: Failed to refresh cached metadata.", "key", key, "error", err)
+	})
+}
+
+// ttlCache is a key value cache where entries are fresh for the ttl.
+// Stale entries are served for up to another ttl while they are refreshed in the background.
+// Entries older than that are reloaded before they are returned. Errors are not cached.
+type ttlCache[V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*ttlCacheEntry[V]
+	closed  bool
+	now     func() time.Time
+
+	// generation is incremented on invalidation, so values loaded before are not cached.
+	generation int
+}
+
+type ttlCacheEntry[V any] struct {
+	value      V
+	loadedAt   time.Time
+	refreshing bool
+}
+
+func newTtlCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{
+		ttl:     ttl,
+		entries: make(map[string]*ttlCacheEntry[V]),
+		now:     time.Now,
+	}
+}
+
+func (x *ttlCache[V]) get(ctx context.Context, key string, load func(ctx context.Context) (V, error), onRefreshError func(err error)) (V, error) {
+	x.mu.Lock()
+	generation := x.generation
+	entry, ok := x.entries[key]
+	if ok {
+		age := x.now().Sub(entry.loadedAt)
+		switch {
+		case age < x.ttl:
+			x.mu.Unlock()
+			return entry.value, nil
+		case age < 2*x.ttl:
+			if !entry.refreshing {
+				entry.refreshing = true
+				go x.refresh(ctx, key, generation, entry, load, onRefreshError)
+			}
+			x.mu.Unlock()
+			return entry.value, nil
+		}
+	}
+	x.mu.Unlock()
+
+	value, err := load(ctx)
+	if err != nil {
+		return value, err
+	}
+	x.put(key, generation, value)
+	return value, nil
+}
+
+func (x *ttlCache[V]) refresh(ctx context.Context, key string, generation int, entry *ttlCacheEntry[V], load func(ctx context.Context) (V, error), onRefreshError func(err error)) {
+	refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), x.ttl)
+	defer cancel()
+
+	value, err := load(refreshCtx)
+	if err != nil {
+		x.mu.Lock()
+		entry.refreshing = false
+		x.mu.Unlock()
+		onRefreshError(err)
+		return
+	}
+	x.put(key, generation, value)
+}
+
+func (x *ttlCache[V]) put(key string, generation int, value V) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.closed || x.generation != generation {
+		return
+	}
+	// Entries that are too old to be served are dropped, so that caches with changing keys do not grow without bounds.
+	for entryKey, entry := range x.entries {
+		if x.now().Sub(entry.loadedAt) >= 2*x.ttl && !entry.refreshing {
+			delete(x.entries, entryKey)
+		}
+	}
+	x.entries[key] = &ttlCacheEntry[V]{value: value, loadedAt: x.now()}
+}
+
+// invalidate removes the entry for the key, or all entries if the key is empty.
+func (x *ttlCache[V]) invalidate(key string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.generation++
+	if key == "" {
+		clear(x.entries)
+	} else {
+		delete(x.entries, key)
+	}
+}
+
+// close removes all entries and stops caching values loaded afterward.
+func (x *ttlCache[V]) close() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.closed = true
+	clear(x.entries)
+}
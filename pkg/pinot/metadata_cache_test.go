@@ -0,0 +1,148 @@
+package pinot
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTtlCache_Get(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	newCache := func() *ttlCache[int] {
+		cache := newTtlCache[int](time.Minute)
+		cache.now = func() time.Time { return now }
+		return cache
+	}
+	failOnRefreshError := func(err error) { t.Errorf("unexpected refresh error: %s", err) }
+
+	t.Run("fresh", func(t *testing.T) {
+		cache := newCache()
+		var loads int
+		load := func(ctx context.Context) (int, error) { loads++; return loads, nil }
+
+		got, err := cache.get(ctx, "key", load, failOnRefreshError)
+		require.NoError(t, err)
+		assert.Equal(t, 1, got)
+
+		got, err = cache.get(ctx, "key", load, failOnRefreshError)
+		require.NoError(t, err)
+		assert.Equal(t, 1, got)
+		assert.Equal(t, 1, loads)
+	})
+
+	t.Run("stale while revalidate", func(t *testing.T) {
+		cache := newCache()
+		_, err := cache.get(ctx, "key", func(ctx context.Context) (int, error) { return 1, nil }, failOnRefreshError)
+		require.NoError(t, err)
+
+		cache.now = func() time.Time { return now.Add(90 * time.Second) }
+		refreshed := make(chan struct{})
+		got, err := cache.get(ctx, "key", func(ctx context.Context) (int, error) {
+			defer close(refreshed)
+			return 2, nil
+		}, failOnRefreshError)
+		require.NoError(t, err)
+		assert.Equal(t, 1, got)
+
+		<-refreshed
+		assert.Eventually(t, func() bool {
+			got, _ := cache.get(ctx, "key", func(ctx context.Context) (int, error) { return 3, nil }, failOnRefreshError)
+			return got == 2
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		cache := newCache()
+		_, err := cache.get(ctx, "key", func(ctx context.Context) (int, error) { return 1, nil }, failOnRefreshError)
+		require.NoError(t, err)
+
+		cache.now = func() time.Time { return now.Add(2 * time.Minute) }
+		got, err := cache.get(ctx, "key", func(ctx context.Context) (int, error) { return 2, nil }, failOnRefreshError)
+		require.NoError(t, err)
+		assert.Equal(t, 2, got)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		cache := newCache()
+		_, err := cache.get(ctx, "key", func(ctx context.Context) (int, error) { return 0, errors.New("unavailable") }, failOnRefreshError)
+		assert.EqualError(t, err, "unavailable")
+
+		got, err := cache.get(ctx, "key", func(ctx context.Context) (int, error) { return 1, nil }, failOnRefreshError)
+		require.NoError(t, err)
+		assert.Equal(t, 1, got)
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		cache := newCache()
+		for _, key := range []string{"a", "b"} {
+			_, err := cache.get(ctx, key, func(ctx context.Context) (int, error) { return 1, nil }, failOnRefreshError)
+			require.NoError(t, err)
+		}
+
+		cache.invalidate("a")
+		assert.NotContains(t, cache.entries, "a")
+		assert.Contains(t, cache.entries, "b")
+
+		cache.invalidate("")
+		assert.Empty(t, cache.entries)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		cache := newCache()
+		_, err := cache.get(ctx, "key", func(ctx context.Context) (int, error) { return 1, nil }, failOnRefreshError)
+		require.NoError(t, err)
+
+		cache.close()
+		assert.Empty(t, cache.entries)
+
+		_, err = cache.get(ctx, "key", func(ctx context.Context) (int, error) { return 2, nil }, failOnRefreshError)
+		require.NoError(t, err)
+		assert.Empty(t, cache.entries)
+	})
+}
+
+func TestPinotClient_MetadataCache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"schemaName":"tbl"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+
+	t.Run("enabled", func(t *testing.T) {
+		requests.Store(0)
+		client := NewPinotClient(http.DefaultClient, ClientProperties{ControllerUrl: server.URL, MetadataCacheTTL: time.Minute})
+
+		for range 3 {
+			schema, err := client.GetTableSchema(ctx, "tbl")
+			require.NoError(t, err)
+			assert.Equal(t, "tbl", schema.SchemaName)
+		}
+		assert.Equal(t, int32(1), requests.Load())
+
+		client.InvalidateMetadataCache("tbl")
+		_, err := client.GetTableSchema(ctx, "tbl")
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		requests.Store(0)
+		client := NewPinotClient(http.DefaultClient, ClientProperties{ControllerUrl: server.URL})
+
+		for range 3 {
+			_, err := client.GetTableSchema(ctx, "tbl")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), requests.Load())
+	})
+}
@@ -0,0 +1,166 @@
+package pinot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth2TokenRefreshMargin is how long before expiry a cached token is replaced.
+const OAuth2TokenRefreshMargin = 30 * time.Second
+
+// ClientCredentials configure the OAuth2 client credentials grant used to fetch bearer tokens.
+type ClientCredentials struct {
+	TokenUrl     string
+	ClientId     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// oauth2TokenSource fetches bearer tokens from the token endpoint and caches them until shortly before they expire.
+type oauth2TokenSource struct {
+	credentials ClientCredentials
+	httpClient  *http.Client
+	now         func() time.Time
+	fetches     *flightGroup[string]
+
+	mu          sync.Mutex
+	accessToken string
+	// refreshAt is zero when the token endpoint did not report an expiry. Such tokens are used until they are rejected.
+	refreshAt time.Time
+}
+
+func newOAuth2TokenSource(httpClient *http.Client, credentials ClientCredentials) *oauth2TokenSource {
+	return &oauth2TokenSource{
+		credentials: credentials,
+		httpClient:  httpClient,
+		now:         time.Now,
+		fetches:     newFlightGroup[string](),
+	}
+}
+
+// token returns the cached token, or fetches a new one if there is none or it is about to expire.
+// Concurrent callers share a single fetch, which runs detached from the caller that started it.
+// Callers that give up stop waiting without holding up the others.
+func (x *oauth2TokenSource) token(ctx context.Context) (string, error) {
+	if accessToken, ok := x.cachedToken(); ok {
+		return accessToken, nil
+	}
+	return x.fetches.do(ctx, "", func(ctx context.Context) (string, error) {
+		accessToken, expiresIn, err := x.fetchToken(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		x.mu.Lock()
+		defer x.mu.Unlock()
+		x.accessToken = accessToken
+		x.refreshAt = time.Time{}
+		if expiresIn > 0 {
+			x.refreshAt = x.now().Add(expiresIn - min(OAuth2TokenRefreshMargin, expiresIn/2))
+		}
+		return accessToken, nil
+	})
+}
+
+func (x *oauth2TokenSource) cachedToken() (string, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.accessToken != "" && (x.refreshAt.IsZero() || x.now().Before(x.refreshAt)) {
+		return x.accessToken, true
+	}
+	return "", false
+}
+
+// invalidate removes the cached token if it is the given token.
+// Comparing the token avoids discarding a token that another caller already replaced.
+func (x *oauth2TokenSource) invalidate(accessToken string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.accessToken == accessToken {
+		x.accessToken = ""
+		x.refreshAt = time.Time{}
+	}
+}
+
+func (x *oauth2TokenSource) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", x.credentials.ClientId)
+	form.Set("client_secret", x.credentials.ClientSecret)
+	if len(x.credentials.Scopes) > 0 {
+		form.Set("scope", strings.Join(x.credentials.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, x.credentials.TokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := x.httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("pinot/http: Failed to fetch oauth2 token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return "", 0, fmt.Errorf("pinot/http: Failed to fetch oauth2 token: %w", newHttpStatusError(resp.StatusCode, string(body)))
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err = decodeJson(resp.Body, &tokenResp); err != nil {
+		return "", 0, err
+	}
+	if tokenResp.AccessToken == "" {
+		return "", 0, fmt.Errorf("pinot/http: Failed to fetch oauth2 token: response has no access token")
+	}
+	return tokenResp.AccessToken, time.Duration(tokenResp.ExpiresIn) * time.Second, nil
+}
+
+// setBearerToken sets the authorization header of the request to a token from the token source.
+func (p *Client) setBearerToken(req *http.Request) error {
+	accessToken, err := p.tokenSource.token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	return nil
+}
+
+// retryWithFreshToken resends a request that was rejected with 401 once, with a newly fetched token.
+// The token may have been revoked or expired early, so the cached token is discarded.
+func (p *Client) retryWithFreshToken(req *http.Request, resp *http.Response) (*http.Response, error) {
+	if req.Body != nil && req.GetBody == nil {
+		// The body was consumed and cannot be replayed.
+		return resp, nil
+	}
+
+	p.closeResponseBody(req.Context(), resp)
+	p.tokenSource.invalidate(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	if err := p.setBearerToken(retry); err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("pinot/http: Retrying request with a fresh oauth2 token.", "method", req.Method, "url", req.URL.String())
+	return p.httpClient.Do(retry)
+}
@@ -0,0 +1,158 @@
+package pinot
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOAuth2TokenSource_Token(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "client", r.PostForm.Get("client_id"))
+		assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+		assert.Equal(t, "read write", r.PostForm.Get("scope"))
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":300}`, fetches.Add(1))
+	}))
+	t.Cleanup(server.Close)
+
+	now := time.Now()
+	source := newOAuth2TokenSource(http.DefaultClient, ClientCredentials{
+		TokenUrl:     server.URL,
+		ClientId:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	})
+	source.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	token, err := source.token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	t.Run("cached", func(t *testing.T) {
+		now = now.Add(4 * time.Minute)
+		token, err := source.token(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+	})
+
+	t.Run("refreshed before expiry", func(t *testing.T) {
+		now = now.Add(31 * time.Second)
+		token, err := source.token(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", token)
+	})
+
+	t.Run("invalidated", func(t *testing.T) {
+		source.invalidate("token-1")
+		token, err := source.token(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token-2", token)
+
+		source.invalidate("token-2")
+		token, err = source.token(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "token-3", token)
+	})
+}
+
+func TestOAuth2TokenSource_Token_Concurrent(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"access_token":"token","expires_in":300}`))
+	}))
+	t.Cleanup(server.Close)
+
+	source := newOAuth2TokenSource(http.DefaultClient, ClientCredentials{TokenUrl: server.URL})
+
+	// The first caller gives up while the fetch is in flight.
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := source.token(ctx)
+		canceled <- err
+	}()
+	require.Eventually(t, func() bool { return fetches.Load() == 1 }, time.Second, time.Millisecond)
+
+	tokens := make(chan string, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			token, err := source.token(context.Background())
+			assert.NoError(t, err)
+			tokens <- token
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		source.fetches.mu.Lock()
+		defer source.fetches.mu.Unlock()
+		return source.fetches.flights[""] != nil && source.fetches.flights[""].waiters == 4
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "token", <-tokens)
+	}
+	assert.Equal(t, int32(1), fetches.Load())
+}
+
+func TestOAuth2TokenSource_Token_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	source := newOAuth2TokenSource(http.DefaultClient, ClientCredentials{TokenUrl: server.URL})
+	_, err := source.token(context.Background())
+	assert.ErrorContains(t, err, "invalid_client")
+}
+
+func TestPinotClient_OAuth2ClientCredentials(t *testing.T) {
+	var fetches atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":300}`, fetches.Add(1))
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	// The first token is revoked, so requests with it are rejected.
+	controller := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"schemaName":"tbl"}`))
+	}))
+	t.Cleanup(controller.Close)
+
+	client := NewPinotClient(http.DefaultClient, ClientProperties{
+		ControllerUrl: controller.URL,
+		Authorization: "Basic ignored",
+		OAuth2ClientCredentials: &ClientCredentials{
+			TokenUrl:     tokenServer.URL,
+			ClientId:     "client",
+			ClientSecret: "secret",
+		},
+	})
+	t.Cleanup(client.Close)
+
+	schema, err := client.GetTableSchema(context.Background(), "tbl")
+	require.NoError(t, err)
+	assert.Equal(t, "tbl", schema.SchemaName)
+	assert.Equal(t, int32(2), fetches.Load())
+}
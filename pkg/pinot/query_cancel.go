@@ -0,0 +1,66 @@
+package pinot
+
+import (
+	"context"
+	"crypto/rand"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var queryCancellationsCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "grafana_plugin",
+		Name:      "pinot_query_cancellations_total",
+		Help:      "Total number of Pinot queries canceled after the request context ended.",
+	},
+	[]string{"status"},
+)
+
+const (
+	queryCancelStatusOk       = "ok"
+	queryCancelStatusNotFound = "not_found"
+	queryCancelStatusFailed   = "failed"
+)
+
+// QueryCancelTimeout bounds the request that cancels a query on the broker.
+const QueryCancelTimeout = 5 * time.Second
+
+func newClientQueryId() string {
+	return "grafana-" + rand.Text()
+}
+
+// cancelQuery asks the broker to stop executing the query with the client query id.
+// Brokers only accept the request when query cancellation is enabled in the broker config.
+func (p *Client) cancelQuery(ctx context.Context, brokerUrl string, clientQueryId string) {
+	cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), QueryCancelTimeout)
+	defer cancel()
+
+	req, err := p.newRequest(cancelCtx, http.MethodDelete, brokerUrl+"/clientQuery/"+url.PathEscape(clientQueryId), nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := p.doRequest(req)
+	if err != nil {
+		p.logger.Error("pinot/http: Failed to cancel query.", "broker", brokerUrl, "clientQueryId", clientQueryId, "error", err)
+		queryCancellationsCounter.WithLabelValues(queryCancelStatusFailed).Inc()
+		return
+	}
+	defer p.closeResponseBody(cancelCtx, resp)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		p.logger.Info("pinot/http: Canceled query.", "broker", brokerUrl, "clientQueryId", clientQueryId)
+		queryCancellationsCounter.WithLabelValues(queryCancelStatusOk).Inc()
+	case http.StatusNotFound:
+		// The query already completed.
+		queryCancellationsCounter.WithLabelValues(queryCancelStatusNotFound).Inc()
+	default:
+		err = p.newErrorFromResponseBody(cancelCtx, resp)
+		p.logger.Error("pinot/http: Failed to cancel query.", "broker", brokerUrl, "clientQueryId", clientQueryId, "error", err)
+		queryCancellationsCounter.WithLabelValues(queryCancelStatusFailed).Inc()
+	}
+}
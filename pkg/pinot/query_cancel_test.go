@@ -0,0 +1,81 @@
+package pinot
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPinotClient_ExecuteSqlQuery_Cancel(t *testing.T) {
+	queryIds := make(chan string, 1)
+	canceledIds := make(chan string, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/query/sql":
+			var body struct {
+				QueryOptions string `json:"queryOptions"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			queryIds <- strings.TrimPrefix(body.QueryOptions, "clientQueryId=")
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/clientQuery/"):
+			canceledIds <- strings.TrimPrefix(r.URL.Path, "/clientQuery/")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := NewPinotClient(http.DefaultClient, ClientProperties{BrokerUrl: server.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := client.ExecuteSqlQuery(ctx, NewSqlQuery("SELECT 1"))
+		errs <- err
+	}()
+
+	queryId := <-queryIds
+	cancel()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+
+	select {
+	case canceledId := <-canceledIds:
+		assert.Equal(t, queryId, canceledId)
+	case <-time.After(time.Second):
+		t.Fatal("query was not canceled on the broker")
+	}
+}
+
+func TestPinotClient_ExecuteSqlQuery_NoCancelAfterResponse(t *testing.T) {
+	canceled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			canceled <- struct{}{}
+		}
+		_, _ = w.Write([]byte(`{"requestId":"1","clientRequestId":"grafana-1","exceptions":[]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewPinotClient(http.DefaultClient, ClientProperties{BrokerUrl: server.URL})
+	resp, err := client.ExecuteSqlQuery(context.Background(), NewSqlQuery("SELECT 1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1", resp.RequestId)
+	assert.Equal(t, "grafana-1", resp.ClientRequestId)
+
+	select {
+	case <-canceled:
+		t.Fatal("completed query was canceled")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,148 @@
+package pinot
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidQueryOption is wrapped by the errors of known query options with values of the wrong type.
+var ErrInvalidQueryOption = errors.New("invalid query option")
+
+type QueryOptionType string
+
+const (
+	QueryOptionTypeBoolean QueryOptionType = "BOOLEAN"
+	QueryOptionTypeInteger QueryOptionType = "INTEGER"
+	QueryOptionTypeString  QueryOptionType = "STRING"
+)
+
+// QueryOptionSpec describes a query option that Pinot accepts.
+type QueryOptionSpec struct {
+	Name string          `json:"name"`
+	Type QueryOptionType `json:"type"`
+	// Values are the accepted values of string options. Any string is accepted when empty.
+	Values      []string `json:"values,omitempty"`
+	Description string   `json:"description"`
+}
+
+// QueryOptionCatalog lists the known Pinot query options.
+// ref https://docs.pinot.apache.org/users/user-guide-query/query-options
+var QueryOptionCatalog = []QueryOptionSpec{
+	{Name: "timeoutMs", Type: QueryOptionTypeInteger, Description: "Timeout of the query in milliseconds."},
+	{Name: "enableNullHandling", Type: QueryOptionTypeBoolean, Description: "Enables null handling of the query."},
+	{Name: "explainPlanVerbose", Type: QueryOptionTypeBoolean, Description: "Returns the explain plans of all segments."},
+	{Name: "useMultistageEngine", Type: QueryOptionTypeBoolean, Description: "Runs the query with the multi-stage engine."},
+	{Name: "maxExecutionThreads", Type: QueryOptionTypeInteger, Description: "Maximum number of threads a server uses for the query."},
+	{Name: "numReplicaGroupsToQuery", Type: QueryOptionTypeInteger, Description: "Number of replica groups the query is sent to."},
+	{Name: "minSegmentGroupTrimSize", Type: QueryOptionTypeInteger, Description: "Minimum number of groups kept when trimming segment results."},
+	{Name: "minServerGroupTrimSize", Type: QueryOptionTypeInteger, Description: "Minimum number of groups kept when trimming server results."},
+	{Name: "groupTrimThreshold", Type: QueryOptionTypeInteger, Description: "Number of groups after which results are trimmed."},
+	{Name: "numGroupsLimit", Type: QueryOptionTypeInteger, Description: "Maximum number of groups of a group by query."},
+	{Name: "skipIndexes", Type: QueryOptionTypeString, Description: "Indexes that the query does not use, such as col1=inverted,range."},
+	{Name: "skipUpsert", Type: QueryOptionTypeBoolean, Description: "Queries all records of upsert tables, including replaced ones."},
+	{Name: "useStarTree", Type: QueryOptionTypeBoolean, Description: "Uses star-tree indexes when possible."},
+	{Name: "maxRowsInJoin", Type: QueryOptionTypeInteger, Description: "Maximum number of rows in the hash table of a join."},
+	{Name: "inPredicatePreSorted", Type: QueryOptionTypeBoolean, Description: "Skips sorting the values of IN predicates."},
+	{Name: "inPredicateLookupAlgorithm", Type: QueryOptionTypeString, Values: []string{"DIVIDE_BINARY_SEARCH", "SCAN", "PLAIN_BINARY_SEARCH"},
+		Description: "Algorithm used to look up the values of IN predicates in dictionaries."},
+	{Name: "maxServerResponseSizeBytes", Type: QueryOptionTypeInteger, Description: "Maximum size of the response of each server."},
+	{Name: "maxQueryResponseSizeBytes", Type: QueryOptionTypeInteger, Description: "Maximum size of the responses of all servers."},
+}
+
+// LookupQueryOption returns the spec of the query option. Names are compared without case.
+func LookupQueryOption(name string) (QueryOptionSpec, bool) {
+	i := slices.IndexFunc(QueryOptionCatalog, func(spec QueryOptionSpec) bool { return strings.EqualFold(spec.Name, name) })
+	if i < 0 {
+		return QueryOptionSpec{}, false
+	}
+	return QueryOptionCatalog[i], true
+}
+
+// Validate returns an error if the value does not have the type of the option.
+func (x QueryOptionSpec) Validate(value string) error {
+	var valid bool
+	var expected string
+	switch x.Type {
+	case QueryOptionTypeBoolean:
+		valid = strings.EqualFold(value, "true") || strings.EqualFold(value, "false")
+		expected = "true or false"
+	case QueryOptionTypeInteger:
+		n, err := strconv.ParseInt(value, 10, 64)
+		valid = err == nil && n >= 0
+		expected = "a non-negative integer"
+	default:
+		valid = len(x.Values) == 0 || slices.ContainsFunc(x.Values, func(v string) bool { return strings.EqualFold(v, value) })
+		expected = "one of " + strings.Join(x.Values, ", ")
+	}
+
+	if !valid {
+		return fmt.Errorf("%w: value `%s` of `%s` is not %s", ErrInvalidQueryOption, value, x.Name, expected)
+	}
+	return nil
+}
+
+// ValidateQueryOptions returns an error if a known option has an invalid value. Unknown options are not checked.
+func ValidateQueryOptions(options []QueryOption) error {
+	for _, o := range options {
+		if spec, ok := LookupQueryOption(o.Name); ok {
+			if err := spec.Validate(o.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// UnknownQueryOptions returns the names of the options that are not in the catalog.
+func UnknownQueryOptions(options []QueryOption) []string {
+	var unknown []string
+	for _, o := range options {
+		if _, ok := LookupQueryOption(o.Name); !ok && !slices.Contains(unknown, o.Name) {
+			unknown = append(unknown, o.Name)
+		}
+	}
+	return unknown
+}
+
+// mergeQueryOptions combines the options of a query with the datasource defaults and the enforced options of the query policy.
+// Query options override defaults of the same name, and enforced options override both. Within a list, the last value wins.
+// Each option keeps the position where its name first appears. Names are compared without case.
+func mergeQueryOptions(query, defaults, enforced []QueryOption) []QueryOption {
+	var merged []QueryOption
+	var levels []int
+	indexByName := make(map[string]int)
+	add := func(options []QueryOption, level int) {
+		for _, o := range options {
+			name := strings.ToLower(o.Name)
+			if i, ok := indexByName[name]; !ok {
+				indexByName[name] = len(merged)
+				merged = append(merged, o)
+				levels = append(levels, level)
+			} else if level >= levels[i] {
+				merged[i] = o
+				levels[i] = level
+			}
+		}
+	}
+	add(query, 1)
+	add(defaults, 0)
+	add(enforced, 2)
+	return merged
+}
+
+// queryOptionsOf returns the options sent with the query, including the datasource defaults and the query policy's options.
+func (p *Client) queryOptionsOf(options []QueryOption) []QueryOption {
+	return mergeQueryOptions(options, p.properties.QueryOptions, p.properties.QueryPolicy.queryOptions())
+}
+
+// encodeQueryOptions returns the options in the name=value;name=value format of the broker's queryOptions parameter.
+func encodeQueryOptions(options []QueryOption) string {
+	encoded := make([]string, len(options))
+	for i, o := range options {
+		encoded[i] = o.Name + "=" + o.Value
+	}
+	return strings.Join(encoded, ";")
+}
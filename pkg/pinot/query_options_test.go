@@ -0,0 +1,121 @@
+package pinot
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQueryOptionSpec_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{name: "timeoutMs", value: "1000"},
+		{name: "timeoutMs", value: "-1", wantErr: "invalid query option: value `-1` of `timeoutMs` is not a non-negative integer"},
+		{name: "timeoutMs", value: "10s", wantErr: "invalid query option: value `10s` of `timeoutMs` is not a non-negative integer"},
+		{name: "enableNullHandling", value: "TRUE"},
+		{name: "enableNullHandling", value: "yes", wantErr: "invalid query option: value `yes` of `enableNullHandling` is not true or false"},
+		{name: "inPredicateLookupAlgorithm", value: "scan"},
+		{name: "inPredicateLookupAlgorithm", value: "HASH",
+			wantErr: "invalid query option: value `HASH` of `inPredicateLookupAlgorithm` is not one of DIVIDE_BINARY_SEARCH, SCAN, PLAIN_BINARY_SEARCH"},
+		{name: "skipIndexes", value: "col1=inverted"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			spec, ok := LookupQueryOption(tt.name)
+			require.True(t, ok)
+			err := spec.Validate(tt.value)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.ErrorIs(t, err, ErrInvalidQueryOption)
+			}
+		})
+	}
+}
+
+func TestValidateQueryOptions(t *testing.T) {
+	assert.NoError(t, ValidateQueryOptions([]QueryOption{{Name: "TIMEOUTMS", Value: "10"}, {Name: "customOption", Value: "x"}}))
+	assert.ErrorIs(t, ValidateQueryOptions([]QueryOption{{Name: "useStarTree", Value: "1"}}), ErrInvalidQueryOption)
+}
+
+func TestUnknownQueryOptions(t *testing.T) {
+	got := UnknownQueryOptions([]QueryOption{
+		{Name: "timeoutMs", Value: "10"},
+		{Name: "customOption", Value: "x"},
+		{Name: "customOption", Value: "y"},
+	})
+	assert.Equal(t, []string{"customOption"}, got)
+}
+
+func TestMergeQueryOptions(t *testing.T) {
+	got := mergeQueryOptions(
+		[]QueryOption{{Name: "timeoutMs", Value: "100"}, {Name: "maxQueryResponseSizeBytes", Value: "4096"}, {Name: "timeoutMs", Value: "200"}},
+		[]QueryOption{{Name: "enableNullHandling", Value: "true"}, {Name: "TimeoutMs", Value: "1000"}},
+		[]QueryOption{{Name: "maxQueryResponseSizeBytes", Value: "1024"}},
+	)
+	assert.Equal(t, []QueryOption{
+		{Name: "timeoutMs", Value: "200"},
+		{Name: "maxQueryResponseSizeBytes", Value: "1024"},
+		{Name: "enableNullHandling", Value: "true"},
+	}, got)
+}
+
+func TestPinotClient_ExecuteSqlQuery_InvalidQueryOption(t *testing.T) {
+	client := NewPinotClient(http.DefaultClient, ClientProperties{})
+	t.Cleanup(client.Close)
+
+	_, err := client.ExecuteSqlQuery(context.Background(), SqlQuery{
+		Sql:          "SELECT 1",
+		QueryOptions: []QueryOption{{Name: "timeoutMs", Value: "soon"}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidQueryOption)
+}
+
+func TestPinotClient_ExecuteTimeSeriesQuery_QueryOptions(t *testing.T) {
+	queryOptions := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tables/metrics/metadata":
+			_, _ = w.Write([]byte(`{"tableName":"metrics_OFFLINE"}`))
+		case TimeSeriesEndpoint + "/query_range":
+			queryOptions <- r.URL.Query().Get("queryOptions")
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewPinotClient(http.DefaultClient, ClientProperties{
+		ControllerUrl: server.URL,
+		BrokerUrl:     server.URL,
+		QueryOptions:  []QueryOption{{Name: "timeoutMs", Value: "1000"}, {Name: "enableNullHandling", Value: "true"}},
+	})
+	t.Cleanup(client.Close)
+
+	_, err := client.ExecuteTimeSeriesQuery(context.Background(), &TimeSeriesRangeQuery{
+		Language:     TimeSeriesQueryLanguagePromQl,
+		Query:        "http_requests_total",
+		Start:        time.Unix(0, 0),
+		End:          time.Unix(60, 0),
+		Step:         time.Minute,
+		TableName:    "metrics",
+		QueryOptions: []QueryOption{{Name: "timeoutMs", Value: "500"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "timeoutMs=500;enableNullHandling=true", <-queryOptions)
+
+	_, err = client.ExecuteTimeSeriesQuery(context.Background(), &TimeSeriesRangeQuery{
+		TableName:    "metrics",
+		QueryOptions: []QueryOption{{Name: "timeoutMs", Value: "-1"}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidQueryOption)
+}
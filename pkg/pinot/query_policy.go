@@ -0,0 +1,304 @@
+package pinot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrQueryPolicy is wrapped by the errors of queries that the query policy does not allow.
+var ErrQueryPolicy = errors.New("query policy")
+
+// QueryPolicy restricts the sql queries that the client sends to Pinot. The zero value allows all queries.
+// Tables are matched without case, and with any database prefix and table type suffix removed.
+type QueryPolicy struct {
+	// DeniedTables cannot be queried.
+	DeniedTables []string
+	// TimeFilterTables can only be queried with a filter on one of their time columns.
+	TimeFilterTables []string
+	// MaxLimit caps the LIMIT of queries. Sql with a larger LIMIT is rejected, so builders clamp their limit with ClampLimit.
+	// Zero means no cap.
+	MaxLimit int64
+	// MaxDocsScanned is checked with the row count of the queried table before the query runs.
+	// Only when the table has more rows than that, the docs matching the query's filter are counted.
+	// Queries are checked again with the docs they scanned once they ran. Zero disables the check.
+	MaxDocsScanned int64
+	// MaxQueryResponseSizeBytes is sent to the broker as the maxQueryResponseSizeBytes query option. Zero sends nothing.
+	MaxQueryResponseSizeBytes int64
+	// Tables hides the tables that it does not allow from table listings, and rejects their metadata lookups and queries.
+	Tables TableFilter
+}
+
+// ClampLimit returns the limit, or MaxLimit if the limit exceeds it.
+func (x QueryPolicy) ClampLimit(limit int64) int64 {
+	if x.MaxLimit > 0 && limit > x.MaxLimit {
+		return x.MaxLimit
+	}
+	return limit
+}
+
+func (x QueryPolicy) checksTables() bool {
+	return len(x.DeniedTables) > 0 || len(x.TimeFilterTables) > 0 || x.MaxDocsScanned > 0 || !x.Tables.IsEmpty()
+}
+
+func (x QueryPolicy) queryOptions() []QueryOption {
+	if x.MaxQueryResponseSizeBytes <= 0 {
+		return nil
+	}
+	return []QueryOption{{Name: "maxQueryResponseSizeBytes", Value: strconv.FormatInt(x.MaxQueryResponseSizeBytes, 10)}}
+}
+
+// checkTable returns an error if the table is denied, or not allowed by the table filter.
+func (x QueryPolicy) checkTable(table string) error {
+	if containsTable(x.DeniedTables, table) || !x.Tables.Allows(table) {
+		return fmt.Errorf("%w: table `%s` cannot be queried", ErrQueryPolicy, table)
+	}
+	return nil
+}
+
+func containsTable(tables []string, table string) bool {
+	return slices.ContainsFunc(tables, func(x string) bool { return normalizeTableName(x) == normalizeTableName(table) })
+}
+
+// checkQueryPolicy returns an error if the policy does not allow the query.
+func (p *Client) checkQueryPolicy(ctx context.Context, query SqlQuery) error {
+	policy := p.properties.QueryPolicy
+	if policy.MaxLimit <= 0 && !policy.checksTables() {
+		return nil
+	}
+
+	tokens := scanSql(query.Sql)
+	if err := checkLimits(tokens, policy.MaxLimit); err != nil {
+		return err
+	}
+
+	queried, err := p.checkQueriedTables(ctx, query)
+	if err != nil {
+		return err
+	}
+	for _, table := range queried {
+		if !containsTable(policy.TimeFilterTables, table) {
+			continue
+		}
+		schema, err := p.GetTableSchema(ctx, table)
+		if err != nil {
+			return err
+		}
+		if !filtersTimeColumn(tokens, schema) {
+			return fmt.Errorf("%w: queries of table `%s` require a time filter", ErrQueryPolicy, table)
+		}
+	}
+	if policy.MaxDocsScanned > 0 && len(queried) > 0 {
+		return p.checkDocsScanned(ctx, query.Sql, tokens, queried[0], policy.MaxDocsScanned)
+	}
+	return nil
+}
+
+// checkQueriedTables returns the tables of the query, or an error if the policy does not allow one of them.
+func (p *Client) checkQueriedTables(ctx context.Context, query SqlQuery) ([]string, error) {
+	policy := p.properties.QueryPolicy
+	if !policy.checksTables() {
+		return nil, nil
+	}
+
+	// All tables are listed, so that references to hidden tables are found.
+	tables, err := p.listCachedTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	queried := FindTableReferences(query.Sql, tables)
+	if query.TableName != "" && !containsTable(queried, query.TableName) {
+		queried = append([]string{query.TableName}, queried...)
+	}
+
+	for _, table := range queried {
+		if err := policy.checkTable(table); err != nil {
+			return nil, err
+		}
+	}
+	return queried, nil
+}
+
+func checkLimits(tokens []sqlToken, maxLimit int64) error {
+	if maxLimit <= 0 {
+		return nil
+	}
+	for i, token := range tokens {
+		if !token.isKeyword("LIMIT") {
+			continue
+		}
+		// Pinot accepts both LIMIT count and LIMIT offset, count.
+		for j := i + 1; j < len(tokens) && j <= i+3; j += 2 {
+			if tokens[j].kind != sqlTokenNumber {
+				break
+			}
+			if limit, err := strconv.ParseInt(tokens[j].text, 10, 64); err != nil || limit > maxLimit {
+				return fmt.Errorf("%w: limit %s exceeds the maximum of %d", ErrQueryPolicy, tokens[j].text, maxLimit)
+			}
+			if j+1 >= len(tokens) || tokens[j+1].text != "," {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// filtersTimeColumn returns true if a WHERE clause of the statement compares one of the schema's time columns.
+func filtersTimeColumn(tokens []sqlToken, schema TableSchema) bool {
+	isTimeColumn := func(token sqlToken) bool {
+		return slices.ContainsFunc(schema.DateTimeFieldSpecs, func(spec DateTimeFieldSpec) bool { return token.isName(spec.Name) })
+	}
+	isComparison := func(token sqlToken) bool {
+		return token.isKeyword("BETWEEN") ||
+			(token.kind == sqlTokenSymbol && slices.Contains([]string{"=", "<", "<=", ">", ">="}, token.text))
+	}
+
+	var inWhere bool
+	for i, token := range tokens {
+		switch {
+		case token.isKeyword("WHERE"):
+			inWhere = true
+		case token.isKeyword("SELECT", "GROUP", "ORDER", "HAVING", "LIMIT"):
+			inWhere = false
+		case inWhere && isTimeColumn(token):
+			if (i+1 < len(tokens) && isComparison(tokens[i+1])) || (i > 0 && isComparison(tokens[i-1])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkDocsScanned returns an error if the statement would scan more docs of the table than allowed.
+// Tables with fewer rows than the maximum pass with the row count of their cached metadata.
+// Otherwise, the docs that match the statement's filter are counted. Counts are cached per table, filter and time bucket,
+// since a count scans as many docs as the statement does.
+// The count is skipped when the filter cannot be used on its own, such as with joins, subqueries or table aliases,
+// or when the count fails. Such queries are only checked by checkDocsScannedOf once they ran.
+func (p *Client) checkDocsScanned(ctx context.Context, sql string, tokens []sqlToken, table string, maxDocs int64) error {
+	metadata, err := p.GetTableMetadata(ctx, table)
+	if err != nil {
+		p.logger.Error("pinot/http: Failed to get metadata of table.", "table", table, "error", err)
+	} else if int64(metadata.NumRows) <= maxDocs {
+		return nil
+	}
+
+	where, ok := whereClause(sql, tokens)
+	if !ok {
+		return nil
+	}
+
+	var count int64
+	if where == "" && err == nil {
+		count = int64(metadata.NumRows)
+	} else {
+		key := p.cacheKeyOf(table + " WHERE " + where)
+		var cached bool
+		if count, cached = p.docCounts.get(key); !cached {
+			count, err = p.countDocs(ctx, table, where)
+			if err != nil {
+				p.logger.Error("pinot/http: Failed to count docs of table.", "table", table, "error", err)
+				return nil
+			}
+			p.docCounts.put(key, count)
+		}
+	}
+
+	if count > maxDocs {
+		return fmt.Errorf("%w: query would scan %d docs of table `%s`, more than the maximum of %d", ErrQueryPolicy, count, table, maxDocs)
+	}
+	return nil
+}
+
+// checkDocsScannedOf returns an error if the query scanned more docs than the policy allows.
+func (p *Client) checkDocsScannedOf(resp *BrokerResponse) error {
+	maxDocs := p.properties.QueryPolicy.MaxDocsScanned
+	if maxDocs > 0 && resp.NumDocsScanned > maxDocs {
+		return fmt.Errorf("%w: query scanned %d docs, more than the maximum of %d", ErrQueryPolicy, resp.NumDocsScanned, maxDocs)
+	}
+	return nil
+}
+
+func (p *Client) countDocs(ctx context.Context, table string, where string) (int64, error) {
+	sql := fmt.Sprintf("SELECT COUNT(*) FROM %s", ObjectExpr(table))
+	if where != "" {
+		sql += " WHERE " + where
+	}
+
+	resp, err := p.doSqlQuery(ctx, SqlQuery{Sql: sql, TableName: table})
+	if err != nil {
+		return 0, err
+	} else if resp.HasExceptions() {
+		return 0, NewBrokerExceptionError(resp.Exceptions)
+	} else if !resp.HasData() {
+		return 0, nil
+	}
+
+	counts, err := ExtractColumnAsDoubles(resp.ResultTable, 0)
+	if err != nil || len(counts) == 0 {
+		return 0, err
+	}
+	return int64(counts[0]), nil
+}
+
+// DocCountBucket is how long the doc counts of query policy checks are cached.
+const DocCountBucket = time.Minute
+
+// docCountCache caches doc counts for the current time bucket.
+// Counts of earlier buckets are dropped once a count of a later bucket is cached.
+type docCountCache struct {
+	mu     sync.Mutex
+	bucket time.Time
+	counts map[string]int64
+	now    func() time.Time
+}
+
+func newDocCountCache() *docCountCache {
+	return &docCountCache{counts: make(map[string]int64), now: time.Now}
+}
+
+func (x *docCountCache) get(key string) (int64, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !x.now().Truncate(DocCountBucket).Equal(x.bucket) {
+		return 0, false
+	}
+	count, ok := x.counts[key]
+	return count, ok
+}
+
+func (x *docCountCache) put(key string, count int64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if bucket := x.now().Truncate(DocCountBucket); !bucket.Equal(x.bucket) {
+		x.bucket = bucket
+		clear(x.counts)
+	}
+	x.counts[key] = count
+}
+
+// whereClause returns the top-level WHERE clause of a select from a single table.
+// It returns false when the statement joins tables, has subqueries or combines selects.
+func whereClause(sql string, tokens []sqlToken) (string, bool) {
+	start, end := -1, len(sql)
+	for _, token := range tokens {
+		switch {
+		case token.isKeyword("JOIN", "UNION", "INTERSECT", "EXCEPT"), token.depth > 0 && token.isKeyword("SELECT"):
+			return "", false
+		case token.depth > 0:
+		case start < 0 && token.isKeyword("WHERE"):
+			start = token.end
+		case start >= 0 && end == len(sql) && (token.isKeyword("GROUP", "ORDER", "HAVING", "LIMIT", "OPTION") || token.text == ";"):
+			end = token.start
+		}
+	}
+	if start < 0 {
+		return "", true
+	}
+	return strings.TrimSpace(sql[start:end]), true
+}
@@ -0,0 +1,252 @@
+package pinot
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newQueryPolicyTestClient(t *testing.T, policy QueryPolicy) (*Client, func() []string) {
+	var mu sync.Mutex
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/tables":
+			_, _ = w.Write([]byte(`{"tables":["orders","customers","secrets"]}`))
+		case r.URL.Path == "/tables/orders/metadata":
+			_, _ = w.Write([]byte(`{"tableName":"orders_OFFLINE","numRows":100}`))
+		case r.URL.Path == "/tables/customers/metadata":
+			_, _ = w.Write([]byte(`{"tableName":"customers_OFFLINE","numRows":20}`))
+		case strings.HasSuffix(r.URL.Path, "/schema"):
+			_, _ = w.Write([]byte(`{"schemaName":"orders","dateTimeFieldSpecs":[{"name":"ts","dataType":"LONG","format":"1:MILLISECONDS:EPOCH","granularity":"1:MILLISECONDS"}]}`))
+		case r.URL.Path == "/query/sql":
+			var body struct {
+				Sql string `json:"sql"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			queries = append(queries, body.Sql)
+			mu.Unlock()
+
+			count := 100
+			if strings.Contains(body.Sql, "WHERE") {
+				count = 10
+			}
+			if strings.HasPrefix(body.Sql, "SELECT COUNT(*)") && strings.Contains(body.Sql, "broken") {
+				_, _ = w.Write([]byte(`{"exceptions":[{"errorCode":200,"message":"QueryExecutionError"}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"numDocsScanned":` + strconv.Itoa(count) +
+				`,"resultTable":{"dataSchema":{"columnNames":["count(*)"],"columnDataTypes":["LONG"]},"rows":[[` +
+				strconv.Itoa(count) + `]]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewPinotClient(http.DefaultClient, ClientProperties{ControllerUrl: server.URL, BrokerUrl: server.URL, QueryPolicy: policy})
+	t.Cleanup(client.Close)
+	return client, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), queries...)
+	}
+}
+
+func TestPinotClient_ExecuteSqlQuery_QueryPolicy(t *testing.T) {
+	ctx := context.Background()
+	newQuery := func(sql string) SqlQuery {
+		return SqlQuery{Sql: sql, TableName: "orders", SkipResultCache: true}
+	}
+
+	t.Run("denied tables", func(t *testing.T) {
+		client, _ := newQueryPolicyTestClient(t, QueryPolicy{DeniedTables: []string{"secrets"}})
+
+		_, err := client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders JOIN "SECRETS_OFFLINE" ON 1=1`))
+		assert.EqualError(t, err, "query policy: table `secrets` cannot be queried")
+		assert.ErrorIs(t, err, ErrQueryPolicy)
+
+		_, err = client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders WHERE name = 'secrets'`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("table filter", func(t *testing.T) {
+		tables, err := NewTableFilter(nil, []string{"secrets"})
+		require.NoError(t, err)
+		client, _ := newQueryPolicyTestClient(t, QueryPolicy{Tables: tables})
+
+		listed, err := client.ListTables(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"orders", "customers"}, listed)
+
+		_, err = client.GetTableSchema(ctx, "secrets")
+		assert.EqualError(t, err, "query policy: table `secrets` cannot be queried")
+		_, err = client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM secrets_OFFLINE`))
+		assert.EqualError(t, err, "query policy: table `secrets` cannot be queried")
+		_, err = client.ExplainPlan(ctx, newQuery(`SELECT * FROM orders WHERE id IN (SELECT id FROM secrets)`))
+		assert.EqualError(t, err, "query policy: table `secrets` cannot be queried")
+		_, err = client.ExecuteTimeSeriesQuery(ctx, &TimeSeriesRangeQuery{TableName: "secrets"})
+		assert.EqualError(t, err, "query policy: table `secrets` cannot be queried")
+
+		_, err = client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("max limit", func(t *testing.T) {
+		client, _ := newQueryPolicyTestClient(t, QueryPolicy{MaxLimit: 100})
+
+		_, err := client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders LIMIT 1000`))
+		assert.EqualError(t, err, "query policy: limit 1000 exceeds the maximum of 100")
+		_, err = client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders LIMIT 10, 1000`))
+		assert.EqualError(t, err, "query policy: limit 1000 exceeds the maximum of 100")
+		_, err = client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders LIMIT 100 -- LIMIT 1000`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("time filter", func(t *testing.T) {
+		client, _ := newQueryPolicyTestClient(t, QueryPolicy{TimeFilterTables: []string{"orders"}})
+
+		for _, sql := range []string{
+			`SELECT * FROM orders`,
+			`SELECT ts FROM orders WHERE name = 'ts > 0' ORDER BY ts`,
+			`SELECT * FROM orders WHERE other_ts > 0`,
+		} {
+			_, err := client.ExecuteSqlQuery(ctx, newQuery(sql))
+			assert.EqualError(t, err, "query policy: queries of table `orders` require a time filter", sql)
+		}
+		for _, sql := range []string{
+			`SELECT * FROM orders WHERE "ts" >= 1000 AND "ts" < 2000`,
+			`SELECT * FROM orders o WHERE o.ts BETWEEN 1000 AND 2000`,
+			`SELECT * FROM orders WHERE 1000 <= ts`,
+		} {
+			_, err := client.ExecuteSqlQuery(ctx, newQuery(sql))
+			assert.NoError(t, err, sql)
+		}
+
+		_, err := client.ExecuteSqlQuery(ctx, SqlQuery{Sql: `SELECT * FROM customers`, TableName: "customers"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("max docs scanned", func(t *testing.T) {
+		client, queries := newQueryPolicyTestClient(t, QueryPolicy{MaxDocsScanned: 50})
+
+		_, err := client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders WHERE "ts" >= 1000 GROUP BY x LIMIT 10`))
+		require.NoError(t, err)
+		// The first queries detect the cluster capabilities.
+		got := queries()
+		assert.Equal(t, []string{
+			`SELECT COUNT(*) FROM "orders" WHERE "ts" >= 1000`,
+			`SELECT * FROM orders WHERE "ts" >= 1000 GROUP BY x LIMIT 10`,
+		}, got[len(got)-2:])
+
+		// Counts are cached for the time bucket.
+		sent := len(queries())
+		_, err = client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders WHERE "ts" >= 1000 GROUP BY x LIMIT 10`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{`SELECT * FROM orders WHERE "ts" >= 1000 GROUP BY x LIMIT 10`}, queries()[sent:])
+
+		// Without a filter, the query would scan the rows of the table's metadata.
+		sent = len(queries())
+		_, err = client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders`))
+		assert.EqualError(t, err, "query policy: query would scan 100 docs of table `orders`, more than the maximum of 50")
+		assert.Empty(t, queries()[sent:])
+
+		// Tables with fewer rows than the maximum are not counted.
+		sent = len(queries())
+		_, err = client.ExecuteSqlQuery(ctx, SqlQuery{Sql: `SELECT * FROM customers WHERE x = 1`, TableName: "customers"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`SELECT * FROM customers WHERE x = 1`}, queries()[sent:])
+
+		// Without a usable filter, the query is checked by the docs it scanned, instead of counting the whole table.
+		sent = len(queries())
+		_, err = client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders WHERE id IN (SELECT id FROM customers WHERE x = 1)`))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`SELECT * FROM orders WHERE id IN (SELECT id FROM customers WHERE x = 1)`}, queries()[sent:])
+
+		_, err = client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders JOIN customers ON 1 = 1`))
+		assert.EqualError(t, err, "query policy: query scanned 100 docs, more than the maximum of 50")
+
+		_, err = client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders WHERE "broken" = 1`))
+		assert.NoError(t, err)
+	})
+
+	t.Run("clamp limit", func(t *testing.T) {
+		assert.Equal(t, int64(100), QueryPolicy{MaxLimit: 100}.ClampLimit(100_000))
+		assert.Equal(t, int64(10), QueryPolicy{MaxLimit: 100}.ClampLimit(10))
+		assert.Equal(t, int64(100_000), QueryPolicy{}.ClampLimit(100_000))
+	})
+
+	t.Run("results over max docs scanned are not cached", func(t *testing.T) {
+		client, queries := newQueryPolicyTestClient(t, QueryPolicy{MaxDocsScanned: 50})
+		client.queryResultCache = newQueryResultCache(time.Minute, 0)
+
+		for range 2 {
+			_, err := client.ExecuteSqlQuery(ctx, newQuery(`SELECT * FROM orders JOIN customers ON 1 = 1`))
+			assert.EqualError(t, err, "query policy: query scanned 100 docs, more than the maximum of 50")
+		}
+		got := queries()
+		assert.Equal(t, []string{`SELECT * FROM orders JOIN customers ON 1 = 1`, `SELECT * FROM orders JOIN customers ON 1 = 1`}, got[len(got)-2:])
+	})
+
+	t.Run("max query response size", func(t *testing.T) {
+		client, _ := newQueryPolicyTestClient(t, QueryPolicy{MaxQueryResponseSizeBytes: 1024})
+		assert.Equal(t, "SELECT 1;\n\nSET maxQueryResponseSizeBytes=1024;", client.RenderSql(NewSqlQuery("SELECT 1")))
+	})
+
+	t.Run("tables are not checked without table rules", func(t *testing.T) {
+		client := NewPinotClient(http.DefaultClient, ClientProperties{QueryPolicy: QueryPolicy{MaxLimit: 10}})
+		t.Cleanup(client.Close)
+		assert.NoError(t, client.checkQueryPolicy(ctx, NewSqlQuery("SELECT 1")))
+	})
+}
+
+func TestWhereClause(t *testing.T) {
+	testCases := []struct {
+		sql    string
+		want   string
+		wantOk bool
+	}{
+		{sql: `SELECT * FROM t`, want: ``, wantOk: true},
+		{sql: `SELECT * FROM t WHERE a = 'GROUP BY' AND f(b) > 1 ORDER BY a LIMIT 5`, want: `a = 'GROUP BY' AND f(b) > 1`, wantOk: true},
+		{sql: `SELECT * FROM t WHERE a = 1;`, want: `a = 1`, wantOk: true},
+		{sql: `SELECT * FROM t JOIN u ON t.id = u.id WHERE a = 1`, wantOk: false},
+		{sql: `SELECT * FROM (SELECT * FROM t) WHERE a = 1`, wantOk: false},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.sql, func(t *testing.T) {
+			got, ok := whereClause(tt.sql, scanSql(tt.sql))
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDocCountCache(t *testing.T) {
+	cache := newDocCountCache()
+	now := time.Unix(0, 0)
+	cache.now = func() time.Time { return now }
+
+	_, ok := cache.get("orders")
+	assert.False(t, ok)
+
+	cache.put("orders", 10)
+	count, ok := cache.get("orders")
+	assert.True(t, ok)
+	assert.Equal(t, int64(10), count)
+
+	now = now.Add(DocCountBucket)
+	_, ok = cache.get("orders")
+	assert.False(t, ok)
+
+	cache.put("customers", 20)
+	assert.Equal(t, map[string]int64{"customers": 20}, cache.counts)
+}
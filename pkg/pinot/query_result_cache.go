@@ -0,0 +1,99 @@
+package pinot
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultQueryResultCacheMaxBytes bounds the memory used by cached broker responses when no limit is configured.
+const DefaultQueryResultCacheMaxBytes = 64 << 20
+
+// queryResultCache is an LRU cache of broker response bodies bounded by their total size.
+// Entries expire after the ttl.
+type queryResultCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	maxBytes int64
+	bytes    int64
+	entries  map[string]*list.Element
+	lru      *list.List
+	closed   bool
+	now      func() time.Time
+}
+
+type queryResultCacheEntry struct {
+	key      string
+	body     []byte
+	cachedAt time.Time
+}
+
+func newQueryResultCache(ttl time.Duration, maxBytes int64) *queryResultCache {
+	if ttl <= 0 {
+		return nil
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultQueryResultCacheMaxBytes
+	}
+	return &queryResultCache{
+		ttl:      ttl,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		now:      time.Now,
+	}
+}
+
+// get returns the cached response body and the time it was cached.
+func (x *queryResultCache) get(key string) ([]byte, time.Time, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	elem, ok := x.entries[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	entry := elem.Value.(*queryResultCacheEntry)
+	if x.now().Sub(entry.cachedAt) >= x.ttl {
+		x.remove(elem)
+		return nil, time.Time{}, false
+	}
+	x.lru.MoveToFront(elem)
+	return entry.body, entry.cachedAt, true
+}
+
+// put caches the response body, evicting the least recently used entries to stay within the size limit.
+// Bodies larger than the limit are not cached.
+func (x *queryResultCache) put(key string, body []byte) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.closed || int64(len(body)) > x.maxBytes {
+		return
+	}
+	if elem, ok := x.entries[key]; ok {
+		x.remove(elem)
+	}
+
+	x.entries[key] = x.lru.PushFront(&queryResultCacheEntry{key: key, body: body, cachedAt: x.now()})
+	x.bytes += int64(len(body))
+	for x.bytes > x.maxBytes {
+		x.remove(x.lru.Back())
+	}
+}
+
+func (x *queryResultCache) remove(elem *list.Element) {
+	entry := x.lru.Remove(elem).(*queryResultCacheEntry)
+	delete(x.entries, entry.key)
+	x.bytes -= int64(len(entry.body))
+}
+
+// close removes all entries and stops caching responses received afterward.
+func (x *queryResultCache) close() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.closed = true
+	clear(x.entries)
+	x.lru.Init()
+	x.bytes = 0
+}
@@ -0,0 +1,175 @@
+package pinot
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQueryResultCache(t *testing.T) {
+	now := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+	newCache := func(maxBytes int64) *queryResultCache {
+		cache := newQueryResultCache(time.Minute, maxBytes)
+		cache.now = func() time.Time { return now }
+		return cache
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Nil(t, newQueryResultCache(0, 100))
+	})
+
+	t.Run("get", func(t *testing.T) {
+		cache := newCache(100)
+		cache.put("a", []byte("result"))
+
+		got, cachedAt, ok := cache.get("a")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("result"), got)
+		assert.Equal(t, now, cachedAt)
+
+		_, _, ok = cache.get("b")
+		assert.False(t, ok)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		cache := newCache(100)
+		cache.put("a", []byte("result"))
+		cache.now = func() time.Time { return now.Add(time.Minute) }
+
+		_, _, ok := cache.get("a")
+		assert.False(t, ok)
+		assert.Zero(t, cache.bytes)
+	})
+
+	t.Run("evicts least recently used", func(t *testing.T) {
+		cache := newCache(10)
+		cache.put("a", []byte("aaaa"))
+		cache.put("b", []byte("bbbb"))
+		cache.get("a")
+		cache.put("c", []byte("cccc"))
+
+		_, _, ok := cache.get("b")
+		assert.False(t, ok)
+		_, _, ok = cache.get("a")
+		assert.True(t, ok)
+		_, _, ok = cache.get("c")
+		assert.True(t, ok)
+		assert.Equal(t, int64(8), cache.bytes)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		cache := newCache(4)
+		cache.put("a", []byte("aaaaa"))
+		_, _, ok := cache.get("a")
+		assert.False(t, ok)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		cache := newCache(100)
+		cache.put("a", []byte("aaaa"))
+		cache.put("a", []byte("aa"))
+		got, _, ok := cache.get("a")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("aa"), got)
+		assert.Equal(t, int64(2), cache.bytes)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		cache := newCache(100)
+		cache.put("a", []byte("aaaa"))
+		cache.close()
+		cache.put("b", []byte("bbbb"))
+
+		_, _, ok := cache.get("a")
+		assert.False(t, ok)
+		_, _, ok = cache.get("b")
+		assert.False(t, ok)
+		assert.Zero(t, cache.bytes)
+	})
+}
+
+func TestPinotClient_ExecuteSqlQuery_ResultCache(t *testing.T) {
+	var requests atomic.Int32
+	var body atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(body.Load().(string)))
+	}))
+	t.Cleanup(server.Close)
+
+	const okBody = `{"resultTable":{"dataSchema":{"columnNames":["a"],"columnDataTypes":["INT"]},"rows":[[1]]}}`
+	const partialBody = `{"resultTable":{"dataSchema":{"columnNames":["a"],"columnDataTypes":["INT"]},"rows":[[1]]},"exceptions":[{"errorCode":427,"message":"1 servers not responded"}]}`
+
+	ctx := context.Background()
+	newClient := func() *Client {
+		return NewPinotClient(http.DefaultClient, ClientProperties{BrokerUrl: server.URL, QueryResultCacheTTL: time.Minute})
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		requests.Store(0)
+		body.Store(okBody)
+		client := newClient()
+
+		resp, err := client.ExecuteSqlQuery(ctx, NewSqlQuery("SELECT 1"))
+		require.NoError(t, err)
+		assert.False(t, resp.IsCached())
+
+		resp, err = client.ExecuteSqlQuery(ctx, NewSqlQuery("SELECT 1"))
+		require.NoError(t, err)
+		assert.True(t, resp.IsCached())
+		assert.Equal(t, 1, resp.ResultTable.RowCount())
+		assert.Equal(t, int32(1), requests.Load())
+
+		_, err = client.ExecuteSqlQuery(ctx, NewSqlQuery("SELECT 2"))
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("skip result cache", func(t *testing.T) {
+		requests.Store(0)
+		body.Store(okBody)
+		client := newClient()
+
+		query := NewSqlQuery("SELECT 1")
+		query.SkipResultCache = true
+		for range 2 {
+			resp, err := client.ExecuteSqlQuery(ctx, query)
+			require.NoError(t, err)
+			assert.False(t, resp.IsCached())
+		}
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("traced queries are not cached", func(t *testing.T) {
+		requests.Store(0)
+		body.Store(okBody)
+		client := newClient()
+
+		query := NewSqlQuery("SELECT 1")
+		query.Trace = true
+		for range 2 {
+			resp, err := client.ExecuteSqlQuery(ctx, query)
+			require.NoError(t, err)
+			assert.False(t, resp.IsCached())
+		}
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("partial results are not cached", func(t *testing.T) {
+		requests.Store(0)
+		body.Store(partialBody)
+		client := newClient()
+
+		for range 2 {
+			resp, err := client.ExecuteSqlQuery(ctx, NewSqlQuery("SELECT 1"))
+			require.NoError(t, err)
+			assert.False(t, resp.IsCached())
+		}
+		assert.Equal(t, int32(2), requests.Load())
+	})
+}
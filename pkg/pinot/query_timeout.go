@@ -0,0 +1,100 @@
+package pinot
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryTimeoutMargin is kept between the timeout of a query and the deadline of its request,
+// so that the broker's timeout response arrives before the request is abandoned.
+// Short deadlines keep a tenth of the time left instead.
+const QueryTimeoutMargin = 500 * time.Millisecond
+
+// QueryTimeoutError is returned when a query does not complete before its timeout or the deadline of its request.
+// It matches BrokerErrorKindQueryTimeout with errors.Is.
+type QueryTimeoutError struct {
+	// Timeout is the timeout of the query, or zero if the query had none.
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *QueryTimeoutError) Error() string {
+	msg := "The query timed out"
+	if e.Timeout > 0 {
+		msg += " after " + e.Timeout.String()
+	}
+	return msg + ". Narrow the time range or add filters.\n" + e.Err.Error()
+}
+
+func (e *QueryTimeoutError) Unwrap() error { return e.Err }
+
+func (e *QueryTimeoutError) Is(target error) bool {
+	return target == BrokerErrorKindQueryTimeout
+}
+
+// withQueryTimeout returns the query with a timeoutMs option derived from the time left before the deadline of the context,
+// or the timeout of the http client, unless the query or the client properties set the option.
+// A timeoutMs option that exceeds the timeout of the http client is lowered to it, since the request would be abandoned first.
+// It also returns the timeout of the query, or zero if there is none.
+func (p *Client) withQueryTimeout(ctx context.Context, query SqlQuery) (SqlQuery, time.Duration) {
+	for _, o := range p.queryOptionsOf(query.QueryOptions) {
+		if strings.EqualFold(o.Name, "timeoutMs") {
+			ms, err := strconv.ParseInt(o.Value, 10, 64)
+			if err != nil || ms <= 0 {
+				return query, 0
+			}
+			timeout := time.Duration(ms) * time.Millisecond
+			if clientTimeout := queryTimeoutOf(p.httpClient.Timeout); clientTimeout > 0 && timeout > clientTimeout {
+				return withTimeoutMs(query, clientTimeout), clientTimeout
+			}
+			return query, timeout
+		}
+	}
+
+	remaining := p.httpClient.Timeout
+	if deadline, ok := ctx.Deadline(); ok && (remaining <= 0 || time.Until(deadline) < remaining) {
+		remaining = time.Until(deadline)
+	}
+	if remaining <= 0 {
+		return query, 0
+	}
+
+	timeout := queryTimeoutOf(remaining)
+	return withTimeoutMs(query, timeout), timeout
+}
+
+// queryTimeoutOf returns the timeout of a query that must complete within the time left, keeping the QueryTimeoutMargin.
+func queryTimeoutOf(remaining time.Duration) time.Duration {
+	if remaining <= 0 {
+		return 0
+	}
+	return max(remaining-min(QueryTimeoutMargin, remaining/10), time.Millisecond)
+}
+
+// withTimeoutMs returns the query with its timeoutMs option set to the timeout.
+// The option of the query overrides the option of the client properties.
+func withTimeoutMs(query SqlQuery, timeout time.Duration) SqlQuery {
+	query.QueryOptions = slices.DeleteFunc(slices.Clone(query.QueryOptions), func(o QueryOption) bool {
+		return strings.EqualFold(o.Name, "timeoutMs")
+	})
+	query.QueryOptions = append(query.QueryOptions, QueryOption{
+		Name:  "timeoutMs",
+		Value: strconv.FormatInt(timeout.Milliseconds(), 10),
+	})
+	return query
+}
+
+// newQueryTimeoutError returns a *QueryTimeoutError if the query failed because it ran out of time, or else the error.
+func newQueryTimeoutError(ctx context.Context, err error, timeout time.Duration) error {
+	var urlErr *url.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) ||
+		(errors.As(err, &urlErr) && urlErr.Timeout()) {
+		return &QueryTimeoutError{Timeout: timeout, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+package pinot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPinotClient_WithQueryTimeout(t *testing.T) {
+	timeoutMsOf := func(t *testing.T, query SqlQuery) int64 {
+		t.Helper()
+		require.Len(t, query.QueryOptions, 1)
+		require.Equal(t, "timeoutMs", query.QueryOptions[0].Name)
+		ms, err := strconv.ParseInt(query.QueryOptions[0].Value, 10, 64)
+		require.NoError(t, err)
+		return ms
+	}
+
+	t.Run("no deadline", func(t *testing.T) {
+		client := NewPinotClient(http.DefaultClient, ClientProperties{})
+		got, timeout := client.withQueryTimeout(context.Background(), NewSqlQuery("SELECT 1"))
+		assert.Equal(t, NewSqlQuery("SELECT 1"), got)
+		assert.Zero(t, timeout)
+	})
+
+	t.Run("context deadline", func(t *testing.T) {
+		client := NewPinotClient(http.DefaultClient, ClientProperties{})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		got, timeout := client.withQueryTimeout(ctx, NewSqlQuery("SELECT 1"))
+		ms := timeoutMsOf(t, got)
+		assert.InDelta(t, 9500, ms, 100)
+		assert.Equal(t, time.Duration(ms)*time.Millisecond, timeout.Truncate(time.Millisecond))
+	})
+
+	t.Run("short context deadline", func(t *testing.T) {
+		client := NewPinotClient(http.DefaultClient, ClientProperties{})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		got, _ := client.withQueryTimeout(ctx, NewSqlQuery("SELECT 1"))
+		assert.InDelta(t, 900, timeoutMsOf(t, got), 50)
+	})
+
+	t.Run("http client timeout", func(t *testing.T) {
+		client := NewPinotClient(&http.Client{Timeout: 20 * time.Second}, ClientProperties{})
+		got, _ := client.withQueryTimeout(context.Background(), NewSqlQuery("SELECT 1"))
+		assert.Equal(t, int64(19500), timeoutMsOf(t, got))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		got, _ = client.withQueryTimeout(ctx, NewSqlQuery("SELECT 1"))
+		assert.InDelta(t, 9500, timeoutMsOf(t, got), 100)
+	})
+
+	t.Run("explicit timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		client := NewPinotClient(http.DefaultClient, ClientProperties{})
+		query := SqlQuery{Sql: "SELECT 1", QueryOptions: []QueryOption{{Name: "timeoutMs", Value: "60000"}}}
+		got, timeout := client.withQueryTimeout(ctx, query)
+		assert.Equal(t, query, got)
+		assert.Equal(t, time.Minute, timeout)
+
+		client = NewPinotClient(http.DefaultClient, ClientProperties{QueryOptions: []QueryOption{{Name: "timeoutMs", Value: "3000"}}})
+		got, timeout = client.withQueryTimeout(ctx, NewSqlQuery("SELECT 1"))
+		assert.Equal(t, NewSqlQuery("SELECT 1"), got)
+		assert.Equal(t, 3*time.Second, timeout)
+	})
+
+	t.Run("explicit timeout exceeds the http client timeout", func(t *testing.T) {
+		client := NewPinotClient(&http.Client{Timeout: 20 * time.Second}, ClientProperties{})
+		query := SqlQuery{Sql: "SELECT 1", QueryOptions: []QueryOption{{Name: "timeoutMs", Value: "60000"}}}
+		got, timeout := client.withQueryTimeout(context.Background(), query)
+		assert.Equal(t, int64(19500), timeoutMsOf(t, got))
+		assert.Equal(t, 19500*time.Millisecond, timeout)
+		assert.Equal(t, "60000", query.QueryOptions[0].Value)
+
+		client = NewPinotClient(&http.Client{Timeout: 20 * time.Second}, ClientProperties{QueryOptions: []QueryOption{{Name: "timeoutMs", Value: "60000"}}})
+		got, timeout = client.withQueryTimeout(context.Background(), NewSqlQuery("SELECT 1"))
+		assert.Equal(t, "SELECT 1;\n\nSET timeoutMs=19500;", client.RenderSql(got))
+		assert.Equal(t, 19500*time.Millisecond, timeout)
+
+		query.QueryOptions[0].Value = "3000"
+		got, timeout = client.withQueryTimeout(context.Background(), query)
+		assert.Equal(t, query, got)
+		assert.Equal(t, 3*time.Second, timeout)
+	})
+}
+
+func TestPinotClient_ExecuteSqlQuery_Timeout(t *testing.T) {
+	sqls := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/query/sql" {
+			// Requests that cancel abandoned queries.
+			return
+		}
+		var body struct {
+			Sql string `json:"sql"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		sqls <- body.Sql
+		if strings.Contains(body.Sql, "sleep") {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte(`{"resultTable":{"dataSchema":{"columnNames":["x"],"columnDataTypes":["INT"]},"rows":[[1]]}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewPinotClient(http.DefaultClient, ClientProperties{BrokerUrl: server.URL, ControllerUrl: server.URL})
+	t.Cleanup(client.Close)
+
+	t.Run("timeout is derived from the deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_, err := client.ExecuteSqlQuery(ctx, NewSqlQuery("SELECT 1"))
+		require.NoError(t, err)
+		assert.Regexp(t, `^SELECT 1;\n\nSET timeoutMs=9\d{3};$`, <-sqls)
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		_, err := client.ExecuteSqlQuery(ctx, NewSqlQuery("SELECT sleep"))
+		<-sqls
+		var timeoutErr *QueryTimeoutError
+		require.True(t, errors.As(err, &timeoutErr), "%v", err)
+		assert.True(t, errors.Is(err, BrokerErrorKindQueryTimeout))
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("explicit timeout bounds the request", func(t *testing.T) {
+		query := SqlQuery{Sql: "SELECT sleep", QueryOptions: []QueryOption{{Name: "timeoutMs", Value: "100"}}}
+
+		start := time.Now()
+		_, err := client.ExecuteSqlQuery(context.Background(), query)
+		assert.Equal(t, "SELECT sleep;\n\nSET timeoutMs=100;", <-sqls)
+		assert.Less(t, time.Since(start), 2*time.Second)
+
+		var timeoutErr *QueryTimeoutError
+		require.True(t, errors.As(err, &timeoutErr), "%v", err)
+		assert.Equal(t, 100*time.Millisecond, timeoutErr.Timeout)
+	})
+}
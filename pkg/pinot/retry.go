@@ -0,0 +1,149 @@
+package pinot
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var requestRetriesCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "grafana_plugin",
+		Name:      "pinot_request_retries_total",
+		Help:      "Total number of retried requests to Pinot.",
+	},
+	[]string{"target", "reason"},
+)
+
+// RetryPolicy configures retries of requests that failed with a transient error.
+// The zero value disables retries.
+type RetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     2,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+	}
+}
+
+// backoff returns the jittered exponential backoff before the given retry.
+func (x RetryPolicy) backoff(retry int) time.Duration {
+	backoff := x.InitialBackoff
+	for i := 0; i < retry && backoff < x.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, x.MaxBackoff)
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+const (
+	retryTargetBroker     = "broker"
+	retryTargetController = "controller"
+)
+
+// doWithRetries calls attempt until it succeeds, fails with a non-transient error or the retries are exhausted.
+// Retries wait for the jittered exponential backoff or the Retry-After duration sent by the server.
+// No retry is attempted if the wait would exceed the context deadline.
+func (p *Client) doWithRetries(ctx context.Context, target string, attempt func() (*http.Response, error)) (*http.Response, error) {
+	policy := p.properties.RetryPolicy
+	for retry := 0; ; retry++ {
+		resp, err := attempt()
+		reason, ok := retryReasonOf(ctx, resp, err)
+		if !ok || retry >= policy.MaxRetries {
+			return resp, err
+		}
+
+		wait := policy.backoff(retry)
+		if retryAfter, ok := retryAfterOf(responseHeaderOf(resp, err)); ok {
+			if retryAfter > policy.MaxBackoff {
+				return resp, err
+			}
+			wait = max(wait, retryAfter)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return resp, err
+		}
+
+		if resp != nil {
+			p.closeResponseBody(ctx, resp)
+		}
+		p.logger.Info("pinot/http: Retrying request.", "target", target, "reason", reason, "retry", retry+1, "wait", wait)
+		requestRetriesCounter.WithLabelValues(target, reason).Inc()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryReasonOf returns the reason to retry a request, or false if the request should not be retried.
+func retryReasonOf(ctx context.Context, resp *http.Response, err error) (string, bool) {
+	var statusErr *HttpStatusError
+	var urlErr *url.Error
+	switch {
+	case ctx.Err() != nil:
+		return "", false
+	case errors.As(err, &statusErr) && isRetryableStatus(statusErr.StatusCode):
+		return strconv.Itoa(statusErr.StatusCode), true
+	case errors.As(err, &urlErr) && urlErr.Op != "parse":
+		// The request could not be sent or the connection failed.
+		return "error", true
+	case resp != nil && isRetryableStatus(resp.StatusCode):
+		return strconv.Itoa(resp.StatusCode), true
+	default:
+		return "", false
+	}
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || isBrokerUnavailableStatus(statusCode)
+}
+
+func responseHeaderOf(resp *http.Response, err error) http.Header {
+	var statusErr *HttpStatusError
+	switch {
+	case resp != nil:
+		return resp.Header
+	case errors.As(err, &statusErr):
+		return statusErr.header
+	default:
+		return nil
+	}
+}
+
+// retryAfterOf parses the Retry-After header, which holds either a number of seconds or an http date.
+func retryAfterOf(header http.Header) (time.Duration, bool) {
+	retryAfter := header.Get("Retry-After")
+	if retryAfter == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		return max(0, time.Until(date)), true
+	}
+	return 0, false
+}
+
+func isIdempotentRequest(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
@@ -0,0 +1,164 @@
+package pinot
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 5, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for retry, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		got := policy.backoff(retry)
+		assert.GreaterOrEqual(t, got, want/2, "retry %d", retry)
+		assert.LessOrEqual(t, got, want, "retry %d", retry)
+	}
+	assert.Zero(t, RetryPolicy{}.backoff(0))
+}
+
+func TestRetryAfterOf(t *testing.T) {
+	newHeader := func(retryAfter string) http.Header {
+		header := http.Header{}
+		if retryAfter != "" {
+			header.Set("Retry-After", retryAfter)
+		}
+		return header
+	}
+
+	t.Run("seconds", func(t *testing.T) {
+		got, ok := retryAfterOf(newHeader("3"))
+		assert.True(t, ok)
+		assert.Equal(t, 3*time.Second, got)
+	})
+
+	t.Run("date", func(t *testing.T) {
+		got, ok := retryAfterOf(newHeader(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)))
+		assert.True(t, ok)
+		assert.InDelta(t, time.Hour, got, float64(5*time.Second))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, ok := retryAfterOf(newHeader(""))
+		assert.False(t, ok)
+		_, ok = retryAfterOf(nil)
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, ok := retryAfterOf(newHeader("soon"))
+		assert.False(t, ok)
+	})
+}
+
+func TestPinotClient_Retries(t *testing.T) {
+	newServer := func(failures int, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if int(requests.Add(1)) <= failures {
+				for k, v := range header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(status)
+				return
+			}
+			switch r.URL.Path {
+			case "/query/sql":
+				_, _ = w.Write([]byte(`{"resultTable":{"dataSchema":{"columnNames":["a"],"columnDataTypes":["INT"]},"rows":[[1]]}}`))
+			default:
+				_, _ = w.Write([]byte(`{"tableName":"tbl"}`))
+			}
+		}))
+		t.Cleanup(server.Close)
+		return server, &requests
+	}
+
+	newClient := func(url string, policy RetryPolicy) *Client {
+		return NewPinotClient(http.DefaultClient, ClientProperties{
+			ControllerUrl: url,
+			BrokerUrl:     url,
+			RetryPolicy:   policy,
+		})
+	}
+
+	policy := RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	t.Run("broker recovers", func(t *testing.T) {
+		server, requests := newServer(2, http.StatusServiceUnavailable, nil)
+		retries := requestRetriesCounter.WithLabelValues(retryTargetBroker, "503")
+		retriesBefore := testutil.ToFloat64(retries)
+		resp, err := newClient(server.URL, policy).ExecuteSqlQuery(context.Background(), NewSqlQuery("SELECT 1"))
+		require.NoError(t, err)
+		assert.Equal(t, 1, resp.ResultTable.RowCount())
+		assert.Equal(t, int32(3), requests.Load())
+		assert.Equal(t, float64(2), testutil.ToFloat64(retries)-retriesBefore)
+	})
+
+	t.Run("broker rate limited", func(t *testing.T) {
+		server, requests := newServer(1, http.StatusTooManyRequests, nil)
+		_, err := newClient(server.URL, policy).ExecuteSqlQuery(context.Background(), NewSqlQuery("SELECT 1"))
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		server, requests := newServer(5, http.StatusBadGateway, nil)
+		_, err := newClient(server.URL, policy).ExecuteSqlQuery(context.Background(), NewSqlQuery("SELECT 1"))
+		var statusErr *HttpStatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("no retries", func(t *testing.T) {
+		server, requests := newServer(5, http.StatusServiceUnavailable, nil)
+		_, err := newClient(server.URL, RetryPolicy{}).ExecuteSqlQuery(context.Background(), NewSqlQuery("SELECT 1"))
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("client error", func(t *testing.T) {
+		server, requests := newServer(5, http.StatusBadRequest, nil)
+		_, err := newClient(server.URL, policy).ExecuteSqlQuery(context.Background(), NewSqlQuery("SELECT 1"))
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("controller recovers", func(t *testing.T) {
+		server, requests := newServer(1, http.StatusServiceUnavailable, nil)
+		metadata, err := newClient(server.URL, policy).GetTableMetadata(context.Background(), "tbl")
+		require.NoError(t, err)
+		assert.Equal(t, "tbl", metadata.TableNameAndType)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("retry after", func(t *testing.T) {
+		server, requests := newServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"1"}})
+		start := time.Now()
+		_, err := newClient(server.URL, RetryPolicy{MaxRetries: 1, MaxBackoff: 2 * time.Second}).ExecuteSqlQuery(context.Background(), NewSqlQuery("SELECT 1"))
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("retry after exceeds max backoff", func(t *testing.T) {
+		server, requests := newServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"60"}})
+		_, err := newClient(server.URL, policy).ExecuteSqlQuery(context.Background(), NewSqlQuery("SELECT 1"))
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("retry after exceeds deadline", func(t *testing.T) {
+		server, requests := newServer(1, http.StatusServiceUnavailable, http.Header{"Retry-After": []string{"1"}})
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, err := newClient(server.URL, RetryPolicy{MaxRetries: 1, MaxBackoff: 2 * time.Second}).ExecuteSqlQuery(ctx, NewSqlQuery("SELECT 1"))
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+}
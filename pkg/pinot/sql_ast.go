@@ -0,0 +1,137 @@
+package pinot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SqlNode is a node of a SQL expression tree.
+// Nodes render identifiers and literals with all embedded quotes escaped, so values never change the structure of the statement.
+type SqlNode interface {
+	Expr() SqlExpr
+}
+
+// Identifier is a table, column or alias name, rendered as a double-quoted identifier.
+type Identifier string
+
+func (x Identifier) Expr() SqlExpr {
+	return SqlExpr(`"` + strings.ReplaceAll(string(x), `"`, `""`) + `"`)
+}
+
+// StringLiteral is rendered as a single-quoted string.
+type StringLiteral string
+
+func (x StringLiteral) Expr() SqlExpr {
+	return SqlExpr(`'` + strings.ReplaceAll(string(x), `'`, `''`) + `'`)
+}
+
+type IntLiteral int64
+
+func (x IntLiteral) Expr() SqlExpr { return SqlExpr(strconv.FormatInt(int64(x), 10)) }
+
+type FloatLiteral float64
+
+func (x FloatLiteral) Expr() SqlExpr { return SqlExpr(strconv.FormatFloat(float64(x), 'g', -1, 64)) }
+
+type BoolLiteral bool
+
+func (x BoolLiteral) Expr() SqlExpr {
+	if x {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// NullLiteral is rendered as NULL.
+type NullLiteral struct{}
+
+func (x NullLiteral) Expr() SqlExpr { return "NULL" }
+
+// Keyword is a trusted SQL fragment such as a data type, a function name or a sort direction.
+// Keywords are rendered verbatim and must never hold user input.
+type Keyword string
+
+func (x Keyword) Expr() SqlExpr { return SqlExpr(x) }
+
+// Raw wraps an expression that was already rendered, so it can be used as a node.
+type Raw SqlExpr
+
+func (x Raw) Expr() SqlExpr { return SqlExpr(x) }
+
+// ItemAccess is a lookup of a key in a map column, such as "labels"['env'].
+type ItemAccess struct {
+	Column SqlNode
+	Key    SqlNode
+}
+
+func (x ItemAccess) Expr() SqlExpr {
+	return SqlExpr(fmt.Sprintf("%s[%s]", x.Column.Expr(), x.Key.Expr()))
+}
+
+var sqlFunctionNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// IsSqlFunctionName returns true if the name can be rendered as a function name without quoting.
+func IsSqlFunctionName(name string) bool {
+	return sqlFunctionNameRegex.MatchString(name)
+}
+
+// FuncCall is a call of a function with a trusted name.
+type FuncCall struct {
+	Name Keyword
+	Args []SqlNode
+}
+
+func (x FuncCall) Expr() SqlExpr {
+	return SqlExpr(fmt.Sprintf("%s(%s)", x.Name, joinNodes(x.Args, ", ")))
+}
+
+// BinaryExpr applies a trusted operator to two operands.
+type BinaryExpr struct {
+	Left     SqlNode
+	Operator Keyword
+	Right    SqlNode
+}
+
+func (x BinaryExpr) Expr() SqlExpr {
+	return SqlExpr(fmt.Sprintf("%s %s %s", x.Left.Expr(), x.Operator, x.Right.Expr()))
+}
+
+// NotExpr negates the operand.
+type NotExpr struct {
+	Operand SqlNode
+}
+
+func (x NotExpr) Expr() SqlExpr {
+	return SqlExpr(fmt.Sprintf("not %s", x.Operand.Expr()))
+}
+
+// ListExpr is a parenthesized list of values, as used by IN.
+type ListExpr []SqlNode
+
+func (x ListExpr) Expr() SqlExpr {
+	return SqlExpr(fmt.Sprintf("(%s)", joinNodes(x, ", ")))
+}
+
+// OrExpr joins the operands with OR and wraps them in parentheses.
+type OrExpr []SqlNode
+
+func (x OrExpr) Expr() SqlExpr {
+	return SqlExpr(fmt.Sprintf("(%s)", joinNodes(x, " OR ")))
+}
+
+// AndExpr joins the operands with AND.
+type AndExpr []SqlNode
+
+func (x AndExpr) Expr() SqlExpr {
+	return SqlExpr(joinNodes(x, " AND "))
+}
+
+func joinNodes(nodes []SqlNode, sep string) string {
+	parts := make([]string, len(nodes))
+	for i, node := range nodes {
+		parts[i] = node.Expr().String()
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,91 @@
+package pinot
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestIdentifier(t *testing.T) {
+	testCases := []struct {
+		name string
+		want SqlExpr
+	}{
+		{name: `col`, want: `"col"`},
+		{name: `my"col`, want: `"my""col"`},
+		{name: `col" OR 1=1 --`, want: `"col"" OR 1=1 --"`},
+		{name: `my'col`, want: `"my'col"`},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Identifier(tt.name).Expr())
+		})
+	}
+}
+
+func TestStringLiteral(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  SqlExpr
+	}{
+		{value: `val`, want: `'val'`},
+		{value: `it's`, want: `'it''s'`},
+		{value: `' OR '1'='1`, want: `''' OR ''1''=''1'`},
+		{value: `"val"`, want: `'"val"'`},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, StringLiteral(tt.value).Expr())
+		})
+	}
+}
+
+func TestSqlNodes(t *testing.T) {
+	filter := AndExpr{
+		BinaryExpr{Left: ItemAccess{Column: Identifier("labels"), Key: StringLiteral("env")}, Operator: "=", Right: StringLiteral("prod")},
+		NotExpr{Operand: BinaryExpr{Left: Identifier("status"), Operator: "in", Right: ListExpr{IntLiteral(500), IntLiteral(503)}}},
+		OrExpr{
+			BinaryExpr{Left: Identifier("ratio"), Operator: ">", Right: FloatLiteral(0.5)},
+			BinaryExpr{Left: Identifier("enabled"), Operator: "=", Right: BoolLiteral(true)},
+			BinaryExpr{Left: Identifier("owner"), Operator: "IS", Right: NullLiteral{}},
+		},
+		BinaryExpr{Left: FuncCall{Name: "LOWER", Args: []SqlNode{Identifier("name")}}, Operator: "=", Right: Raw("'x'")},
+	}
+	assert.Equal(t,
+		SqlExpr(`"labels"['env'] = 'prod' AND not "status" in (500, 503) AND ("ratio" > 0.5 OR "enabled" = TRUE OR "owner" IS NULL) AND LOWER("name") = 'x'`),
+		filter.Expr())
+}
+
+func TestIsSqlFunctionName(t *testing.T) {
+	assert.True(t, IsSqlFunctionName("sum"))
+	assert.True(t, IsSqlFunctionName("PERCENTILE_TDIGEST99"))
+	assert.False(t, IsSqlFunctionName(""))
+	assert.False(t, IsSqlFunctionName("1sum"))
+	assert.False(t, IsSqlFunctionName("sum(1); DROP TABLE x; --"))
+}
+
+func FuzzIdentifier(f *testing.F) {
+	for _, seed := range []string{"", "col", `my"col`, `""`, `col" OR 1=1 --`} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		expr := Identifier(name).Expr().String()
+		assert.True(t, strings.HasPrefix(expr, `"`) && strings.HasSuffix(expr, `"`))
+		// Every quote inside the identifier is escaped by doubling it.
+		assert.NotContains(t, strings.ReplaceAll(expr[1:len(expr)-1], `""`, ""), `"`)
+		assert.Equal(t, name, UnquoteObjectName(expr))
+	})
+}
+
+func FuzzStringLiteral(f *testing.F) {
+	for _, seed := range []string{"", "val", `it's`, `''`, `' OR '1'='1`} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		expr := StringLiteral(value).Expr().String()
+		assert.True(t, strings.HasPrefix(expr, `'`) && strings.HasSuffix(expr, `'`))
+		// Every quote inside the literal is escaped by doubling it.
+		assert.NotContains(t, strings.ReplaceAll(expr[1:len(expr)-1], `''`, ""), `'`)
+		assert.Equal(t, value, UnquoteStringLiteral(expr))
+	})
+}
@@ -13,12 +13,14 @@ type SqlExpr string
 
 func (x SqlExpr) String() string { return string(x) }
 
+// ObjectExpr quotes a table, column or alias name.
 func ObjectExpr(obj string) SqlExpr {
-	return SqlExpr(fmt.Sprintf(`"%s"`, obj))
+	return Identifier(obj).Expr()
 }
 
+// StringLiteralExpr quotes a string value.
 func StringLiteralExpr(lit string) SqlExpr {
-	return SqlExpr(fmt.Sprintf(`'%s'`, lit))
+	return StringLiteral(lit).Expr()
 }
 
 func LiteralExpr[T string | int | int64 | int32 | bool | float32 | float64](val T) SqlExpr {
@@ -34,22 +36,25 @@ func LiteralExpr[T string | int | int64 | int32 | bool | float32 | float64](val
 	case float32, float64:
 		return SqlExpr(fmt.Sprintf("%v", valTyped))
 	default:
-		return SqlExpr(fmt.Sprintf(`'%s'`, valTyped))
+		return StringLiteralExpr(any(val).(string))
 	}
 }
 
+// UnquoteObjectName reverses ObjectExpr. Names quoted with backticks are also accepted.
 func UnquoteObjectName(s string) string {
-	if (strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`)) ||
-		(strings.HasPrefix(s, "`") && strings.HasSuffix(s, "`")) {
-		return s[1 : len(s)-1]
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	} else if len(s) >= 2 && strings.HasPrefix(s, "`") && strings.HasSuffix(s, "`") {
+		return strings.ReplaceAll(s[1:len(s)-1], "``", "`")
 	} else {
 		return s
 	}
 }
 
+// UnquoteStringLiteral reverses StringLiteralExpr.
 func UnquoteStringLiteral(s string) string {
-	if strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
-		return s[1 : len(s)-1]
+	if len(s) >= 2 && strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
 	} else {
 		return s
 	}
@@ -59,7 +64,7 @@ func ComplexFieldExpr(column string, key string) SqlExpr {
 	if key == "" {
 		return ObjectExpr(column)
 	} else {
-		return SqlExpr(fmt.Sprintf("%s[%s]", ObjectExpr(column), StringLiteralExpr(key)))
+		return ItemAccess{Column: Identifier(column), Key: StringLiteral(key)}.Expr()
 	}
 }
 
@@ -86,10 +91,10 @@ func TimeFilterBucketAlignedExpr(filter TimeFilter, bucketSize time.Duration) Sq
 }
 
 func TimeFilterExpr(filter TimeFilter) SqlExpr {
-	return SqlExpr(fmt.Sprintf(`%s >= %s AND %s < %s`,
-		ObjectExpr(filter.Column), TimeExpr(filter.From, filter.Format),
-		ObjectExpr(filter.Column), TimeExpr(filter.To, filter.Format),
-	))
+	return AndExpr{
+		BinaryExpr{Left: Identifier(filter.Column), Operator: ">=", Right: Raw(TimeExpr(filter.From, filter.Format))},
+		BinaryExpr{Left: Identifier(filter.Column), Operator: "<", Right: Raw(TimeExpr(filter.To, filter.Format))},
+	}.Expr()
 }
 
 func TimeExpr(ts time.Time, format DateTimeFormat) SqlExpr {
@@ -131,21 +136,24 @@ func TimeGroupExpr(configs ListTableConfigsResponse, timeGroup DateTimeConversio
 		return ObjectExpr(timeCol)
 	}
 
-	return SqlExpr(fmt.Sprintf(`DATETIMECONVERT(%s, %s, %s, %s)`,
-		ObjectExpr(timeGroup.TimeColumn),
-		DateTimeFormatExpr(timeGroup.InputFormat),
-		DateTimeFormatExpr(timeGroup.OutputFormat),
-		GranularityExpr(timeGroup.Granularity)))
+	return FuncCall{Name: "DATETIMECONVERT", Args: []SqlNode{
+		Identifier(timeGroup.TimeColumn),
+		Raw(DateTimeFormatExpr(timeGroup.InputFormat)),
+		Raw(DateTimeFormatExpr(timeGroup.OutputFormat)),
+		Raw(GranularityExpr(timeGroup.Granularity)),
+	}}.Expr()
 }
 
 func JsonExtractScalarExpr(sourceExpr SqlExpr, path string, resultType string, defaultValueExpr SqlExpr) SqlExpr {
-	return SqlExpr(fmt.Sprintf(`JSONEXTRACTSCALAR(%s, %s, %s, %s)`,
-		sourceExpr, StringLiteralExpr(path), StringLiteralExpr(resultType), defaultValueExpr))
+	return FuncCall{Name: "JSONEXTRACTSCALAR", Args: []SqlNode{
+		Raw(sourceExpr), StringLiteral(path), StringLiteral(resultType), Raw(defaultValueExpr),
+	}}.Expr()
 }
 
 func RegexpExtractExpr(sourceExpr SqlExpr, pattern string, group int, defaultValueExpr SqlExpr) SqlExpr {
-	return SqlExpr(fmt.Sprintf(`REGEXPEXTRACT(%s, %s, %d, %s)`,
-		sourceExpr, StringLiteralExpr(pattern), group, defaultValueExpr))
+	return FuncCall{Name: "REGEXPEXTRACT", Args: []SqlNode{
+		Raw(sourceExpr), StringLiteral(pattern), IntLiteral(group), Raw(defaultValueExpr),
+	}}.Expr()
 }
 
 func QueryOptionExpr(name string, valueExpr SqlExpr) SqlExpr {
@@ -39,6 +39,8 @@ func TestUnquoteObjectName(t *testing.T) {
 		{name: `object"`, want: `object"`},
 		{name: "`object", want: "`object"},
 		{name: "object`", want: "object`"},
+		{name: `"my""object"`, want: `my"object`},
+		{name: "`my``object`", want: "my`object"},
 	}
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -56,6 +58,7 @@ func TestUnquoteStringLiteral(t *testing.T) {
 		{name: `object`, want: `object`},
 		{name: `'object`, want: `'object`},
 		{name: `object'`, want: `object'`},
+		{name: `'it''s'`, want: `it's`},
 	}
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
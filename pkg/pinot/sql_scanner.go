@@ -0,0 +1,110 @@
+package pinot
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type sqlTokenKind int
+
+const (
+	sqlTokenWord sqlTokenKind = iota
+	sqlTokenIdentifier
+	sqlTokenString
+	sqlTokenNumber
+	sqlTokenSymbol
+)
+
+// sqlToken is a token of a sql statement. Quoted identifiers hold their unquoted name.
+type sqlToken struct {
+	kind  sqlTokenKind
+	text  string
+	depth int
+	start int
+	end   int
+}
+
+func (x sqlToken) isKeyword(keywords ...string) bool {
+	return x.kind == sqlTokenWord && slices.ContainsFunc(keywords, func(keyword string) bool {
+		return strings.EqualFold(x.text, keyword)
+	})
+}
+
+// isName returns true if the token is a word or quoted identifier that names the object, with or without a qualifier.
+func (x sqlToken) isName(name string) bool {
+	switch x.kind {
+	case sqlTokenIdentifier:
+		return x.text == name
+	case sqlTokenWord:
+		return strings.EqualFold(x.text, name) || strings.HasSuffix(strings.ToLower(x.text), "."+strings.ToLower(name))
+	default:
+		return false
+	}
+}
+
+var sqlScannerRegex = regexp.MustCompile(`(?s)(\s+|--[^\n]*|/\*.*?\*/)|('(?:[^']|'')*')|("(?:[^"]|"")*"|` + "`(?:[^`]|``)*`" + `)|` +
+	`([A-Za-z_][A-Za-z0-9_.$]*)|([0-9]+(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?)|(<=|>=|<>|!=|.)`)
+
+// scanSql splits the statement into tokens. Whitespace and comments are dropped, and each token records its parenthesis depth.
+func scanSql(sql string) []sqlToken {
+	var tokens []sqlToken
+	var depth int
+	for _, match := range sqlScannerRegex.FindAllStringSubmatchIndex(sql, -1) {
+		token := sqlToken{start: match[0], end: match[1], text: sql[match[0]:match[1]]}
+		switch {
+		case match[2] >= 0:
+			continue
+		case match[4] >= 0:
+			token.kind = sqlTokenString
+		case match[6] >= 0:
+			token.kind = sqlTokenIdentifier
+			token.text = UnquoteObjectName(token.text)
+		case match[8] >= 0:
+			token.kind = sqlTokenWord
+		case match[10] >= 0:
+			token.kind = sqlTokenNumber
+		default:
+			token.kind = sqlTokenSymbol
+		}
+
+		if token.text == ")" && token.kind == sqlTokenSymbol {
+			depth--
+		}
+		token.depth = depth
+		if token.text == "(" && token.kind == sqlTokenSymbol {
+			depth++
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// FindTableReferences returns the tables that the sql names directly. String literals and comments are ignored.
+// Names are compared without case, and with any database prefix and table type suffix removed.
+func FindTableReferences(sql string, tables []string) []string {
+	tableByName := make(map[string]string, len(tables))
+	for _, table := range tables {
+		tableByName[normalizeTableName(table)] = table
+	}
+
+	var found []string
+	for _, token := range scanSql(sql) {
+		if token.kind != sqlTokenWord && token.kind != sqlTokenIdentifier {
+			continue
+		}
+		if table, ok := tableByName[normalizeTableName(token.text)]; ok && !slices.Contains(found, table) {
+			found = append(found, table)
+		}
+	}
+	return found
+}
+
+func normalizeTableName(name string) string {
+	name = strings.ToLower(name)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, "_offline")
+	return strings.TrimSuffix(name, "_realtime")
+}
@@ -0,0 +1,43 @@
+package pinot
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestScanSql(t *testing.T) {
+	tokens := scanSql(`SELECT "a""b", 'x -- y' FROM t /* WHERE */ WHERE (ts >= 10) -- LIMIT 5`)
+
+	var texts []string
+	var kinds []sqlTokenKind
+	for _, token := range tokens {
+		texts = append(texts, token.text)
+		kinds = append(kinds, token.kind)
+	}
+	assert.Equal(t, []string{"SELECT", `a"b`, ",", "'x -- y'", "FROM", "t", "WHERE", "(", "ts", ">=", "10", ")"}, texts)
+	assert.Equal(t, []sqlTokenKind{
+		sqlTokenWord, sqlTokenIdentifier, sqlTokenSymbol, sqlTokenString, sqlTokenWord, sqlTokenWord, sqlTokenWord,
+		sqlTokenSymbol, sqlTokenWord, sqlTokenSymbol, sqlTokenNumber, sqlTokenSymbol,
+	}, kinds)
+	assert.Equal(t, 0, tokens[7].depth)
+	assert.Equal(t, 1, tokens[8].depth)
+	assert.Equal(t, 0, tokens[11].depth)
+}
+
+func TestFindTableReferences(t *testing.T) {
+	tables := []string{"orders", "customers", "events"}
+	testCases := []struct {
+		sql  string
+		want []string
+	}{
+		{sql: `SELECT * FROM orders`, want: []string{"orders"}},
+		{sql: `SELECT * FROM "ORDERS_OFFLINE" JOIN db.customers ON 1=1`, want: []string{"orders", "customers"}},
+		{sql: "SELECT * FROM `events_REALTIME`", want: []string{"events"}},
+		{sql: `SELECT 'orders', "customers_id" FROM t -- events`, want: nil},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.sql, func(t *testing.T) {
+			assert.Equal(t, tt.want, FindTableReferences(tt.sql, tables))
+		})
+	}
+}
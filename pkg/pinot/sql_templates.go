@@ -9,9 +9,19 @@ import (
 
 const DistinctValuesLimit = 100
 
-var distinctValuesSqlTemplate = template.Must(template.New("distinct-values-sql").Parse(`
+// sqlTemplateFuncs quote the identifiers and literals spliced into templates.
+var sqlTemplateFuncs = template.FuncMap{
+	"ident": ObjectExpr,
+	"str":   StringLiteralExpr,
+}
+
+func newSqlTemplate(name string, text string) *template.Template {
+	return template.Must(template.New(name).Funcs(sqlTemplateFuncs).Parse(text))
+}
+
+var distinctValuesSqlTemplate = newSqlTemplate("distinct-values-sql", `
 SELECT DISTINCT {{.ColumnExpr}}
-FROM "{{.TableName}}"
+FROM {{ ident .TableName }}
 WHERE {{.ColumnExpr}} IS NOT NULL {{- if .TimeFilterExpr }}
     AND {{.TimeFilterExpr}}{{end}}
     {{- range .DimensionFilterExprs }}
@@ -19,7 +29,7 @@ WHERE {{.ColumnExpr}} IS NOT NULL {{- if .TimeFilterExpr }}
 {{- end }}
 ORDER BY {{.ColumnExpr}} ASC
 LIMIT {{.Limit}};
-`))
+`)
 
 type DistinctValuesSqlParams struct {
 	ColumnExpr           SqlExpr
@@ -36,9 +46,9 @@ func RenderDistinctValuesSql(params DistinctValuesSqlParams) (string, error) {
 	return render(distinctValuesSqlTemplate, params)
 }
 
-var timeSeriesSqlTemplate = template.Must(template.New("time-series-sql").Parse(`
+var timeSeriesSqlTemplate = newSqlTemplate("time-series-sql", `
 SELECT{{ range .GroupByColumnExprs }}
-    {{ .Expr }}{{if .Alias}} AS '{{.Alias}}'{{end}},
+    {{ .Expr }}{{if .Alias}} AS {{ if $.UseMultistageEngine }}{{ ident .Alias }}{{ else }}{{ str .Alias }}{{ end }}{{end}},
     {{- end }}
     {{.TimeGroupExpr}} AS {{.TimeColumnAliasExpr}},
     {{.AggregationFunction}}({{.MetricColumnExpr}}) AS {{.MetricColumnAliasExpr}}
@@ -59,7 +69,7 @@ ORDER BY{{ range $index, $element := .OrderByExprs }}{{$sep}}
     {{.TimeColumnAliasExpr}} DESC
 {{- end }}
 LIMIT {{.Limit}};
-`))
+`)
 
 type ExprWithAlias struct {
 	Expr  SqlExpr
@@ -78,16 +88,17 @@ type TimeSeriesSqlParams struct {
 	DimensionFilterExprs  []SqlExpr
 	Limit                 int64
 	OrderByExprs          []SqlExpr
+	UseMultistageEngine   bool
 }
 
 func RenderTimeSeriesSql(params TimeSeriesSqlParams) (string, error) {
 	return render(timeSeriesSqlTemplate, params)
 }
 
-var singleMetricSqlTemplate = template.Must(template.New("single-metric-sql").Parse(`
+var singleMetricSqlTemplate = newSqlTemplate("single-metric-sql", `
 SELECT
     {{.MetricColumnExpr}} AS {{.MetricColumnAliasExpr}},
-    "{{.TimeColumn}}" AS {{.TimeColumnAliasExpr}}
+    {{ ident .TimeColumn }} AS {{.TimeColumnAliasExpr}}
 FROM
     {{.TableNameExpr}}
 WHERE
@@ -97,7 +108,7 @@ WHERE
 {{- end }}
 ORDER BY {{.TimeColumnAliasExpr}} DESC
 LIMIT {{.Limit}};
-`))
+`)
 
 type SingleMetricSqlParams struct {
 	TableNameExpr         SqlExpr
@@ -114,13 +125,13 @@ func RenderSingleMetricSql(params SingleMetricSqlParams) (string, error) {
 	return render(singleMetricSqlTemplate, params)
 }
 
-var logSqlTemplate = template.Must(template.New("log-sql").Parse(`
+var logSqlTemplate = newSqlTemplate("log-sql", `
 SELECT
-    {{ .LogColumnExpr }}{{ if .LogColumnAlias }} AS '{{ .LogColumnAlias }}'{{ end }},
+    {{ .LogColumnExpr }}{{ if .LogColumnAlias }} AS {{ if .UseMultistageEngine }}{{ ident .LogColumnAlias }}{{ else }}{{ str .LogColumnAlias }}{{ end }}{{ end }},
     {{- range .MetadataColumns}}
-    {{ .Expr }}{{ if .Alias }} AS '{{ .Alias }}'{{ end }},
+    {{ .Expr }}{{ if .Alias }} AS {{ if $.UseMultistageEngine }}{{ ident .Alias }}{{ else }}{{ str .Alias }}{{ end }}{{ end }},
     {{- end }}
-    "{{ .TimeColumn }}"
+    {{ ident .TimeColumn }}
 FROM {{ .TableNameExpr }}
 WHERE {{ .LogColumnExpr }} IS NOT NULL
     {{- if .TimeFilterExpr }}
@@ -130,10 +141,10 @@ WHERE {{ .LogColumnExpr }} IS NOT NULL
     AND {{ . }}
     {{- end }}
 ORDER BY
-    "{{ .TimeColumn }}" ASC,
-    {{ if .LogColumnAlias }}"{{ .LogColumnAlias }}"{{ else }}{{ .LogColumnExpr }}{{ end }} ASC
+    {{ ident .TimeColumn }} ASC,
+    {{ if .LogColumnAlias }}{{ ident .LogColumnAlias }}{{ else }}{{ .LogColumnExpr }}{{ end }} ASC
 LIMIT {{ .Limit }};
-`))
+`)
 
 type LogSqlParams struct {
 	TableNameExpr        SqlExpr
@@ -144,6 +155,7 @@ type LogSqlParams struct {
 	TimeFilterExpr       SqlExpr
 	DimensionFilterExprs []SqlExpr
 	Limit                int64
+	UseMultistageEngine  bool
 }
 
 func RenderLogSql(params LogSqlParams) (string, error) {
@@ -78,6 +78,38 @@ LIMIT 10000;`
 	assert.Equal(t, want, got)
 }
 
+func TestRenderTimeSeriesSql_UseMultistageEngine(t *testing.T) {
+	want := `SELECT
+    "dim1" AS "dim1Alias",
+    "ts" AS "time",
+    sum("met") AS "metric"
+FROM
+    "my_table"
+WHERE
+    "ts" >= 10 AND "ts" <= 20
+GROUP BY
+    "dim1",
+    "time"
+ORDER BY
+    "time" DESC
+LIMIT 10000;`
+
+	got, err := RenderTimeSeriesSql(TimeSeriesSqlParams{
+		TableNameExpr:         `"my_table"`,
+		GroupByColumnExprs:    []ExprWithAlias{{Expr: `"dim1"`, Alias: `dim1Alias`}},
+		MetricColumnExpr:      `"met"`,
+		AggregationFunction:   "sum",
+		TimeFilterExpr:        `"ts" >= 10 AND "ts" <= 20`,
+		TimeGroupExpr:         `"ts"`,
+		TimeColumnAliasExpr:   `"time"`,
+		MetricColumnAliasExpr: `"metric"`,
+		Limit:                 10000,
+		UseMultistageEngine:   true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
 func TestRenderSingleMetricSql(t *testing.T) {
 	want := `SELECT
     "met" AS "metric",
@@ -138,3 +170,30 @@ LIMIT 1000;`
 	assert.NoError(t, err)
 	assert.Equal(t, want, got)
 }
+
+func TestRenderLogSql_UseMultistageEngine(t *testing.T) {
+	want := `SELECT
+    "message" AS "message_alias",
+    "col1" AS "alias1",
+    "ts"
+FROM "my_table"
+WHERE "message" IS NOT NULL
+    AND "ts" >= 10 AND "ts" <= 20
+ORDER BY
+    "ts" ASC,
+    "message_alias" ASC
+LIMIT 1000;`
+
+	got, err := RenderLogSql(LogSqlParams{
+		TableNameExpr:       `"my_table"`,
+		TimeColumn:          "ts",
+		LogColumnExpr:       `"message"`,
+		LogColumnAlias:      "message_alias",
+		MetadataColumns:     []ExprWithAlias{{Expr: `"col1"`, Alias: "alias1"}},
+		TimeFilterExpr:      `"ts" >= 10 AND "ts" <= 20`,
+		Limit:               1000,
+		UseMultistageEngine: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,74 @@
+package pinot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TableFilter limits the tables of a datasource to the names that match an include pattern and no exclude pattern.
+// Patterns match the whole table name, without the table type suffix. The zero value allows all tables.
+type TableFilter struct {
+	Include []*regexp.Regexp
+	Exclude []*regexp.Regexp
+}
+
+// NewTableFilter compiles the include and exclude patterns. Empty patterns are ignored.
+func NewTableFilter(include []string, exclude []string) (TableFilter, error) {
+	var filter TableFilter
+	var err error
+	if filter.Include, err = compileTablePatterns(include); err != nil {
+		return TableFilter{}, err
+	}
+	if filter.Exclude, err = compileTablePatterns(exclude); err != nil {
+		return TableFilter{}, err
+	}
+	return filter, nil
+}
+
+func compileTablePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid table pattern `%s`: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func (x TableFilter) IsEmpty() bool {
+	return len(x.Include) == 0 && len(x.Exclude) == 0
+}
+
+// Allows returns true if the table matches an include pattern, or there are none, and matches no exclude pattern.
+func (x TableFilter) Allows(table string) bool {
+	name := tableNameWithoutType(table)
+	for _, re := range x.Exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(x.Include) == 0 {
+		return true
+	}
+	for _, re := range x.Include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func tableNameWithoutType(table string) string {
+	for _, suffix := range []string{"_" + string(TableTypeOffline), "_" + string(TableTypeRealTime)} {
+		if len(table) > len(suffix) && strings.EqualFold(table[len(table)-len(suffix):], suffix) {
+			return table[:len(table)-len(suffix)]
+		}
+	}
+	return table
+}
@@ -0,0 +1,28 @@
+package pinot
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTableFilter_Allows(t *testing.T) {
+	filter, err := NewTableFilter([]string{"orders.*", "customers"}, []string{".*_staging", ""})
+	require.NoError(t, err)
+
+	assert.True(t, filter.Allows("orders"))
+	assert.True(t, filter.Allows("orders_v2"))
+	assert.True(t, filter.Allows("customers_OFFLINE"))
+	assert.False(t, filter.Allows("orders_staging"))
+	assert.False(t, filter.Allows("orders_staging_REALTIME"))
+	assert.False(t, filter.Allows("my_customers"))
+	assert.False(t, filter.Allows("events"))
+
+	assert.True(t, TableFilter{}.Allows("events"))
+	assert.True(t, TableFilter{}.IsEmpty())
+}
+
+func TestNewTableFilter_Invalid(t *testing.T) {
+	_, err := NewTableFilter(nil, []string{"orders("})
+	assert.ErrorContains(t, err, "invalid table pattern `orders(`")
+}
@@ -30,6 +30,8 @@ type TimeSeriesRangeQuery struct {
 	End       time.Time
 	Step      time.Duration
 	TableName string
+	// QueryOptions are sent with the query, together with the options of the client properties.
+	QueryOptions []QueryOption
 }
 
 type TimeSeriesQueryResponse struct {
@@ -104,25 +106,20 @@ func flattenLabels(metric map[string]string) map[string]string {
 }
 
 func (p *Client) IsTimeseriesSupported(ctx context.Context) (bool, error) {
-	if ctx.Err() != nil {
-		return false, ctx.Err()
-	}
-
-	req, err := p.newRequest(ctx, http.MethodHead, p.properties.BrokerUrl+TimeSeriesEndpoint+"/query_range", nil)
-	if err != nil {
-		return false, err
-	}
-
-	resp, err := p.doRequest(req)
+	caps, err := p.Capabilities(ctx)
 	if err != nil {
 		return false, err
 	}
-	defer p.closeResponseBody(ctx, resp)
-
-	return resp.StatusCode != http.StatusNotFound, nil
+	return caps.TimeseriesApi, nil
 }
 
 func (p *Client) ExecuteTimeSeriesQuery(ctx context.Context, req *TimeSeriesRangeQuery) (*TimeSeriesQueryResponse, error) {
+	if err := p.properties.QueryPolicy.checkTable(req.TableName); err != nil {
+		return nil, err
+	} else if err := ValidateQueryOptions(req.QueryOptions); err != nil {
+		return nil, err
+	}
+
 	tableMetadata, err := p.GetTableMetadata(ctx, req.TableName)
 	if err != nil {
 		return nil, err
@@ -144,15 +141,20 @@ func (p *Client) ExecuteTimeSeriesQuery(ctx context.Context, req *TimeSeriesRang
 	values.Set("end", formatTime(req.End))
 	values.Set("step", formatStep(req.Step))
 	values.Set("table", tableMetadata.TableNameAndType)
+	if options := p.queryOptionsOf(req.QueryOptions); len(options) > 0 {
+		values.Set("queryOptions", encodeQueryOptions(options))
+	}
 
-	httpReq, err := p.newTimeseriesGetRequest(ctx, "/query_range?"+values.Encode())
+	p.logger.Info("pinot/http: Executing timeseries query.", "queryString", req.Query)
+	httpResp, err := p.doBrokerRequest(ctx, req.TableName, func(brokerUrl string) (*http.Request, error) {
+		return p.newTimeseriesGetRequest(ctx, brokerUrl, "/query_range?"+values.Encode())
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	var resp TimeSeriesQueryResponse
-	p.logger.Info("pinot/http: Executing timeseries query.", "queryString", req.Query)
-	if err := p.doRequestAndDecodeResponse(httpReq, &resp); err != nil {
+	if err := p.decodeResponse(ctx, httpResp, &resp); err != nil {
 		return nil, err
 	}
 	return &resp, nil
@@ -228,7 +230,7 @@ func (p *Client) ListTimeSeriesMetrics(ctx context.Context, query TimeSeriesMetr
 		return nil, err
 	}
 
-	resp, err := p.ExecuteSqlQuery(ctx, SqlQuery{Sql: sql})
+	resp, err := p.ExecuteSqlQuery(ctx, SqlQuery{Sql: sql, TableName: query.TableName})
 	switch {
 	case err != nil:
 		return nil, err
@@ -323,7 +325,11 @@ func (x *labelsCollection) add(name, value string) {
 func (p *Client) fetchTimeSeriesLabels(ctx context.Context, tableName string, metricName string, from time.Time, to time.Time) (*labelsCollection, error) {
 	var filterExprs []SqlExpr
 	if metricName != "" {
-		filterExprs = []SqlExpr{SqlExpr(fmt.Sprintf(`"%s" = '%s'`, TimeSeriesTableColumnMetricName, metricName))}
+		filterExprs = []SqlExpr{BinaryExpr{
+			Left:     Identifier(TimeSeriesTableColumnMetricName),
+			Operator: "=",
+			Right:    StringLiteral(metricName),
+		}.Expr()}
 	}
 
 	dataType, err := p.timeSeriesLabelType(ctx, tableName)
@@ -353,7 +359,7 @@ func (p *Client) fetchTimeSeriesLabels(ctx context.Context, tableName string, me
 		return nil, err
 	}
 
-	resp, err := p.ExecuteSqlQuery(ctx, SqlQuery{Sql: sql})
+	resp, err := p.ExecuteSqlQuery(ctx, SqlQuery{Sql: sql, TableName: tableName})
 	switch {
 	case err != nil:
 		return nil, err
@@ -464,6 +470,6 @@ func IsTimeSeriesTableSchema(schema TableSchema) bool {
 	return true
 }
 
-func (p *Client) newTimeseriesGetRequest(ctx context.Context, endpoint string) (*http.Request, error) {
-	return p.newRequest(ctx, http.MethodGet, p.properties.BrokerUrl+TimeSeriesEndpoint+endpoint, nil)
+func (p *Client) newTimeseriesGetRequest(ctx context.Context, brokerUrl string, endpoint string) (*http.Request, error) {
+	return p.newRequest(ctx, http.MethodGet, brokerUrl+TimeSeriesEndpoint+endpoint, nil)
 }
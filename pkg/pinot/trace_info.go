@@ -0,0 +1,59 @@
+package pinot
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// TraceEntry is the time an operator spent executing a query on one server.
+type TraceEntry struct {
+	Server     string
+	TraceId    string
+	Operator   string
+	DurationMs float64
+}
+
+// TraceEntries returns the operator timings in the trace info of the response.
+// The broker reports the trace of each server as a json string of the form `[{"<traceId>":[{"<operator> Time":<ms>},...]},...]`.
+// Entries that are not operator timings are skipped.
+func (x *BrokerResponse) TraceEntries() []TraceEntry {
+	servers := make([]string, 0, len(x.TraceInfo))
+	for server := range x.TraceInfo {
+		servers = append(servers, server)
+	}
+	slices.Sort(servers)
+
+	var entries []TraceEntry
+	for _, server := range servers {
+		var traces []map[string][]map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(x.TraceInfo[server]), &traces); err != nil {
+			continue
+		}
+		for _, trace := range traces {
+			traceIds := make([]string, 0, len(trace))
+			for traceId := range trace {
+				traceIds = append(traceIds, traceId)
+			}
+			slices.Sort(traceIds)
+
+			for _, traceId := range traceIds {
+				for _, timing := range trace[traceId] {
+					for key, val := range timing {
+						var durationMs float64
+						if err := json.Unmarshal(val, &durationMs); err != nil {
+							continue
+						}
+						entries = append(entries, TraceEntry{
+							Server:     server,
+							TraceId:    traceId,
+							Operator:   strings.TrimSuffix(key, " Time"),
+							DurationMs: durationMs,
+						})
+					}
+				}
+			}
+		}
+	}
+	return entries
+}
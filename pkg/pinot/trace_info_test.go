@@ -0,0 +1,35 @@
+package pinot
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBrokerResponse_TraceEntries(t *testing.T) {
+	t.Run("servers", func(t *testing.T) {
+		resp := BrokerResponse{TraceInfo: map[string]string{
+			"server_2": `[{"0":[{"SelectionOnlyOperator Time":3}]}]`,
+			"server_1": `[{"0":[{"FilterOperator Time":1},{"SelectionOnlyOperator Time":2.5}]},{"0_0":[{"DocIdSetOperator Time":0}]}]`,
+		}}
+		assert.Equal(t, []TraceEntry{
+			{Server: "server_1", TraceId: "0", Operator: "FilterOperator", DurationMs: 1},
+			{Server: "server_1", TraceId: "0", Operator: "SelectionOnlyOperator", DurationMs: 2.5},
+			{Server: "server_1", TraceId: "0_0", Operator: "DocIdSetOperator", DurationMs: 0},
+			{Server: "server_2", TraceId: "0", Operator: "SelectionOnlyOperator", DurationMs: 3},
+		}, resp.TraceEntries())
+	})
+
+	t.Run("skips unknown entries", func(t *testing.T) {
+		resp := BrokerResponse{TraceInfo: map[string]string{
+			"server_1": `[{"0":[{"note":"text"},{"FilterOperator Time":1}]}]`,
+			"server_2": `not json`,
+		}}
+		assert.Equal(t, []TraceEntry{
+			{Server: "server_1", TraceId: "0", Operator: "FilterOperator", DurationMs: 1},
+		}, resp.TraceEntries())
+	})
+
+	t.Run("no trace", func(t *testing.T) {
+		assert.Empty(t, (&BrokerResponse{}).TraceEntries())
+	})
+}
@@ -6,21 +6,75 @@ import (
 	"fmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/plugin/dataquery"
 	"net/http"
+	"time"
 )
 
 const TokenTypeNone = "None"
 
+// TokenTypeOAuth2ClientCredentials authenticates with bearer tokens fetched from an oauth2 token endpoint.
+const TokenTypeOAuth2ClientCredentials = "OAuth2ClientCredentials"
+
+// DefaultMaxConcurrentQueries is the number of queries each datasource instance runs at once when no limit is configured.
+const DefaultMaxConcurrentQueries = 10
+
 type Config struct {
-	ControllerUrl string        `json:"controllerUrl"`
-	BrokerUrl     string        `json:"brokerUrl"`
-	DatabaseName  string        `json:"databaseName"`
-	TokenType     string        `json:"tokenType"`
-	QueryOptions  []QueryOption `json:"queryOptions"`
-	OAuthPassThru bool          `json:"oauthPassThru"`
+	ControllerUrl   string        `json:"controllerUrl"`
+	BrokerUrl       string        `json:"brokerUrl"`
+	BrokerUrls      []string      `json:"brokerUrls"`
+	BrokerDiscovery bool          `json:"brokerDiscovery"`
+	DatabaseName    string        `json:"databaseName"`
+	TokenType       string        `json:"tokenType"`
+	QueryOptions    []QueryOption `json:"queryOptions"`
+	OAuthPassThru   bool          `json:"oauthPassThru"`
+
+	// OAuth2 client credentials, used when the token type is TokenTypeOAuth2ClientCredentials.
+	OAuth2TokenUrl string   `json:"oauth2TokenUrl"`
+	OAuth2ClientId string   `json:"oauth2ClientId"`
+	OAuth2Scopes   []string `json:"oauth2Scopes"`
+
+	// Retries of transient broker and controller failures.
+	// Defaults to pinot.DefaultRetryPolicy when MaxRetries is not set.
+	MaxRetries            *int  `json:"maxRetries"`
+	RetryInitialBackoffMs int64 `json:"retryInitialBackoffMs"`
+	RetryMaxBackoffMs     int64 `json:"retryMaxBackoffMs"`
+
+	// Defaults to pinot.DefaultMetadataCacheTTL when not set. Zero disables the metadata cache.
+	MetadataCacheTtlSeconds *int `json:"metadataCacheTtlSeconds"`
+
+	// Caching of sql query results is disabled unless a ttl is set.
+	QueryResultCacheTtlSeconds int `json:"queryResultCacheTtlSeconds"`
+	QueryResultCacheMaxMb      int `json:"queryResultCacheMaxMb"`
+
+	// Defaults to DefaultMaxConcurrentQueries when not set.
+	MaxConcurrentQueries int `json:"maxConcurrentQueries"`
+
+	// Handling of results returned together with broker exceptions, unless the query sets a policy.
+	// Defaults to dataquery.PartialResultPolicyFail when not set.
+	PartialResultPolicy    dataquery.PartialResultPolicy `json:"partialResultPolicy"`
+	MinServerResponseRatio float64                       `json:"minServerResponseRatio"`
+
+	// Filters added to the queries of each Grafana user, team or org role.
+	// Teams are looked up with the Grafana service account token RowLevelSecurityToken.
+	RowLevelSecurity dataquery.RowLevelSecurity `json:"rowLevelSecurity"`
+
+	// Query governance. Queries that violate a policy are rejected before they are sent to Pinot.
+	DeniedTables              []string `json:"deniedTables"`
+	TimeFilterTables          []string `json:"timeFilterTables"`
+	MaxQueryLimit             int64    `json:"maxQueryLimit"`
+	MaxDocsScanned            int64    `json:"maxDocsScanned"`
+	MaxQueryResponseSizeBytes int64    `json:"maxQueryResponseSizeBytes"`
+
+	// Regular expressions of the tables that dashboards can list and query. Patterns match the whole table name.
+	TableIncludePatterns []string `json:"tableIncludePatterns"`
+	TableExcludePatterns []string `json:"tableExcludePatterns"`
 
 	// Secrets
-	TokenSecret string `json:"-"`
+	TokenSecret        string `json:"-"`
+	OAuth2ClientSecret string `json:"-"`
+	// The Grafana service account token that the teams of row-level security rules are looked up with.
+	RowLevelSecurityToken string `json:"-"`
 }
 
 type QueryOption struct {
@@ -31,34 +85,164 @@ type QueryOption struct {
 func (config *Config) ReadFrom(settings backend.DataSourceInstanceSettings) error {
 	if err := json.Unmarshal(settings.JSONData, &config); err != nil {
 		return fmt.Errorf("failed to unmarshal datasource config: %w", err)
-	} else if config.BrokerUrl == "" {
+	} else if config.BrokerUrl == "" && len(config.BrokerUrls) == 0 && !config.BrokerDiscovery {
 		return errors.New("broker url cannot be empty")
 	} else if config.ControllerUrl == "" {
 		return errors.New("controller url cannot be empty")
+	} else if config.MaxRetries != nil && *config.MaxRetries < 0 {
+		return errors.New("max retries cannot be negative")
+	} else if config.RetryInitialBackoffMs < 0 || config.RetryMaxBackoffMs < 0 {
+		return errors.New("retry backoff cannot be negative")
+	} else if config.MetadataCacheTtlSeconds != nil && *config.MetadataCacheTtlSeconds < 0 {
+		return errors.New("metadata cache ttl cannot be negative")
+	} else if config.QueryResultCacheTtlSeconds < 0 || config.QueryResultCacheMaxMb < 0 {
+		return errors.New("query result cache settings cannot be negative")
+	} else if config.MaxConcurrentQueries < 0 {
+		return errors.New("max concurrent queries cannot be negative")
+	} else if !config.PartialResultPolicy.IsValid() {
+		return fmt.Errorf("unknown partial result policy `%s`", config.PartialResultPolicy)
+	} else if config.MinServerResponseRatio < 0 || config.MinServerResponseRatio > 1 {
+		return errors.New("min server response ratio must be between 0 and 1")
+	} else if config.TokenType == TokenTypeOAuth2ClientCredentials && config.OAuthPassThru {
+		return errors.New("oauth2 client credentials cannot be combined with oauth pass-through")
+	} else if config.TokenType == TokenTypeOAuth2ClientCredentials && (config.OAuth2TokenUrl == "" || config.OAuth2ClientId == "") {
+		return errors.New("oauth2 token url and client id cannot be empty")
+	} else if config.MaxQueryLimit < 0 || config.MaxDocsScanned < 0 || config.MaxQueryResponseSizeBytes < 0 {
+		return errors.New("query policy limits cannot be negative")
+	} else if _, err := pinot.NewTableFilter(config.TableIncludePatterns, config.TableExcludePatterns); err != nil {
+		return err
+	} else if err := config.RowLevelSecurity.Validate(); err != nil {
+		return err
+	} else if err := pinot.ValidateQueryOptions(config.PinotQueryOptions()); err != nil {
+		return err
 	}
 
 	config.TokenSecret = settings.DecryptedSecureJSONData["authToken"]
+	config.OAuth2ClientSecret = settings.DecryptedSecureJSONData["oauth2ClientSecret"]
+	if config.TokenType == TokenTypeOAuth2ClientCredentials && config.OAuth2ClientSecret == "" {
+		return errors.New("oauth2 client secret cannot be empty")
+	}
+	config.RowLevelSecurityToken = settings.DecryptedSecureJSONData["rowLevelSecurityToken"]
+	if config.RowLevelSecurity.HasTeamRules() && config.RowLevelSecurityToken == "" {
+		return errors.New("row-level security team rules require a Grafana service account token")
+	}
 	return nil
 }
 
 func PinotClientOf(httpClient *http.Client, config Config) *pinot.Client {
 	var authorization string
-	if !config.OAuthPassThru && config.TokenType != TokenTypeNone {
+	var clientCredentials *pinot.ClientCredentials
+	switch {
+	case config.OAuthPassThru, config.TokenType == TokenTypeNone:
+		// Requests carry the user's authorization, or none.
+	case config.TokenType == TokenTypeOAuth2ClientCredentials:
+		clientCredentials = &pinot.ClientCredentials{
+			TokenUrl:     config.OAuth2TokenUrl,
+			ClientId:     config.OAuth2ClientId,
+			ClientSecret: config.OAuth2ClientSecret,
+			Scopes:       config.OAuth2Scopes,
+		}
+	default:
 		authorization = fmt.Sprintf("%s %s", config.TokenType, config.TokenSecret)
 	}
 
+	return pinot.NewPinotClient(httpClient, pinot.ClientProperties{
+		ControllerUrl:   config.ControllerUrl,
+		BrokerUrl:       config.BrokerUrl,
+		BrokerUrls:      config.BrokerUrls,
+		BrokerDiscovery: config.BrokerDiscovery,
+		DatabaseName:    config.DatabaseName,
+		QueryOptions:    config.PinotQueryOptions(),
+		Authorization:   authorization,
+		OAuthPassThru:   config.OAuthPassThru,
+		RetryPolicy:     config.RetryPolicy(),
+
+		OAuth2ClientCredentials: clientCredentials,
+
+		MetadataCacheTTL:         config.MetadataCacheTTL(),
+		QueryResultCacheTTL:      config.QueryResultCacheTTL(),
+		QueryResultCacheMaxBytes: int64(config.QueryResultCacheMaxMb) << 20,
+
+		QueryPolicy: config.QueryPolicy(),
+	})
+}
+
+// PinotQueryOptions returns the default query options of the datasource, without empty entries.
+func (config Config) PinotQueryOptions() []pinot.QueryOption {
 	var queryOptions []pinot.QueryOption
 	for _, o := range config.QueryOptions {
 		if o.Name != "" || o.Value != "" {
 			queryOptions = append(queryOptions, pinot.QueryOption{Name: o.Name, Value: o.Value})
 		}
 	}
+	return queryOptions
+}
 
-	return pinot.NewPinotClient(httpClient, pinot.ClientProperties{
-		ControllerUrl: config.ControllerUrl,
-		BrokerUrl:     config.BrokerUrl,
-		DatabaseName:  config.DatabaseName,
-		QueryOptions:  queryOptions,
-		Authorization: authorization,
-	})
+func (config Config) RetryPolicy() pinot.RetryPolicy {
+	policy := pinot.DefaultRetryPolicy()
+	if config.MaxRetries != nil {
+		policy.MaxRetries = *config.MaxRetries
+	}
+	if config.RetryInitialBackoffMs > 0 {
+		policy.InitialBackoff = time.Duration(config.RetryInitialBackoffMs) * time.Millisecond
+	}
+	if config.RetryMaxBackoffMs > 0 {
+		policy.MaxBackoff = time.Duration(config.RetryMaxBackoffMs) * time.Millisecond
+	}
+	return policy
+}
+
+func (config Config) QueryPolicy() pinot.QueryPolicy {
+	// The patterns are validated by ReadFrom.
+	tables, _ := pinot.NewTableFilter(config.TableIncludePatterns, config.TableExcludePatterns)
+	return pinot.QueryPolicy{
+		DeniedTables:              config.DeniedTables,
+		TimeFilterTables:          config.TimeFilterTables,
+		MaxLimit:                  config.MaxQueryLimit,
+		MaxDocsScanned:            config.MaxDocsScanned,
+		MaxQueryResponseSizeBytes: config.MaxQueryResponseSizeBytes,
+		Tables:                    tables,
+	}
+}
+
+func (config Config) MetadataCacheTTL() time.Duration {
+	switch {
+	case config.OAuthPassThru:
+		// The metadata visible to each user can differ, so it cannot be shared.
+		return 0
+	case config.MetadataCacheTtlSeconds != nil:
+		return time.Duration(*config.MetadataCacheTtlSeconds) * time.Second
+	default:
+		return pinot.DefaultMetadataCacheTTL
+	}
+}
+
+func (config Config) QueryResultCacheTTL() time.Duration {
+	if config.OAuthPassThru {
+		// The data visible to each user can differ, so results cannot be shared.
+		return 0
+	}
+	return time.Duration(config.QueryResultCacheTtlSeconds) * time.Second
+}
+
+func (config Config) ResolveMaxConcurrentQueries() int {
+	if config.MaxConcurrentQueries > 0 {
+		return config.MaxConcurrentQueries
+	}
+	return DefaultMaxConcurrentQueries
+}
+
+func (config Config) QueryDefaults() dataquery.QueryDefaults {
+	return dataquery.QueryDefaults{
+		PartialResultPolicy:    config.PartialResultPolicy,
+		MinServerResponseRatio: config.MinServerResponseRatio,
+	}
+}
+
+// GrafanaTeams returns the lookup of the teams of row-level security rules, or nil when no rule matches teams.
+func (config Config) GrafanaTeams(httpClient *http.Client) *dataquery.GrafanaTeams {
+	if !config.RowLevelSecurity.HasTeamRules() {
+		return nil
+	}
+	return dataquery.NewGrafanaTeams(httpClient, config.RowLevelSecurity.GrafanaUrl, config.RowLevelSecurityToken)
 }
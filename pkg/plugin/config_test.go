@@ -3,8 +3,12 @@ package plugin
 import (
 	"encoding/json"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/plugin/dataquery"
 	"github.com/stretchr/testify/assert"
+	"net/http"
 	"testing"
+	"time"
 )
 
 func TestConfig_ReadFrom(t *testing.T) {
@@ -22,3 +26,217 @@ func TestConfig_ReadFrom(t *testing.T) {
 		TokenType:     "Bearer",
 	}, got)
 }
+
+func TestConfig_ReadFrom_BrokerUrls(t *testing.T) {
+	settings := backend.DataSourceInstanceSettings{
+		JSONData: json.RawMessage(
+			`{"brokerUrls":["http://broker-0:8000","http://broker-1:8000"],"controllerUrl":"http://localhost:9000"}`),
+		DecryptedSecureJSONData: map[string]string{},
+	}
+
+	var got Config
+	assert.NoError(t, got.ReadFrom(settings))
+	assert.Equal(t, []string{"http://broker-0:8000", "http://broker-1:8000"}, got.BrokerUrls)
+
+	got = Config{}
+	settings.JSONData = json.RawMessage(`{"controllerUrl":"http://localhost:9000"}`)
+	assert.EqualError(t, got.ReadFrom(settings), "broker url cannot be empty")
+}
+
+func TestConfig_RetryPolicy(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		assert.Equal(t, pinot.DefaultRetryPolicy(), Config{}.RetryPolicy())
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		settings := backend.DataSourceInstanceSettings{
+			JSONData: json.RawMessage(
+				`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","maxRetries":0,"retryInitialBackoffMs":50,"retryMaxBackoffMs":500}`),
+		}
+
+		var config Config
+		assert.NoError(t, config.ReadFrom(settings))
+		assert.Equal(t, pinot.RetryPolicy{
+			MaxRetries:     0,
+			InitialBackoff: 50 * time.Millisecond,
+			MaxBackoff:     500 * time.Millisecond,
+		}, config.RetryPolicy())
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		settings := backend.DataSourceInstanceSettings{
+			JSONData: json.RawMessage(
+				`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","maxRetries":-1}`),
+		}
+
+		var config Config
+		assert.EqualError(t, config.ReadFrom(settings), "max retries cannot be negative")
+	})
+}
+
+func TestConfig_MetadataCacheTTL(t *testing.T) {
+	ttlSeconds := 10
+	assert.Equal(t, pinot.DefaultMetadataCacheTTL, Config{}.MetadataCacheTTL())
+	assert.Equal(t, 10*time.Second, Config{MetadataCacheTtlSeconds: &ttlSeconds}.MetadataCacheTTL())
+	assert.Zero(t, Config{MetadataCacheTtlSeconds: &ttlSeconds, OAuthPassThru: true}.MetadataCacheTTL())
+}
+
+func TestConfig_QueryResultCacheTTL(t *testing.T) {
+	assert.Zero(t, Config{}.QueryResultCacheTTL())
+	assert.Equal(t, 10*time.Second, Config{QueryResultCacheTtlSeconds: 10}.QueryResultCacheTTL())
+	assert.Zero(t, Config{QueryResultCacheTtlSeconds: 10, OAuthPassThru: true}.QueryResultCacheTTL())
+}
+
+func TestPinotClientOf_OAuthPassThru(t *testing.T) {
+	assert.True(t, PinotClientOf(http.DefaultClient, Config{OAuthPassThru: true}).Properties().OAuthPassThru)
+	assert.False(t, PinotClientOf(http.DefaultClient, Config{}).Properties().OAuthPassThru)
+}
+
+func TestConfig_QueryDefaults(t *testing.T) {
+	t.Run("configured", func(t *testing.T) {
+		settings := backend.DataSourceInstanceSettings{
+			JSONData: json.RawMessage(
+				`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","partialResultPolicy":"WARN","minServerResponseRatio":0.5}`),
+		}
+
+		var config Config
+		assert.NoError(t, config.ReadFrom(settings))
+		assert.Equal(t, dataquery.QueryDefaults{
+			PartialResultPolicy:    dataquery.PartialResultPolicyWarn,
+			MinServerResponseRatio: 0.5,
+		}, config.QueryDefaults())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var config Config
+		assert.EqualError(t, config.ReadFrom(backend.DataSourceInstanceSettings{JSONData: json.RawMessage(
+			`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","partialResultPolicy":"IGNORE"}`),
+		}), "unknown partial result policy `IGNORE`")
+
+		config = Config{}
+		assert.EqualError(t, config.ReadFrom(backend.DataSourceInstanceSettings{JSONData: json.RawMessage(
+			`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","minServerResponseRatio":2}`),
+		}), "min server response ratio must be between 0 and 1")
+	})
+}
+
+func TestConfig_ReadFrom_OAuth2ClientCredentials(t *testing.T) {
+	settings := backend.DataSourceInstanceSettings{
+		JSONData: json.RawMessage(
+			`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","tokenType":"OAuth2ClientCredentials",` +
+				`"oauth2TokenUrl":"http://localhost:8080/oauth/token","oauth2ClientId":"client","oauth2Scopes":["read"]}`),
+		DecryptedSecureJSONData: map[string]string{"oauth2ClientSecret": "secret"},
+	}
+
+	var config Config
+	assert.NoError(t, config.ReadFrom(settings))
+	assert.Equal(t, "secret", config.OAuth2ClientSecret)
+
+	client := PinotClientOf(http.DefaultClient, config)
+	assert.Empty(t, client.Properties().Authorization)
+	assert.Equal(t, &pinot.ClientCredentials{
+		TokenUrl:     "http://localhost:8080/oauth/token",
+		ClientId:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read"},
+	}, client.Properties().OAuth2ClientCredentials)
+
+	config = Config{}
+	settings.DecryptedSecureJSONData = map[string]string{}
+	assert.EqualError(t, config.ReadFrom(settings), "oauth2 client secret cannot be empty")
+
+	config = Config{}
+	settings.JSONData = json.RawMessage(
+		`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","tokenType":"OAuth2ClientCredentials"}`)
+	assert.EqualError(t, config.ReadFrom(settings), "oauth2 token url and client id cannot be empty")
+}
+
+func TestConfig_ReadFrom_RowLevelSecurity(t *testing.T) {
+	var config Config
+	assert.NoError(t, config.ReadFrom(backend.DataSourceInstanceSettings{JSONData: json.RawMessage(
+		`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","rowLevelSecurity":{` +
+			`"rules":[{"users":["jdoe"],"tables":["orders"],"filter":"tenant_id = 'acme'"}],"codeMode":"wrap"}}`),
+	}))
+	assert.Equal(t, dataquery.RowLevelSecurity{
+		Rules:    []dataquery.RowLevelSecurityRule{{Users: []string{"jdoe"}, Tables: []string{"orders"}, Filter: "tenant_id = 'acme'"}},
+		CodeMode: dataquery.RowLevelSecurityCodeModeWrap,
+	}, config.RowLevelSecurity)
+
+	config = Config{}
+	assert.EqualError(t, config.ReadFrom(backend.DataSourceInstanceSettings{JSONData: json.RawMessage(
+		`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","rowLevelSecurity":{"rules":[{"tables":["orders"]}]}}`),
+	}), "row-level security rule 1 must match users, teams or roles")
+
+	teamRules := json.RawMessage(`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000",` +
+		`"rowLevelSecurity":{"rules":[{"teams":["acme"],"filter":"tenant_id = 'acme'"}],"grafanaUrl":"http://grafana:3000"}}`)
+	config = Config{}
+	assert.EqualError(t, config.ReadFrom(backend.DataSourceInstanceSettings{JSONData: teamRules}),
+		"row-level security team rules require a Grafana service account token")
+
+	config = Config{}
+	assert.NoError(t, config.ReadFrom(backend.DataSourceInstanceSettings{
+		JSONData:                teamRules,
+		DecryptedSecureJSONData: map[string]string{"rowLevelSecurityToken": "glsa_token"},
+	}))
+	assert.Equal(t, []string{"acme"}, config.RowLevelSecurity.Rules[0].Teams)
+	assert.Equal(t, "http://grafana:3000", config.RowLevelSecurity.GrafanaUrl)
+	assert.Equal(t, "glsa_token", config.RowLevelSecurityToken)
+	assert.NotNil(t, config.GrafanaTeams(http.DefaultClient))
+
+	config = Config{}
+	assert.EqualError(t, config.ReadFrom(backend.DataSourceInstanceSettings{JSONData: json.RawMessage(
+		`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","rowLevelSecurity":{"codeMode":"allow"}}`),
+	}), "unknown row-level security code mode `allow`")
+}
+
+func TestConfig_PinotQueryOptions(t *testing.T) {
+	var config Config
+	assert.NoError(t, config.ReadFrom(backend.DataSourceInstanceSettings{JSONData: json.RawMessage(
+		`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000",` +
+			`"queryOptions":[{"name":"timeoutMs","value":"1000"},{},{"name":"customOption","value":"x"}]}`),
+	}))
+	assert.Equal(t, []pinot.QueryOption{{Name: "timeoutMs", Value: "1000"}, {Name: "customOption", Value: "x"}}, config.PinotQueryOptions())
+	assert.Equal(t, config.PinotQueryOptions(), PinotClientOf(http.DefaultClient, config).Properties().QueryOptions)
+
+	config = Config{}
+	assert.EqualError(t, config.ReadFrom(backend.DataSourceInstanceSettings{JSONData: json.RawMessage(
+		`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","queryOptions":[{"name":"timeoutMs","value":"1s"}]}`),
+	}), "invalid query option: value `1s` of `timeoutMs` is not a non-negative integer")
+}
+
+func TestConfig_QueryPolicy(t *testing.T) {
+	var config Config
+	assert.NoError(t, config.ReadFrom(backend.DataSourceInstanceSettings{JSONData: json.RawMessage(
+		`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","deniedTables":["secrets"],"timeFilterTables":["events"],` +
+			`"maxQueryLimit":1000,"maxDocsScanned":1000000,"maxQueryResponseSizeBytes":1048576}`),
+	}))
+	assert.Equal(t, pinot.QueryPolicy{
+		DeniedTables:              []string{"secrets"},
+		TimeFilterTables:          []string{"events"},
+		MaxLimit:                  1000,
+		MaxDocsScanned:            1000000,
+		MaxQueryResponseSizeBytes: 1048576,
+	}, config.QueryPolicy())
+	assert.Equal(t, config.QueryPolicy(), PinotClientOf(http.DefaultClient, config).Properties().QueryPolicy)
+
+	config = Config{}
+	assert.EqualError(t, config.ReadFrom(backend.DataSourceInstanceSettings{JSONData: json.RawMessage(
+		`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","maxQueryLimit":-1}`),
+	}), "query policy limits cannot be negative")
+
+	config = Config{}
+	assert.EqualError(t, config.ReadFrom(backend.DataSourceInstanceSettings{JSONData: json.RawMessage(
+		`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","tableIncludePatterns":["orders("]}`),
+	}), "invalid table pattern `orders(`: error parsing regexp: missing closing ): `^(?:orders()$`")
+}
+
+func TestConfig_QueryPolicy_TablePatterns(t *testing.T) {
+	var config Config
+	assert.NoError(t, config.ReadFrom(backend.DataSourceInstanceSettings{JSONData: json.RawMessage(
+		`{"brokerUrl":"http://localhost:8000","controllerUrl":"http://localhost:9000","tableIncludePatterns":["orders.*"],"tableExcludePatterns":[".*_staging"]}`),
+	}))
+	tables := config.QueryPolicy().Tables
+	assert.True(t, tables.Allows("orders"))
+	assert.False(t, tables.Allows("orders_staging"))
+	assert.False(t, tables.Allows("customers"))
+}
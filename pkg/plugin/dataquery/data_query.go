@@ -2,8 +2,10 @@ package dataquery
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
 	"time"
 )
 
@@ -44,6 +46,7 @@ const (
 	VariableQueryTypeColumnList     VariableQueryType = "COLUMN_LIST"
 	VariableQueryTypeDistinctValues VariableQueryType = "DISTINCT_VALUES"
 	VariableQueryTypePinotQlCode    VariableQueryType = "PINOT_QL_CODE"
+	VariableQueryTypeDatabaseList   VariableQueryType = "DATABASE_LIST"
 )
 
 type ColumnType string
@@ -70,9 +73,17 @@ type DataQuery struct {
 	EditorMode  EditorMode  `json:"editorMode"`
 	DisplayType DisplayType `json:"displayType"`
 
-	TableName    string        `json:"tableName"`
-	QueryOptions []QueryOption `json:"queryOptions"`
-	SeriesLimit  int           `json:"seriesLimit"`
+	// Database overrides the database of the datasource for this query.
+	Database            string        `json:"database"`
+	TableName           string        `json:"tableName"`
+	QueryOptions        []QueryOption `json:"queryOptions"`
+	SeriesLimit         int           `json:"seriesLimit"`
+	UseMultistageEngine bool          `json:"useMultistageEngine"`
+	SkipResultCache     bool          `json:"skipResultCache"`
+	Trace               bool          `json:"trace"`
+
+	PartialResultPolicy    PartialResultPolicy `json:"partialResultPolicy"`
+	MinServerResponseRatio *float64            `json:"minServerResponseRatio"`
 
 	// Sql builder query
 	TimeColumn          string            `json:"timeColumn"`
@@ -116,8 +127,10 @@ type TimeRange struct {
 }
 
 type DimensionFilter struct {
-	ColumnName string   `json:"columnName"`
-	ColumnKey  string   `json:"columnKey,omitempty"`
+	ColumnName string              `json:"columnName"`
+	ColumnKey  string              `json:"columnKey,omitempty"`
+	Values     []pinot.FilterValue `json:"values,omitempty"`
+	// ValueExprs are literal SQL expressions. They are only used when Values is empty.
 	ValueExprs []string `json:"valueExprs"`
 	Operator   string   `json:"operator"`
 }
@@ -160,5 +173,30 @@ func (query *DataQuery) ReadFrom(backendQuery backend.DataQuery) error {
 	query.IntervalSize = backendQuery.Interval
 	query.MaxDataPoints = backendQuery.MaxDataPoints
 
+	if !query.PartialResultPolicy.IsValid() {
+		return fmt.Errorf("unknown partial result policy `%s`", query.PartialResultPolicy)
+	} else if ratio := query.MinServerResponseRatio; ratio != nil && (*ratio < 0 || *ratio > 1) {
+		return errors.New("min server response ratio must be between 0 and 1")
+	}
 	return nil
 }
+
+// ApplyDefaults sets the datasource defaults for settings the query does not override.
+func (query *DataQuery) ApplyDefaults(defaults QueryDefaults) {
+	if query.PartialResultPolicy == "" {
+		query.PartialResultPolicy = defaults.PartialResultPolicy
+	}
+	// Zero is a valid ratio that accepts any partial result, so only a missing ratio falls back to the default.
+	if query.MinServerResponseRatio == nil {
+		ratio := defaults.MinServerResponseRatio
+		query.MinServerResponseRatio = &ratio
+	}
+}
+
+func (query DataQuery) partialResultOptions() PartialResultOptions {
+	options := PartialResultOptions{Policy: query.PartialResultPolicy}
+	if query.MinServerResponseRatio != nil {
+		options.MinServerResponseRatio = *query.MinServerResponseRatio
+	}
+	return options
+}
@@ -1,6 +1,7 @@
 package dataquery
 
 import (
+	"errors"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
@@ -11,12 +12,74 @@ func NewEmptyDataResponse() backend.DataResponse {
 	return backend.DataResponse{Status: http.StatusOK}
 }
 
-func NewSqlQueryDataResponse(frame *data.Frame, exceptions []pinot.BrokerException) backend.DataResponse {
-	if len(exceptions) == 0 {
-		return NewOkDataResponse(frame)
-	} else {
-		return NewPartialDataResponse([]*data.Frame{frame}, exceptions)
+// NewBrokerDataResponse returns the frame annotated with the executed query and the broker execution stats.
+// When the broker returned trace info, the trace is included as an additional frame.
+// Results returned together with broker exceptions are handled according to the partial result policy.
+func NewBrokerDataResponse(frame *data.Frame, brokerResp *pinot.BrokerResponse, executedQuery string, policy PartialResultPolicy) backend.DataResponse {
+	if frame != nil {
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+		frame.Meta.ExecutedQueryString = executedQuery
+		frame.Meta.Stats = BrokerStatsOf(brokerResp)
+		if brokerResp.IsCached() {
+			custom, _ := frame.Meta.Custom.(map[string]interface{})
+			if custom == nil {
+				custom = make(map[string]interface{})
+			}
+			custom["cacheHit"] = true
+			custom["cachedAt"] = brokerResp.CachedAt
+			frame.Meta.Custom = custom
+		}
 	}
+	frames := []*data.Frame{frame}
+	if len(brokerResp.TraceInfo) > 0 {
+		frames = append(frames, ExtractTraceDataFrame(brokerResp.TraceEntries()))
+	}
+	if !brokerResp.HasExceptions() {
+		return NewOkDataResponse(frames...)
+	}
+
+	switch policy {
+	case PartialResultPolicySilent:
+		return NewOkDataResponse(frames...)
+	case PartialResultPolicyWarn:
+		if frame != nil {
+			frame.AppendNotices(data.Notice{
+				Severity: data.NoticeSeverityWarning,
+				Text:     "Results are incomplete. " + pinot.NewBrokerExceptionError(brokerResp.Exceptions).Error(),
+			})
+		}
+		return NewOkDataResponse(frames...)
+	default:
+		return NewPartialDataResponse(frames, brokerResp.Exceptions)
+	}
+}
+
+// BrokerStatsOf returns the execution stats reported by the broker.
+func BrokerStatsOf(brokerResp *pinot.BrokerResponse) []data.QueryStat {
+	newStat := func(name string, unit string, value int64) data.QueryStat {
+		return data.QueryStat{FieldConfig: data.FieldConfig{DisplayName: name, Unit: unit}, Value: float64(value)}
+	}
+
+	stats := []data.QueryStat{
+		newStat("Time used", "ms", brokerResp.TimeUsedMs),
+		newStat("Docs scanned", "", brokerResp.NumDocsScanned),
+		newStat("Total docs", "", brokerResp.TotalDocs),
+		newStat("Entries scanned in filter", "", brokerResp.NumEntriesScannedInFilter),
+		newStat("Entries scanned post filter", "", brokerResp.NumEntriesScannedPostFilter),
+		newStat("Segments queried", "", brokerResp.NumSegmentsQueried),
+		newStat("Segments processed", "", brokerResp.NumSegmentsProcessed),
+		newStat("Segments matched", "", brokerResp.NumSegmentsMatched),
+		newStat("Consuming segments queried", "", brokerResp.NumConsumingSegmentsQueried),
+		newStat("Servers queried", "", brokerResp.NumServersQueried),
+		newStat("Servers responded", "", brokerResp.NumServersResponded),
+	}
+	// Only realtime tables report a freshness time.
+	if brokerResp.MinConsumingFreshnessTimeMs > 0 {
+		stats = append(stats, newStat("Min consuming freshness time", "dateTimeAsIso", brokerResp.MinConsumingFreshnessTimeMs))
+	}
+	return stats
 }
 
 func NewOkDataResponse(frames ...*data.Frame) backend.DataResponse {
@@ -36,13 +99,32 @@ func NewPartialDataResponse(frames []*data.Frame, exceptions []pinot.BrokerExcep
 }
 
 func NewPinotExceptionsDataResponse(exceptions []pinot.BrokerException) backend.DataResponse {
+	err := pinot.NewBrokerExceptionError(exceptions)
 	return backend.DataResponse{
-		Status:      backend.StatusInternal,
-		Error:       pinot.NewBrokerExceptionError(exceptions),
+		Status:      BrokerErrorStatus(err.Kind()),
+		Error:       err,
 		ErrorSource: backend.ErrorSourceDownstream,
 	}
 }
 
+// BrokerErrorStatus returns the response status for broker exceptions of the given kind.
+func BrokerErrorStatus(kind pinot.BrokerErrorKind) backend.Status {
+	switch kind {
+	case pinot.BrokerErrorKindQueryTimeout:
+		return backend.StatusTimeout
+	case pinot.BrokerErrorKindTableNotFound:
+		return backend.StatusNotFound
+	case pinot.BrokerErrorKindColumnNotFound, pinot.BrokerErrorKindSqlParse:
+		return backend.StatusBadRequest
+	case pinot.BrokerErrorKindQuotaExceeded:
+		return backend.StatusTooManyRequests
+	case pinot.BrokerErrorKindServerNotResponding:
+		return backend.StatusBadGateway
+	default:
+		return backend.StatusInternal
+	}
+}
+
 func NewBadRequestErrorResponse(err error) backend.DataResponse {
 	return NewErrorDataResponse(backend.StatusBadRequest, err, backend.ErrorSourcePlugin)
 }
@@ -51,6 +133,19 @@ func NewPluginErrorResponse(err error) backend.DataResponse {
 	return NewInternalErrorDataResponse(err, backend.ErrorSourcePlugin)
 }
 
+// NewQueryErrorResponse returns a forbidden response for queries rejected by row-level security,
+// a bad request response for queries rejected by the query policy, and a plugin error otherwise.
+func NewQueryErrorResponse(err error) backend.DataResponse {
+	if errors.Is(err, ErrRowLevelSecurity) {
+		return NewErrorDataResponse(backend.StatusForbidden, err, backend.ErrorSourcePlugin)
+	} else if errors.Is(err, pinot.ErrQueryPolicy) || errors.Is(err, pinot.ErrInvalidQueryOption) {
+		return NewBadRequestErrorResponse(err)
+	} else if errors.Is(err, pinot.BrokerErrorKindQueryTimeout) {
+		return NewErrorDataResponse(backend.StatusTimeout, err, backend.ErrorSourceDownstream)
+	}
+	return NewPluginErrorResponse(err)
+}
+
 func NewDownstreamErrorResponse(err error) backend.DataResponse {
 	return NewInternalErrorDataResponse(err, backend.ErrorSourceDownstream)
 }
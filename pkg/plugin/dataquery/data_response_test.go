@@ -1,12 +1,16 @@
 package dataquery
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestNewEmptyDataResponse(t *testing.T) {
@@ -17,23 +21,91 @@ func TestNewEmptyDataResponse(t *testing.T) {
 	assert.Empty(t, got.ErrorSource)
 }
 
-func TestNewSqlQueryDataResponse(t *testing.T) {
-	frame := data.NewFrame("test")
-	t.Run("success", func(t *testing.T) {
-		got := NewSqlQueryDataResponse(frame, nil)
+func TestNewBrokerDataResponse(t *testing.T) {
+	t.Run("not cached", func(t *testing.T) {
+		got := NewBrokerDataResponse(data.NewFrame("test"), &pinot.BrokerResponse{TimeUsedMs: 5}, "SELECT 1", PartialResultPolicyFail)
+		assert.Equal(t, backend.StatusOK, got.Status)
+		require.Len(t, got.Frames, 1)
+		assert.Equal(t, "SELECT 1", got.Frames[0].Meta.ExecutedQueryString)
+		assert.Equal(t, BrokerStatsOf(&pinot.BrokerResponse{TimeUsedMs: 5}), got.Frames[0].Meta.Stats)
+		assert.Nil(t, got.Frames[0].Meta.Custom)
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		cachedAt := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+		got := NewBrokerDataResponse(data.NewFrame("test"), &pinot.BrokerResponse{CachedAt: cachedAt}, "SELECT 1", PartialResultPolicyFail)
 		assert.Equal(t, backend.StatusOK, got.Status)
-		assert.Equal(t, data.Frames{frame}, got.Frames)
-		assert.Empty(t, got.Error)
-		assert.Empty(t, got.ErrorSource)
+		require.Len(t, got.Frames, 1)
+		assert.Equal(t, map[string]interface{}{"cacheHit": true, "cachedAt": cachedAt}, got.Frames[0].Meta.Custom)
+	})
+
+	t.Run("keeps existing meta", func(t *testing.T) {
+		cachedAt := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+		frame := data.NewFrame("test").SetMeta(&data.FrameMeta{Custom: map[string]interface{}{"frameType": "LabeledTimeValues"}})
+		got := NewBrokerDataResponse(frame, &pinot.BrokerResponse{CachedAt: cachedAt}, "SELECT 1", PartialResultPolicyFail)
+		require.Len(t, got.Frames, 1)
+		assert.Equal(t, map[string]interface{}{
+			"frameType": "LabeledTimeValues",
+			"cacheHit":  true,
+			"cachedAt":  cachedAt,
+		}, got.Frames[0].Meta.Custom)
+	})
+
+	t.Run("trace", func(t *testing.T) {
+		brokerResp := &pinot.BrokerResponse{TraceInfo: map[string]string{"server_1": `[{"0":[{"FilterOperator Time":1}]}]`}}
+		got := NewBrokerDataResponse(data.NewFrame("test"), brokerResp, "SELECT 1", PartialResultPolicyFail)
+		assert.Equal(t, backend.StatusOK, got.Status)
+		require.Len(t, got.Frames, 2)
+		assert.Equal(t, ExtractTraceDataFrame(brokerResp.TraceEntries()), got.Frames[1])
 	})
 
 	t.Run("partial", func(t *testing.T) {
 		exceptions := []pinot.BrokerException{{Message: "error", ErrorCode: 1}}
-		got := NewSqlQueryDataResponse(frame, exceptions)
-		assert.Equal(t, backend.StatusInternal, got.Status)
-		assert.Equal(t, data.Frames{frame}, got.Frames)
-		assert.Equal(t, pinot.NewBrokerExceptionError(exceptions), got.Error)
-		assert.Equal(t, backend.ErrorSourceDownstream, got.ErrorSource)
+
+		t.Run("policy="+PartialResultPolicyFail.String(), func(t *testing.T) {
+			got := NewBrokerDataResponse(data.NewFrame("test"), &pinot.BrokerResponse{Exceptions: exceptions}, "SELECT 1", PartialResultPolicyFail)
+			assert.Equal(t, backend.StatusInternal, got.Status)
+			assert.Equal(t, pinot.NewBrokerExceptionError(exceptions), got.Error)
+			assert.Len(t, got.Frames, 1)
+		})
+
+		t.Run("policy="+PartialResultPolicyWarn.String(), func(t *testing.T) {
+			got := NewBrokerDataResponse(data.NewFrame("test"), &pinot.BrokerResponse{Exceptions: exceptions}, "SELECT 1", PartialResultPolicyWarn)
+			assert.Equal(t, backend.StatusOK, got.Status)
+			assert.NoError(t, got.Error)
+			require.Len(t, got.Frames, 1)
+			assert.Equal(t, []data.Notice{{
+				Severity: data.NoticeSeverityWarning,
+				Text:     "Results are incomplete. " + pinot.NewBrokerExceptionError(exceptions).Error(),
+			}}, got.Frames[0].Meta.Notices)
+		})
+
+		t.Run("policy="+PartialResultPolicySilent.String(), func(t *testing.T) {
+			got := NewBrokerDataResponse(data.NewFrame("test"), &pinot.BrokerResponse{Exceptions: exceptions}, "SELECT 1", PartialResultPolicySilent)
+			assert.Equal(t, backend.StatusOK, got.Status)
+			assert.NoError(t, got.Error)
+			require.Len(t, got.Frames, 1)
+			assert.Empty(t, got.Frames[0].Meta.Notices)
+		})
+	})
+}
+
+func TestBrokerStatsOf(t *testing.T) {
+	t.Run("offline", func(t *testing.T) {
+		got := BrokerStatsOf(&pinot.BrokerResponse{TimeUsedMs: 12, NumDocsScanned: 100, NumServersQueried: 2})
+		assert.Len(t, got, 11)
+		assert.Equal(t, data.QueryStat{FieldConfig: data.FieldConfig{DisplayName: "Time used", Unit: "ms"}, Value: 12}, got[0])
+		assert.Equal(t, data.QueryStat{FieldConfig: data.FieldConfig{DisplayName: "Docs scanned"}, Value: 100}, got[1])
+		assert.Equal(t, data.QueryStat{FieldConfig: data.FieldConfig{DisplayName: "Servers queried"}, Value: 2}, got[9])
+	})
+
+	t.Run("realtime", func(t *testing.T) {
+		got := BrokerStatsOf(&pinot.BrokerResponse{MinConsumingFreshnessTimeMs: 1727740800000})
+		assert.Len(t, got, 12)
+		assert.Equal(t, data.QueryStat{
+			FieldConfig: data.FieldConfig{DisplayName: "Min consuming freshness time", Unit: "dateTimeAsIso"},
+			Value:       1727740800000,
+		}, got[11])
 	})
 }
 
@@ -49,7 +121,7 @@ func TestNewOkDataResponse(t *testing.T) {
 func TestNewPartialDataResponse(t *testing.T) {
 	frame := data.NewFrame("test")
 	exceptions := []pinot.BrokerException{{Message: "error", ErrorCode: 1}}
-	got := NewSqlQueryDataResponse(frame, exceptions)
+	got := NewPartialDataResponse([]*data.Frame{frame}, exceptions)
 	assert.Equal(t, backend.StatusInternal, got.Status)
 	assert.Equal(t, data.Frames{frame}, got.Frames)
 	assert.Equal(t, pinot.NewBrokerExceptionError(exceptions), got.Error)
@@ -63,6 +135,24 @@ func TestNewPinotExceptionsDataResponse(t *testing.T) {
 	assert.Empty(t, got.Frames)
 	assert.Equal(t, pinot.NewBrokerExceptionError(exceptions), got.Error)
 	assert.Equal(t, backend.ErrorSourceDownstream, got.ErrorSource)
+
+	t.Run("classified", func(t *testing.T) {
+		exceptions := []pinot.BrokerException{{Message: "Timed out", ErrorCode: 250}}
+		got := NewPinotExceptionsDataResponse(exceptions)
+		assert.Equal(t, backend.StatusTimeout, got.Status)
+		assert.ErrorIs(t, got.Error, pinot.BrokerErrorKindQueryTimeout)
+		assert.Equal(t, backend.ErrorSourceDownstream, got.ErrorSource)
+	})
+}
+
+func TestBrokerErrorStatus(t *testing.T) {
+	assert.Equal(t, backend.StatusTimeout, BrokerErrorStatus(pinot.BrokerErrorKindQueryTimeout))
+	assert.Equal(t, backend.StatusNotFound, BrokerErrorStatus(pinot.BrokerErrorKindTableNotFound))
+	assert.Equal(t, backend.StatusBadRequest, BrokerErrorStatus(pinot.BrokerErrorKindColumnNotFound))
+	assert.Equal(t, backend.StatusBadRequest, BrokerErrorStatus(pinot.BrokerErrorKindSqlParse))
+	assert.Equal(t, backend.StatusTooManyRequests, BrokerErrorStatus(pinot.BrokerErrorKindQuotaExceeded))
+	assert.Equal(t, backend.StatusBadGateway, BrokerErrorStatus(pinot.BrokerErrorKindServerNotResponding))
+	assert.Equal(t, backend.StatusInternal, BrokerErrorStatus(pinot.BrokerErrorKindUnknown))
 }
 
 func TestNewPluginErrorResponse(t *testing.T) {
@@ -73,6 +163,14 @@ func TestNewPluginErrorResponse(t *testing.T) {
 	assert.Equal(t, backend.ErrorSourcePlugin, got.ErrorSource)
 }
 
+func TestNewQueryErrorResponse(t *testing.T) {
+	assert.Equal(t, backend.StatusForbidden, NewQueryErrorResponse(fmt.Errorf("%w: denied", ErrRowLevelSecurity)).Status)
+	assert.Equal(t, backend.StatusBadRequest, NewQueryErrorResponse(fmt.Errorf("%w: denied", pinot.ErrQueryPolicy)).Status)
+	assert.Equal(t, backend.StatusBadRequest, NewQueryErrorResponse(fmt.Errorf("%w: invalid", pinot.ErrInvalidQueryOption)).Status)
+	assert.Equal(t, backend.StatusTimeout, NewQueryErrorResponse(&pinot.QueryTimeoutError{Err: context.DeadlineExceeded}).Status)
+	assert.Equal(t, backend.StatusInternal, NewQueryErrorResponse(errors.New("error")).Status)
+}
+
 func TestNewDownstreamErrorResponse(t *testing.T) {
 	got := NewDownstreamErrorResponse(errors.New("error"))
 	assert.Equal(t, backend.StatusInternal, got.Status)
@@ -2,11 +2,15 @@ package dataquery
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -29,6 +33,15 @@ var queryDuration = promauto.NewSummaryVec(
 	[]string{"query_type", "status"},
 )
 
+var brokerExceptionsCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "grafana_plugin",
+		Name:      "pinot_data_query_broker_exceptions_total",
+		Help:      "Total number of queries to the Pinot data source that returned broker exceptions.",
+	},
+	[]string{"query_type", "kind"},
+)
+
 const (
 	DefaultQueryLimit   = 100_000
 	DefaultSeriesLimit  = 20
@@ -41,7 +54,7 @@ type ExecutableQuery interface {
 	Execute(client *pinot.Client, ctx context.Context) backend.DataResponse
 }
 
-func ExecuteQuery(client *pinot.Client, ctx context.Context, backendQuery backend.DataQuery) backend.DataResponse {
+func ExecuteQuery(client *pinot.Client, ctx context.Context, backendQuery backend.DataQuery, defaults QueryDefaults) backend.DataResponse {
 	startTime := time.Now()
 
 	var query DataQuery
@@ -49,7 +62,9 @@ func ExecuteQuery(client *pinot.Client, ctx context.Context, backendQuery backen
 	if err := query.ReadFrom(backendQuery); err != nil {
 		resp = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
 	} else {
-		resp = ExecutableQueryFrom(query).Execute(client, ctx)
+		query.ApplyDefaults(defaults)
+		resp = ExecutableQueryFrom(query).Execute(client.WithDatabase(query.Database), ctx)
+		warnUnknownQueryOptions(&resp, query.QueryOptions)
 	}
 
 	labels := prometheus.Labels{
@@ -58,6 +73,11 @@ func ExecuteQuery(client *pinot.Client, ctx context.Context, backendQuery backen
 	}
 	queryCounter.With(labels).Inc()
 	queryDuration.With(labels).Observe(time.Since(startTime).Seconds())
+
+	var brokerErr *pinot.BrokerExceptionError
+	if errors.As(resp.Error, &brokerErr) {
+		brokerExceptionsCounter.WithLabelValues(query.QueryType.String(), brokerErr.Kind().String()).Inc()
+	}
 	return resp
 }
 
@@ -74,44 +94,57 @@ func ExecutableQueryFrom(query DataQuery) ExecutableQuery {
 			IntervalSize: query.IntervalSize,
 			Legend:       query.Legend,
 			SeriesLimit:  query.SeriesLimit,
+			QueryOptions: query.QueryOptions,
 		}
 
 	case query.QueryType == QueryTypePinotVariableQuery:
 		return VariableQuery{
-			TableName:    query.TableName,
-			VariableType: query.VariableQuery.VariableType,
-			ColumnName:   query.VariableQuery.ColumnName,
-			PinotQlCode:  query.VariableQuery.PinotQlCode,
-			ColumnType:   query.VariableQuery.ColumnType,
+			TableName:           query.TableName,
+			VariableType:        query.VariableQuery.VariableType,
+			ColumnName:          query.VariableQuery.ColumnName,
+			PinotQlCode:         query.VariableQuery.PinotQlCode,
+			ColumnType:          query.VariableQuery.ColumnType,
+			UseMultistageEngine: query.UseMultistageEngine,
+			SkipResultCache:     query.SkipResultCache,
+			PartialResults:      query.partialResultOptions(),
 		}
 
 	case query.QueryType == QueryTypePinotQl && query.EditorMode == EditorModeCode:
 		return PinotQlCodeQuery{
-			Code:              query.PinotQlCode,
-			TableName:         query.TableName,
-			TimeColumnAlias:   query.TimeColumnAlias,
-			MetricColumnAlias: query.MetricColumnAlias,
-			LogColumnAlias:    query.LogColumnAlias,
-			TimeRange:         query.TimeRange,
-			IntervalSize:      query.IntervalSize,
-			DisplayType:       query.DisplayType,
-			Legend:            query.Legend,
-			SeriesLimit:       query.SeriesLimit,
+			Code:                query.PinotQlCode,
+			TableName:           query.TableName,
+			TimeColumnAlias:     query.TimeColumnAlias,
+			MetricColumnAlias:   query.MetricColumnAlias,
+			LogColumnAlias:      query.LogColumnAlias,
+			TimeRange:           query.TimeRange,
+			IntervalSize:        query.IntervalSize,
+			DisplayType:         query.DisplayType,
+			Legend:              query.Legend,
+			SeriesLimit:         query.SeriesLimit,
+			UseMultistageEngine: query.UseMultistageEngine,
+			SkipResultCache:     query.SkipResultCache,
+			PartialResults:      query.partialResultOptions(),
+			Trace:               query.Trace,
 		}
 
 	case query.QueryType == QueryTypePinotQl && query.EditorMode == EditorModeBuilder && query.DisplayType == DisplayTypeLogs:
 		return LogsBuilderQuery{
-			TimeRange:        query.TimeRange,
-			TableName:        query.TableName,
-			TimeColumn:       query.TimeColumn,
-			LogColumn:        query.LogColumn,
-			LogColumnAlias:   query.LogColumnAlias,
-			MetadataColumns:  query.MetadataColumns,
-			JsonExtractors:   query.JsonExtractors,
-			RegexpExtractors: query.RegexpExtractors,
-			DimensionFilters: query.DimensionFilters,
-			QueryOptions:     query.QueryOptions,
-			Limit:            query.Limit,
+			TimeRange:           query.TimeRange,
+			IntervalSize:        query.IntervalSize,
+			TableName:           query.TableName,
+			TimeColumn:          query.TimeColumn,
+			LogColumn:           query.LogColumn,
+			LogColumnAlias:      query.LogColumnAlias,
+			MetadataColumns:     query.MetadataColumns,
+			JsonExtractors:      query.JsonExtractors,
+			RegexpExtractors:    query.RegexpExtractors,
+			DimensionFilters:    query.DimensionFilters,
+			QueryOptions:        query.QueryOptions,
+			Limit:               query.Limit,
+			UseMultistageEngine: query.UseMultistageEngine,
+			SkipResultCache:     query.SkipResultCache,
+			PartialResults:      query.partialResultOptions(),
+			Trace:               query.Trace,
 		}
 
 	case query.QueryType == QueryTypePinotQl && query.EditorMode == EditorModeBuilder:
@@ -143,6 +176,10 @@ func ExecutableQueryFrom(query DataQuery) ExecutableQuery {
 			QueryOptions:        query.QueryOptions,
 			Legend:              query.Legend,
 			SeriesLimit:         query.SeriesLimit,
+			UseMultistageEngine: query.UseMultistageEngine,
+			SkipResultCache:     query.SkipResultCache,
+			PartialResults:      query.partialResultOptions(),
+			Trace:               query.Trace,
 		}
 
 	default:
@@ -160,24 +197,45 @@ func (d NoOpQuery) Execute(*pinot.Client, context.Context) backend.DataResponse
 
 func newSqlQueryWithOptions(sql string, options []QueryOption) pinot.SqlQuery {
 	query := pinot.NewSqlQuery(sql)
+	query.QueryOptions = pinotQueryOptionsOf(options)
+	return query
+}
+
+// pinotQueryOptionsOf returns the options that have both a name and a value.
+func pinotQueryOptionsOf(options []QueryOption) []pinot.QueryOption {
+	var pinotOptions []pinot.QueryOption
 	for _, o := range options {
 		if o.Name == "" || o.Value == "" {
 			continue
 		}
-		query.QueryOptions = append(query.QueryOptions, pinot.QueryOption{Name: o.Name, Value: o.Value})
+		pinotOptions = append(pinotOptions, pinot.QueryOption{Name: o.Name, Value: o.Value})
 	}
-	return query
+	return pinotOptions
+}
+
+// warnUnknownQueryOptions adds a warning to the first frame of the response for options that are not in the query option catalog.
+func warnUnknownQueryOptions(resp *backend.DataResponse, options []QueryOption) {
+	unknown := pinot.UnknownQueryOptions(pinotQueryOptionsOf(options))
+	if resp.Error != nil || len(unknown) == 0 || len(resp.Frames) == 0 {
+		return
+	}
+	resp.Frames[0].AppendNotices(data.Notice{
+		Severity: data.NoticeSeverityWarning,
+		Text:     fmt.Sprintf("Unknown query options: %s.", strings.Join(unknown, ", ")),
+	})
 }
 
-func doSqlQuery(ctx context.Context, pinotClient *pinot.Client, query pinot.SqlQuery) (*pinot.ResultTable, []pinot.BrokerException, bool, backend.DataResponse) {
+func doSqlQuery(ctx context.Context, pinotClient *pinot.Client, query pinot.SqlQuery, partialResults PartialResultOptions) (*pinot.BrokerResponse, bool, backend.DataResponse) {
 	resp, err := pinotClient.ExecuteSqlQuery(ctx, query)
 	if err != nil {
-		return nil, nil, false, NewPluginErrorResponse(err)
+		return nil, false, NewQueryErrorResponse(err)
+	} else if err = partialResults.checkServersResponded(resp); err != nil {
+		return nil, false, NewErrorDataResponse(backend.StatusBadGateway, err, backend.ErrorSourceDownstream)
 	} else if resp.HasData() {
-		return resp.ResultTable, resp.Exceptions, true, backend.DataResponse{}
+		return resp, true, backend.DataResponse{}
 	} else if resp.HasExceptions() {
-		return nil, resp.Exceptions, false, NewPinotExceptionsDataResponse(resp.Exceptions)
+		return nil, false, NewPinotExceptionsDataResponse(resp.Exceptions)
 	} else {
-		return nil, nil, false, NewEmptyDataResponse()
+		return nil, false, NewEmptyDataResponse()
 	}
 }
@@ -73,10 +73,11 @@ func TestExecutableQueryFrom(t *testing.T) {
 
 	t.Run("queryType="+string(QueryTypePromQl), func(t *testing.T) {
 		got := ExecutableQueryFrom(DataQuery{
-			QueryType: QueryTypePromQl,
+			QueryType:    QueryTypePromQl,
+			QueryOptions: []QueryOption{{Name: "timeoutMs", Value: "1"}},
 		})
 		if assert.IsType(t, PromQlQuery{}, got) {
-			assert.Equal(t, PromQlQuery{}, got.(PromQlQuery))
+			assert.Equal(t, PromQlQuery{QueryOptions: []QueryOption{{Name: "timeoutMs", Value: "1"}}}, got.(PromQlQuery))
 		}
 	})
 
@@ -97,6 +98,21 @@ func TestNoOpDriver_Execute(t *testing.T) {
 	assert.Empty(t, 0, got.ErrorSource)
 }
 
+func TestWarnUnknownQueryOptions(t *testing.T) {
+	options := []QueryOption{{Name: "timeoutMs", Value: "1"}, {Name: "customOption", Value: "x"}, {Name: "emptyOption"}}
+
+	resp := NewOkDataResponse(data.NewFrame("response"))
+	warnUnknownQueryOptions(&resp, options)
+	assert.Equal(t, []data.Notice{{
+		Severity: data.NoticeSeverityWarning,
+		Text:     "Unknown query options: customOption.",
+	}}, resp.Frames[0].Meta.Notices)
+
+	resp = NewOkDataResponse(data.NewFrame("response"))
+	warnUnknownQueryOptions(&resp, options[:1])
+	assert.Nil(t, resp.Frames[0].Meta)
+}
+
 func sliceToPointers[V any](arr []V) []*V {
 	res := make([]*V, len(arr))
 	for i := range arr {
@@ -137,6 +153,23 @@ type DriverTestCase struct {
 	IntervalSize time.Duration
 }
 
+// withoutBrokerMeta checks that the frames carry the executed query and the broker stats and strips them for comparison.
+func withoutBrokerMeta(t *testing.T, frames data.Frames) data.Frames {
+	t.Helper()
+	for _, frame := range frames {
+		if assert.NotNil(t, frame.Meta, "Frame.Meta") {
+			assert.NotEmpty(t, frame.Meta.ExecutedQueryString, "Frame.Meta.ExecutedQueryString")
+			assert.NotEmpty(t, frame.Meta.Stats, "Frame.Meta.Stats")
+			frame.Meta.ExecutedQueryString = ""
+			frame.Meta.Stats = nil
+			if assert.ObjectsAreEqual(data.FrameMeta{}, *frame.Meta) {
+				frame.Meta = nil
+			}
+		}
+	}
+	return frames
+}
+
 func runSqlQuerySumHappyPath(t *testing.T, newDriver func(testCase DriverTestCase) ExecutableQuery, wantFrames func(times []time.Time, values []float64) data.Frames) {
 	t.Helper()
 	client := test_helpers.SetupPinotAndCreateClient(t)
@@ -171,7 +204,7 @@ func runSqlQuerySumHappyPath(t *testing.T, newDriver func(testCase DriverTestCas
 			4.994997804782016e+07,
 			4.995001567005852e+07,
 		},
-	), got.Frames, "DataResponse.Frames")
+	), withoutBrokerMeta(t, got.Frames), "DataResponse.Frames")
 	assert.Empty(t, got.ErrorSource, "DataResponse.ErrorSource")
 	assert.NoError(t, got.Error, "DataResponse.Error")
 
@@ -211,7 +244,7 @@ func runSqlQuerySumPartialResults(t *testing.T, newDriver func(testCase DriverTe
 			598.2744783346354,
 			601.2399258074636,
 		},
-	), got.Frames, "DataResponse.Frames")
+	), withoutBrokerMeta(t, got.Frames), "DataResponse.Frames")
 	assert.Equal(t, backend.ErrorSourceDownstream, got.ErrorSource, "DataResponse.ErrorSource")
 	assertBrokerExceptionErrorWithCodes(t, got.Error, 305)
 }
@@ -228,7 +261,7 @@ func runSqlQueryDistinctValsHappyPath(t *testing.T, newDriver func(testCase Driv
 	assert.Equal(t, wantFrames([]string{
 		"db_record_write",
 		"http_request_handled",
-	}), got.Frames, "DataResponse.Frames")
+	}), withoutBrokerMeta(t, got.Frames), "DataResponse.Frames")
 	assert.Empty(t, got.ErrorSource, "DataResponse.ErrorSource")
 	assert.NoError(t, got.Error, "DataResponse.Error")
 }
@@ -285,7 +318,7 @@ func runSqlQueryDistinctValsPartialResults(t *testing.T, newDriver func(testCase
 			"301.0219221033833",
 			"301.1795843063259",
 		},
-	), got.Frames, "DataResponse.Frames")
+	), withoutBrokerMeta(t, got.Frames), "DataResponse.Frames")
 	assert.Equal(t, backend.ErrorSourceDownstream, got.ErrorSource, "DataResponse.ErrorSource")
 	assertBrokerExceptionErrorWithCodes(t, got.Error, 305)
 }
@@ -332,7 +365,7 @@ func runSqlQueryColumnDne(t *testing.T, newDriver func(testCase DriverTestCase)
 		},
 		IntervalSize: 1 * time.Minute,
 	}).Execute(client, context.Background())
-	assert.Equal(t, backend.StatusInternal, got.Status, "DataResponse.Status")
+	assert.Equal(t, backend.StatusBadRequest, got.Status, "DataResponse.Status")
 	assert.Empty(t, got.Frames, "DataResponse.Frames")
 	assert.Equal(t, backend.ErrorSourceDownstream, got.ErrorSource, "DataResponse.ErrorSource")
 	assertBrokerExceptionErrorWithCodes(t, got.Error, 710)
@@ -30,6 +30,27 @@ func ExtractTableDataFrame(results *pinot.ResultTable, timeColumn string) (*data
 	return frame, nil
 }
 
+// ExtractTraceDataFrame returns the operator timings of a traced query as a table.
+func ExtractTraceDataFrame(entries []pinot.TraceEntry) *data.Frame {
+	servers := make([]string, len(entries))
+	traceIds := make([]string, len(entries))
+	operators := make([]string, len(entries))
+	durations := make([]float64, len(entries))
+	for i, entry := range entries {
+		servers[i] = entry.Server
+		traceIds[i] = entry.TraceId
+		operators[i] = entry.Operator
+		durations[i] = entry.DurationMs
+	}
+
+	return data.NewFrame("trace",
+		data.NewField("operator", nil, operators),
+		data.NewField("server", nil, servers),
+		data.NewField("traceId", nil, traceIds),
+		data.NewField("duration", nil, durations).SetConfig(&data.FieldConfig{Unit: "ms"}),
+	).SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
+}
+
 func ExtractLogsDataFrame(results *pinot.ResultTable, timeColumn, logColumn string) (*data.Frame, error) {
 	linesIdx, err := pinot.GetColumnIdx(results, logColumn)
 	if err != nil {
@@ -50,6 +50,21 @@ func TestExtractLogsDataFrame(t *testing.T) {
 	assert.Equal(t, want, got)
 }
 
+func TestExtractTraceDataFrame(t *testing.T) {
+	got := ExtractTraceDataFrame([]pinot.TraceEntry{
+		{Server: "server_1", TraceId: "0", Operator: "FilterOperator", DurationMs: 1},
+		{Server: "server_2", TraceId: "0_0", Operator: "SelectionOnlyOperator", DurationMs: 2.5},
+	})
+
+	want := data.NewFrame("trace",
+		data.NewField("operator", nil, []string{"FilterOperator", "SelectionOnlyOperator"}),
+		data.NewField("server", nil, []string{"server_1", "server_2"}),
+		data.NewField("traceId", nil, []string{"0", "0_0"}),
+		data.NewField("duration", nil, []float64{1, 2.5}).SetConfig(&data.FieldConfig{Unit: "ms"}),
+	).SetMeta(&data.FrameMeta{PreferredVisualization: data.VisTypeTable})
+	assert.Equal(t, want, got)
+}
+
 func TestExtractColumnAsField(t *testing.T) {
 	client := test_helpers.SetupPinotAndCreateClient(t)
 
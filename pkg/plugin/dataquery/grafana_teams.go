@@ -0,0 +1,125 @@
+package dataquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGrafanaTeamsTTL is how long the teams of a user are cached.
+const DefaultGrafanaTeamsTTL = time.Minute
+
+// GrafanaTeams looks up the teams of Grafana users with the Grafana HTTP API.
+// Grafana does not send the teams of the user to plugins, so they are looked up with a service account token.
+// The service account needs permission to read users and teams.
+type GrafanaTeams struct {
+	httpClient *http.Client
+	// grafanaUrl defaults to the app url of the Grafana server that runs the plugin.
+	grafanaUrl string
+	token      string
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]grafanaTeamsEntry
+}
+
+type grafanaTeamsEntry struct {
+	teams    []string
+	loadedAt time.Time
+}
+
+func NewGrafanaTeams(httpClient *http.Client, grafanaUrl string, token string) *GrafanaTeams {
+	return &GrafanaTeams{
+		httpClient: httpClient,
+		grafanaUrl: grafanaUrl,
+		token:      token,
+		ttl:        DefaultGrafanaTeamsTTL,
+		now:        time.Now,
+		entries:    make(map[string]grafanaTeamsEntry),
+	}
+}
+
+// TeamsOf returns the names of the teams of the user in the org of the request.
+func (x *GrafanaTeams) TeamsOf(ctx context.Context, login string) ([]string, error) {
+	orgId := backend.PluginConfigFromContext(ctx).OrgID
+	key := strconv.FormatInt(orgId, 10) + "/" + login
+
+	x.mu.Lock()
+	entry, ok := x.entries[key]
+	x.mu.Unlock()
+	if ok && x.now().Sub(entry.loadedAt) < x.ttl {
+		return entry.teams, nil
+	}
+
+	teams, err := x.fetchTeams(ctx, orgId, login)
+	if err != nil {
+		return nil, err
+	}
+
+	x.mu.Lock()
+	x.entries[key] = grafanaTeamsEntry{teams: teams, loadedAt: x.now()}
+	x.mu.Unlock()
+	return teams, nil
+}
+
+func (x *GrafanaTeams) fetchTeams(ctx context.Context, orgId int64, login string) ([]string, error) {
+	var user struct {
+		Id int64 `json:"id"`
+	}
+	if err := x.get(ctx, orgId, "/api/users/lookup?loginOrEmail="+url.QueryEscape(login), &user); err != nil {
+		return nil, err
+	}
+
+	var teams []struct {
+		Name string `json:"name"`
+	}
+	if err := x.get(ctx, orgId, fmt.Sprintf("/api/users/%d/teams", user.Id), &teams); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(teams))
+	for i, team := range teams {
+		names[i] = team.Name
+	}
+	return names, nil
+}
+
+func (x *GrafanaTeams) get(ctx context.Context, orgId int64, endpoint string, dest any) error {
+	grafanaUrl := x.grafanaUrl
+	if grafanaUrl == "" {
+		appUrl, err := backend.GrafanaConfigFromContext(ctx).AppURL()
+		if err != nil {
+			return err
+		}
+		grafanaUrl = appUrl
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(grafanaUrl, "/")+endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+x.token)
+	req.Header.Set("Accept", "application/json")
+	if orgId != 0 {
+		req.Header.Set("X-Grafana-Org-Id", strconv.FormatInt(orgId, 10))
+	}
+
+	resp, err := x.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("grafana api %s returned status %s", req.URL.Path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
@@ -0,0 +1,76 @@
+package dataquery
+
+import (
+	"context"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newGrafanaTeamsTestServer serves the Grafana API with user jdoe in teams initech and sales.
+func newGrafanaTeamsTestServer(t *testing.T, requests *atomic.Int32) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests != nil {
+			requests.Add(1)
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/api/users/lookup" && r.URL.Query().Get("loginOrEmail") == "jdoe":
+			_, _ = w.Write([]byte(`{"id":7,"login":"jdoe"}`))
+		case r.URL.Path == "/api/users/7/teams" && r.Header.Get("X-Grafana-Org-Id") == "2":
+			_, _ = w.Write([]byte(`[{"id":1,"name":"initech"},{"id":2,"name":"sales"}]`))
+		case r.URL.Path == "/api/users/7/teams":
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestGrafanaTeams_TeamsOf(t *testing.T) {
+	var requests atomic.Int32
+	teams := NewGrafanaTeams(http.DefaultClient, newGrafanaTeamsTestServer(t, &requests), "token")
+	now := time.Unix(0, 0)
+	teams.now = func() time.Time { return now }
+	ctx := backend.WithPluginContext(context.Background(), backend.PluginContext{OrgID: 2})
+
+	got, err := teams.TeamsOf(ctx, "jdoe")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"initech", "sales"}, got)
+	assert.Equal(t, int32(2), requests.Load())
+
+	t.Run("cached", func(t *testing.T) {
+		got, err := teams.TeamsOf(ctx, "jdoe")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"initech", "sales"}, got)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(DefaultGrafanaTeamsTTL)
+		_, err := teams.TeamsOf(ctx, "jdoe")
+		require.NoError(t, err)
+		assert.Equal(t, int32(4), requests.Load())
+	})
+
+	t.Run("other org", func(t *testing.T) {
+		got, err := teams.TeamsOf(backend.WithPluginContext(context.Background(), backend.PluginContext{OrgID: 3}), "jdoe")
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := teams.TeamsOf(ctx, "guest")
+		assert.EqualError(t, err, "grafana api /api/users/lookup returned status 404 Not Found")
+	})
+}
@@ -5,22 +5,28 @@ import (
 	"fmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
+	"time"
 )
 
 var _ ExecutableQuery = LogsBuilderQuery{}
 
 type LogsBuilderQuery struct {
-	TimeRange        TimeRange
-	TableName        string
-	TimeColumn       string
-	LogColumn        ComplexField
-	LogColumnAlias   string
-	MetadataColumns  []ComplexField
-	JsonExtractors   []JsonExtractor
-	RegexpExtractors []RegexpExtractor
-	DimensionFilters []DimensionFilter
-	QueryOptions     []QueryOption
-	Limit            int64
+	TimeRange           TimeRange
+	IntervalSize        time.Duration
+	TableName           string
+	TimeColumn          string
+	LogColumn           ComplexField
+	LogColumnAlias      string
+	MetadataColumns     []ComplexField
+	JsonExtractors      []JsonExtractor
+	RegexpExtractors    []RegexpExtractor
+	DimensionFilters    []DimensionFilter
+	QueryOptions        []QueryOption
+	Limit               int64
+	UseMultistageEngine bool
+	SkipResultCache     bool
+	PartialResults      PartialResultOptions
+	Trace               bool
 }
 
 func (query LogsBuilderQuery) Validate() error {
@@ -32,7 +38,7 @@ func (query LogsBuilderQuery) Validate() error {
 	case query.TimeColumn == "":
 		return fmt.Errorf("time column is required")
 	default:
-		return nil
+		return ValidateFilters(query.DimensionFilters)
 	}
 }
 
@@ -43,20 +49,20 @@ func (query LogsBuilderQuery) Execute(client *pinot.Client, ctx context.Context)
 
 	sqlQuery, err := query.RenderSqlQuery(ctx, client)
 	if err != nil {
-		return NewPluginErrorResponse(err)
+		return NewQueryErrorResponse(err)
 	}
 
-	results, exceptions, ok, backendResp := doSqlQuery(ctx, client, sqlQuery)
+	brokerResp, ok, backendResp := doSqlQuery(ctx, client, sqlQuery, query.PartialResults)
 	if !ok {
 		return backendResp
 	}
 
-	frame, err := ExtractLogsDataFrame(results, query.TimeColumn, BuilderLogColumn)
+	frame, err := ExtractLogsDataFrame(brokerResp.ResultTable, query.TimeColumn, BuilderLogColumn)
 	if err != nil {
 		return NewPluginErrorResponse(err)
 	}
 
-	return NewSqlQueryDataResponse(frame, exceptions)
+	return NewBrokerDataResponse(frame, brokerResp, client.RenderSql(sqlQuery), query.PartialResults.Policy)
 }
 
 func (query LogsBuilderQuery) RenderSqlQuery(ctx context.Context, client *pinot.Client) (pinot.SqlQuery, error) {
@@ -70,29 +76,55 @@ func (query LogsBuilderQuery) RenderSqlQuery(ctx context.Context, client *pinot.
 		return pinot.SqlQuery{}, err
 	}
 
+	if err = CheckFilterDataTypes(tableSchema, query.DimensionFilters); err != nil {
+		return pinot.SqlQuery{}, err
+	}
+	filterExprs, err := FilterExprsFrom(query.DimensionFilters)
+	if err != nil {
+		return pinot.SqlQuery{}, err
+	}
+	filterExprs, err = AppendRowFilter(ctx, query.TableName, filterExprs)
+	if err != nil {
+		return pinot.SqlQuery{}, err
+	}
+
+	tableConfigs, err := client.ListTableConfigs(ctx, query.TableName)
+	if err != nil {
+		return pinot.SqlQuery{}, err
+	}
+	// Align the time filter like the $__timeFilter macro, so that refreshes within a bucket render the same query.
+	derivedGranularities := pinot.DerivedGranularitiesFor(tableConfigs, query.TimeColumn, OutputTimeFormat())
+	granularity := ResolveGranularity(ctx, "", timeColumnFormat, query.IntervalSize, derivedGranularities)
+
 	sql, err := pinot.RenderLogSql(pinot.LogSqlParams{
 		TableNameExpr:        pinot.ObjectExpr(query.TableName),
 		TimeColumn:           query.TimeColumn,
 		LogColumnExpr:        pinot.ComplexFieldExpr(query.LogColumn.Name, query.LogColumn.Key),
 		LogColumnAlias:       BuilderLogColumn,
 		MetadataColumns:      query.logsMetadataColumns(),
-		DimensionFilterExprs: FilterExprsFrom(query.DimensionFilters),
-		Limit:                query.resolveLimit(),
-		TimeFilterExpr: pinot.TimeFilterExpr(pinot.TimeFilter{
+		DimensionFilterExprs: filterExprs,
+		Limit:                client.Properties().QueryPolicy.ClampLimit(query.resolveLimit()),
+		UseMultistageEngine:  query.UseMultistageEngine,
+		TimeFilterExpr: pinot.TimeFilterBucketAlignedExpr(pinot.TimeFilter{
 			Column: query.TimeColumn,
 			Format: timeColumnFormat,
 			From:   query.TimeRange.From,
 			To:     query.TimeRange.To,
-		}),
+		}, granularity.Duration()),
 	})
 	if err != nil {
 		return pinot.SqlQuery{}, err
 	}
 
-	return newSqlQueryWithOptions(sql, query.QueryOptions), nil
+	return query.newSqlQuery(sql), nil
 }
 
 func (query LogsBuilderQuery) RenderSqlWithMacros() (string, error) {
+	filterExprs, err := FilterExprsFrom(query.DimensionFilters)
+	if err != nil {
+		return "", err
+	}
+
 	sql, err := pinot.RenderLogSql(pinot.LogSqlParams{
 		TableNameExpr:        MacroExprFor(MacroTable),
 		TimeColumn:           query.TimeColumn,
@@ -100,14 +132,23 @@ func (query LogsBuilderQuery) RenderSqlWithMacros() (string, error) {
 		LogColumnAlias:       BuilderLogColumn,
 		MetadataColumns:      query.logsMetadataColumns(),
 		TimeFilterExpr:       MacroExprFor(MacroTimeFilter, pinot.ObjectExpr(query.TimeColumn).String()),
-		DimensionFilterExprs: FilterExprsFrom(query.DimensionFilters),
+		DimensionFilterExprs: filterExprs,
 		Limit:                query.resolveLimit(),
+		UseMultistageEngine:  query.UseMultistageEngine,
 	})
 	if err != nil {
 		return "", err
 	}
-	return newSqlQueryWithOptions(sql, query.QueryOptions).RenderSql(), nil
+	return query.newSqlQuery(sql).RenderSql(), nil
+}
 
+func (query LogsBuilderQuery) newSqlQuery(sql string) pinot.SqlQuery {
+	sqlQuery := newSqlQueryWithOptions(sql, query.QueryOptions)
+	sqlQuery.TableName = query.TableName
+	sqlQuery.UseMultistageEngine = query.UseMultistageEngine
+	sqlQuery.SkipResultCache = query.SkipResultCache
+	sqlQuery.Trace = query.Trace
+	return sqlQuery
 }
 
 func (query LogsBuilderQuery) logsMetadataColumns() []pinot.ExprWithAlias {
@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/plugin/test_helpers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -45,5 +49,71 @@ func TestExecuteLogsBuilderQuery(t *testing.T) {
 		Custom: map[string]interface{}{"frameType": "LabeledTimeValues"},
 	}
 
-	assert.Equal(t, wantFrame, got.Frames[0])
+	assert.Equal(t, wantFrame, withoutBrokerMeta(t, got.Frames)[0])
+}
+
+func newLogsBuilderTestClient(t *testing.T, policy pinot.QueryPolicy) *pinot.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tables/orders":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			_, _ = w.Write([]byte(`{
+				"schemaName": "orders",
+				"dimensionFieldSpecs": [{"name": "message", "dataType": "STRING"}],
+				"dateTimeFieldSpecs": [{"name": "ts", "dataType": "LONG", "format": "1:MILLISECONDS:EPOCH", "granularity": "1:MILLISECONDS"}]
+			}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	client := pinot.NewPinotClient(http.DefaultClient, pinot.ClientProperties{
+		ControllerUrl: server.URL,
+		BrokerUrl:     server.URL,
+		QueryPolicy:   policy,
+	})
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestLogsBuilderQuery_RenderSqlQuery_TimeFilter(t *testing.T) {
+	client := newLogsBuilderTestClient(t, pinot.QueryPolicy{})
+
+	query := LogsBuilderQuery{
+		TimeRange:  TimeRange{From: time.UnixMilli(1500), To: time.UnixMilli(2500)},
+		TableName:  "orders",
+		TimeColumn: "ts",
+		LogColumn:  ComplexField{Name: "message"},
+	}
+	sqlQuery, err := query.RenderSqlQuery(context.Background(), client)
+	require.NoError(t, err)
+	assert.Contains(t, sqlQuery.Sql, `"ts" >= 1500 AND "ts" < 2500`)
+
+	query.IntervalSize = time.Second
+	sqlQuery, err = query.RenderSqlQuery(context.Background(), client)
+	require.NoError(t, err)
+	assert.Contains(t, sqlQuery.Sql, `"ts" >= 1000 AND "ts" < 3000`)
+
+	query.TimeRange = TimeRange{From: time.UnixMilli(1700), To: time.UnixMilli(2900)}
+	sqlQuery2, err := query.RenderSqlQuery(context.Background(), client)
+	require.NoError(t, err)
+	assert.Equal(t, sqlQuery.Sql, sqlQuery2.Sql)
+}
+
+func TestLogsBuilderQuery_RenderSqlQuery_MaxLimit(t *testing.T) {
+	client := newLogsBuilderTestClient(t, pinot.QueryPolicy{MaxLimit: 500})
+
+	query := LogsBuilderQuery{
+		TimeRange:  TimeRange{From: time.UnixMilli(1000), To: time.UnixMilli(2000)},
+		TableName:  "orders",
+		TimeColumn: "ts",
+		LogColumn:  ComplexField{Name: "message"},
+	}
+	sqlQuery, err := query.RenderSqlQuery(context.Background(), client)
+	require.NoError(t, err)
+	assert.Contains(t, sqlQuery.Sql, "LIMIT 500")
+
+	query.Limit = 10
+	sqlQuery, err = query.RenderSqlQuery(context.Background(), client)
+	require.NoError(t, err)
+	assert.Contains(t, sqlQuery.Sql, "LIMIT 10")
 }
@@ -0,0 +1,55 @@
+package dataquery
+
+import (
+	"fmt"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
+)
+
+// PartialResultPolicy decides how results returned together with broker exceptions are handled.
+type PartialResultPolicy string
+
+func (x PartialResultPolicy) String() string { return string(x) }
+
+const (
+	// PartialResultPolicyFail returns the results with an error.
+	PartialResultPolicyFail PartialResultPolicy = "FAIL"
+	// PartialResultPolicyWarn returns the results with a warning notice.
+	PartialResultPolicyWarn PartialResultPolicy = "WARN"
+	// PartialResultPolicySilent returns the results as if the query succeeded.
+	PartialResultPolicySilent PartialResultPolicy = "SILENT"
+)
+
+// IsValid returns true for known policies and the empty policy, which falls back to the default.
+func (x PartialResultPolicy) IsValid() bool {
+	switch x {
+	case "", PartialResultPolicyFail, PartialResultPolicyWarn, PartialResultPolicySilent:
+		return true
+	default:
+		return false
+	}
+}
+
+type PartialResultOptions struct {
+	Policy PartialResultPolicy
+	// Results are rejected when less than this fraction of the queried servers responded. Zero disables the check.
+	MinServerResponseRatio float64
+}
+
+// QueryDefaults are the datasource settings used by queries that do not override them.
+type QueryDefaults struct {
+	PartialResultPolicy    PartialResultPolicy
+	MinServerResponseRatio float64
+}
+
+// checkServersResponded returns an error if too few of the queried servers responded.
+func (x PartialResultOptions) checkServersResponded(resp *pinot.BrokerResponse) error {
+	if x.MinServerResponseRatio <= 0 || resp.NumServersQueried == 0 {
+		return nil
+	}
+	ratio := float64(resp.NumServersResponded) / float64(resp.NumServersQueried)
+	if ratio < x.MinServerResponseRatio {
+		return fmt.Errorf("only %d of %d servers responded, which is below the minimum response ratio of %g",
+			resp.NumServersResponded, resp.NumServersQueried, x.MinServerResponseRatio)
+	}
+	return nil
+}
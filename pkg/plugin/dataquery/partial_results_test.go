@@ -0,0 +1,60 @@
+package dataquery
+
+import (
+	"encoding/json"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPartialResultOptions_CheckServersResponded(t *testing.T) {
+	resp := &pinot.BrokerResponse{NumServersQueried: 4, NumServersResponded: 3}
+
+	assert.NoError(t, PartialResultOptions{}.checkServersResponded(resp))
+	assert.NoError(t, PartialResultOptions{MinServerResponseRatio: 0.75}.checkServersResponded(resp))
+	assert.EqualError(t, PartialResultOptions{MinServerResponseRatio: 0.8}.checkServersResponded(resp),
+		"only 3 of 4 servers responded, which is below the minimum response ratio of 0.8")
+	assert.NoError(t, PartialResultOptions{MinServerResponseRatio: 1}.checkServersResponded(&pinot.BrokerResponse{}))
+}
+
+func TestDataQuery_ApplyDefaults(t *testing.T) {
+	defaults := QueryDefaults{PartialResultPolicy: PartialResultPolicyWarn, MinServerResponseRatio: 0.5}
+
+	query := DataQuery{}
+	query.ApplyDefaults(defaults)
+	assert.Equal(t, PartialResultOptions{Policy: PartialResultPolicyWarn, MinServerResponseRatio: 0.5}, query.partialResultOptions())
+
+	query = DataQuery{PartialResultPolicy: PartialResultPolicySilent}
+	query.ApplyDefaults(defaults)
+	assert.Equal(t, PartialResultOptions{Policy: PartialResultPolicySilent, MinServerResponseRatio: 0.5}, query.partialResultOptions())
+
+	ratio := 0.9
+	query = DataQuery{MinServerResponseRatio: &ratio}
+	query.ApplyDefaults(defaults)
+	assert.Equal(t, PartialResultOptions{Policy: PartialResultPolicyWarn, MinServerResponseRatio: 0.9}, query.partialResultOptions())
+
+	// An explicit zero accepts any partial result, even when the default requires a ratio.
+	var zero float64
+	query = DataQuery{MinServerResponseRatio: &zero}
+	query.ApplyDefaults(defaults)
+	assert.Equal(t, PartialResultOptions{Policy: PartialResultPolicyWarn, MinServerResponseRatio: 0}, query.partialResultOptions())
+}
+
+func TestDataQuery_ReadFrom_PartialResults(t *testing.T) {
+	var query DataQuery
+	assert.NoError(t, query.ReadFrom(backend.DataQuery{JSON: json.RawMessage(`{"partialResultPolicy":"FAIL","minServerResponseRatio":0.75}`)}))
+	assert.Equal(t, PartialResultOptions{Policy: PartialResultPolicyFail, MinServerResponseRatio: 0.75}, query.partialResultOptions())
+
+	query = DataQuery{}
+	assert.NoError(t, query.ReadFrom(backend.DataQuery{JSON: json.RawMessage(`{"minServerResponseRatio":0}`)}))
+	query.ApplyDefaults(QueryDefaults{MinServerResponseRatio: 0.5})
+	assert.Equal(t, PartialResultOptions{MinServerResponseRatio: 0}, query.partialResultOptions())
+
+	assert.EqualError(t, new(DataQuery).ReadFrom(backend.DataQuery{JSON: json.RawMessage(`{"partialResultPolicy":"ignore"}`)}),
+		"unknown partial result policy `ignore`")
+	assert.EqualError(t, new(DataQuery).ReadFrom(backend.DataQuery{JSON: json.RawMessage(`{"minServerResponseRatio":1.5}`)}),
+		"min server response ratio must be between 0 and 1")
+	assert.EqualError(t, new(DataQuery).ReadFrom(backend.DataQuery{JSON: json.RawMessage(`{"minServerResponseRatio":-0.1}`)}),
+		"min server response ratio must be between 0 and 1")
+}
@@ -12,16 +12,20 @@ import (
 var _ ExecutableQuery = PinotQlCodeQuery{}
 
 type PinotQlCodeQuery struct {
-	Code              string
-	TableName         string
-	TimeColumnAlias   string
-	MetricColumnAlias string
-	LogColumnAlias    string
-	TimeRange         TimeRange
-	IntervalSize      time.Duration
-	DisplayType       DisplayType
-	Legend            string
-	SeriesLimit       int
+	Code                string
+	TableName           string
+	TimeColumnAlias     string
+	MetricColumnAlias   string
+	LogColumnAlias      string
+	TimeRange           TimeRange
+	IntervalSize        time.Duration
+	DisplayType         DisplayType
+	Legend              string
+	SeriesLimit         int
+	UseMultistageEngine bool
+	SkipResultCache     bool
+	PartialResults      PartialResultOptions
+	Trace               bool
 }
 
 func (query PinotQlCodeQuery) Validate() error {
@@ -46,20 +50,20 @@ func (query PinotQlCodeQuery) Execute(client *pinot.Client, ctx context.Context)
 
 	sqlQuery, err := query.RenderSqlQuery(ctx, client)
 	if err != nil {
-		return NewPluginErrorResponse(err)
+		return NewQueryErrorResponse(err)
 	}
 
-	results, exceptions, ok, backendResp := doSqlQuery(ctx, client, sqlQuery)
+	brokerResp, ok, backendResp := doSqlQuery(ctx, client, sqlQuery, query.PartialResults)
 	if !ok {
 		return backendResp
 	}
 
-	frame, err := query.ExtractResults(results)
+	frame, err := query.ExtractResults(brokerResp.ResultTable)
 	if err != nil {
 		return NewPluginErrorResponse(err)
 	}
 
-	return NewSqlQueryDataResponse(frame, exceptions)
+	return NewBrokerDataResponse(frame, brokerResp, client.RenderSql(sqlQuery), query.PartialResults.Policy)
 }
 
 func (query PinotQlCodeQuery) RenderSqlQuery(ctx context.Context, client *pinot.Client) (pinot.SqlQuery, error) {
@@ -73,8 +77,14 @@ func (query PinotQlCodeQuery) RenderSqlQuery(ctx context.Context, client *pinot.
 		return pinot.SqlQuery{}, err
 	}
 
+	tableExpr, requiresMultistageEngine, err := ConstrainCode(ctx, client, query.TableName, query.Code)
+	if err != nil {
+		return pinot.SqlQuery{}, err
+	}
+
 	sql, err := MacroEngine{
 		TableName:    query.TableName,
+		TableExpr:    tableExpr,
 		TableSchema:  tableSchema,
 		TableConfigs: tableConfigs,
 		TimeRange:    query.TimeRange,
@@ -88,7 +98,12 @@ func (query PinotQlCodeQuery) RenderSqlQuery(ctx context.Context, client *pinot.
 		}
 	}
 
-	return pinot.NewSqlQuery(sql), nil
+	sqlQuery := pinot.NewSqlQuery(sql)
+	sqlQuery.TableName = query.TableName
+	sqlQuery.UseMultistageEngine = query.UseMultistageEngine || requiresMultistageEngine
+	sqlQuery.SkipResultCache = query.SkipResultCache
+	sqlQuery.Trace = query.Trace
+	return sqlQuery, nil
 }
 
 func (query PinotQlCodeQuery) ExtractResults(results *pinot.ResultTable) (*data.Frame, error) {
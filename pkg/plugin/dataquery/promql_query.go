@@ -18,6 +18,7 @@ type PromQlQuery struct {
 	IntervalSize time.Duration
 	Legend       string
 	SeriesLimit  int
+	QueryOptions []QueryOption
 }
 
 func (query PromQlQuery) Execute(client *pinot.Client, ctx context.Context) backend.DataResponse {
@@ -25,6 +26,10 @@ func (query PromQlQuery) Execute(client *pinot.Client, ctx context.Context) back
 		return NewEmptyDataResponse()
 	}
 
+	if err := RejectUnfilterable(ctx, query.TableName); err != nil {
+		return NewQueryErrorResponse(err)
+	}
+
 	queryResponse, err := client.ExecuteTimeSeriesQuery(ctx, &pinot.TimeSeriesRangeQuery{
 		Language:  pinot.TimeSeriesQueryLanguagePromQl,
 		Query:     query.PromQlCode,
@@ -32,9 +37,11 @@ func (query PromQlQuery) Execute(client *pinot.Client, ctx context.Context) back
 		End:       query.TimeRange.To,
 		Step:      query.IntervalSize,
 		TableName: query.TableName,
+
+		QueryOptions: pinotQueryOptionsOf(query.QueryOptions),
 	})
 	if err != nil {
-		return NewPluginErrorResponse(err)
+		return NewQueryErrorResponse(err)
 	}
 
 	frames := extractTimeSeriesMatrix(queryResponse.Data.Result, query.Legend, query.IntervalSize, query.SeriesLimit)
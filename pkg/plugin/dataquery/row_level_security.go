@@ -0,0 +1,255 @@
+package dataquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrRowLevelSecurity is wrapped by the errors of queries that row-level security does not allow.
+var ErrRowLevelSecurity = errors.New("row-level security")
+
+// RowLevelSecurityCodeMode decides how code-mode sql of a table with row-level security is handled.
+type RowLevelSecurityCodeMode string
+
+const (
+	// RowLevelSecurityCodeModeReject rejects code-mode sql. This is the default.
+	RowLevelSecurityCodeModeReject RowLevelSecurityCodeMode = "reject"
+	// RowLevelSecurityCodeModeWrap expands $__table() to a filtered subquery and rejects sql that names tables directly.
+	// Subqueries require the multi-stage engine, so wrapped queries always use it.
+	RowLevelSecurityCodeModeWrap RowLevelSecurityCodeMode = "wrap"
+)
+
+// RowLevelSecurity maps Grafana users, teams and org roles to filters that are added to every query of a table.
+// A table is protected when any rule applies to it. Queries of a protected table are rejected if no rule matches the user.
+// Rules only match the user of the plugin context, since request headers can be set by the browser.
+// The teams of the user are looked up with GrafanaTeams.
+type RowLevelSecurity struct {
+	Rules    []RowLevelSecurityRule   `json:"rules"`
+	CodeMode RowLevelSecurityCodeMode `json:"codeMode"`
+	// GrafanaUrl is the url of the Grafana API that teams are looked up with.
+	// Defaults to the app url of the Grafana server that runs the plugin.
+	GrafanaUrl string `json:"grafanaUrl"`
+}
+
+// RowLevelSecurityRule grants the matching users access to the rows of the tables that match the filter.
+// A rule matches a user by login or email, by team name or by org role.
+// A rule without tables applies to all tables. A rule without a filter grants access to all rows.
+type RowLevelSecurityRule struct {
+	Users  []string `json:"users"`
+	Teams  []string `json:"teams"`
+	Roles  []string `json:"roles"`
+	Tables []string `json:"tables"`
+	Filter string   `json:"filter"`
+}
+
+func (x RowLevelSecurity) Enabled() bool {
+	return len(x.Rules) > 0
+}
+
+// HasTeamRules returns true when any rule matches teams.
+func (x RowLevelSecurity) HasTeamRules() bool {
+	return slices.ContainsFunc(x.Rules, func(rule RowLevelSecurityRule) bool { return len(rule.Teams) > 0 })
+}
+
+func (x RowLevelSecurity) Validate() error {
+	for i, rule := range x.Rules {
+		if len(rule.Users) == 0 && len(rule.Teams) == 0 && len(rule.Roles) == 0 {
+			return fmt.Errorf("row-level security rule %d must match users, teams or roles", i+1)
+		}
+	}
+	switch x.CodeMode {
+	case "", RowLevelSecurityCodeModeReject, RowLevelSecurityCodeModeWrap:
+		return nil
+	default:
+		return fmt.Errorf("unknown row-level security code mode `%s`", x.CodeMode)
+	}
+}
+
+// Principal identifies the Grafana user of a request.
+type Principal struct {
+	Login string
+	Email string
+	Role  string
+	Teams []string
+}
+
+func (x RowLevelSecurityRule) matches(principal Principal) bool {
+	for _, user := range x.Users {
+		if user != "" && (user == principal.Login || strings.EqualFold(user, principal.Email)) {
+			return true
+		}
+	}
+	for _, team := range x.Teams {
+		if slices.Contains(principal.Teams, team) {
+			return true
+		}
+	}
+	for _, role := range x.Roles {
+		if principal.Role != "" && strings.EqualFold(role, principal.Role) {
+			return true
+		}
+	}
+	return false
+}
+
+func (x RowLevelSecurityRule) appliesTo(table string) bool {
+	return len(x.Tables) == 0 || slices.Contains(x.Tables, table)
+}
+
+// FilterFor returns the filter for queries of the table by the principal.
+// Filters of several matching rules are combined with OR. The filter is empty when the table is not protected,
+// or when a matching rule has no filter.
+func (x RowLevelSecurity) FilterFor(principal Principal, table string) (pinot.SqlExpr, error) {
+	var protected bool
+	var filters pinot.OrExpr
+	for _, rule := range x.Rules {
+		if !rule.appliesTo(table) {
+			continue
+		}
+		protected = true
+		if !rule.matches(principal) {
+			continue
+		}
+		if strings.TrimSpace(rule.Filter) == "" {
+			return "", nil
+		}
+		// The filter is written by the datasource admin, so it is trusted sql.
+		filters = append(filters, pinot.Raw("("+rule.Filter+")"))
+	}
+
+	switch {
+	case !protected:
+		return "", nil
+	case len(filters) == 0:
+		return "", fmt.Errorf("%w: no rule grants access to table `%s`", ErrRowLevelSecurity, table)
+	default:
+		return filters.Expr(), nil
+	}
+}
+
+type rowLevelSecurityKey struct{}
+
+type rowLevelSecurityScope struct {
+	policy    RowLevelSecurity
+	principal func() (Principal, error)
+}
+
+func (x rowLevelSecurityScope) filterFor(table string) (pinot.SqlExpr, error) {
+	principal, err := x.principal()
+	if err != nil {
+		return "", err
+	}
+	return x.policy.FilterFor(principal, table)
+}
+
+// WithRowLevelSecurity returns a context that applies the policy to the queries of the request's user.
+// The user is taken from the plugin context, which is set by Grafana.
+// When the policy has team rules, the teams of the user are looked up once per request, on the first query of a table.
+// Queries fail if the teams cannot be looked up.
+func WithRowLevelSecurity(ctx context.Context, policy RowLevelSecurity, teams *GrafanaTeams) context.Context {
+	if !policy.Enabled() {
+		return ctx
+	}
+
+	var principal Principal
+	if user := backend.UserFromContext(ctx); user != nil {
+		principal.Login = user.Login
+		principal.Email = user.Email
+		principal.Role = user.Role
+	}
+
+	resolve := func() (Principal, error) { return principal, nil }
+	if policy.HasTeamRules() && principal.Login != "" {
+		resolve = sync.OnceValues(func() (Principal, error) {
+			if teams == nil {
+				return Principal{}, fmt.Errorf("%w: teams cannot be looked up without a Grafana service account token", ErrRowLevelSecurity)
+			}
+			userTeams, err := teams.TeamsOf(ctx, principal.Login)
+			if err != nil {
+				return Principal{}, fmt.Errorf("%w: failed to look up the teams of user `%s`: %w", ErrRowLevelSecurity, principal.Login, err)
+			}
+			resolved := principal
+			resolved.Teams = userTeams
+			return resolved, nil
+		})
+	}
+	return context.WithValue(ctx, rowLevelSecurityKey{}, rowLevelSecurityScope{policy: policy, principal: resolve})
+}
+
+// RowFilterFor returns the row-level security filter of the table for the user of the request.
+func RowFilterFor(ctx context.Context, table string) (pinot.SqlExpr, error) {
+	scope, ok := ctx.Value(rowLevelSecurityKey{}).(rowLevelSecurityScope)
+	if !ok {
+		return "", nil
+	}
+	return scope.filterFor(table)
+}
+
+// AppendRowFilter adds the row-level security filter of the table to the filter expressions.
+func AppendRowFilter(ctx context.Context, table string, filterExprs []pinot.SqlExpr) ([]pinot.SqlExpr, error) {
+	rowFilter, err := RowFilterFor(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	if rowFilter == "" {
+		return filterExprs, nil
+	}
+	return append(filterExprs, rowFilter), nil
+}
+
+// ConstrainCode returns the table expression for the $__table() macro of code-mode sql.
+// Code-mode sql can name any table, so it is rejected if it names a table with a row filter.
+// When the query's table has a row filter, the sql is rejected, or $__table() is replaced with a filtered subquery.
+// The returned flag is set when the query must run on the multi-stage engine.
+func ConstrainCode(ctx context.Context, client *pinot.Client, table string, code string) (pinot.SqlExpr, bool, error) {
+	tableExpr := pinot.ObjectExpr(table)
+	scope, ok := ctx.Value(rowLevelSecurityKey{}).(rowLevelSecurityScope)
+	if !ok {
+		return tableExpr, false, nil
+	}
+
+	rowFilter, err := scope.filterFor(table)
+	if err != nil {
+		return "", false, err
+	}
+	if rowFilter != "" && scope.policy.CodeMode != RowLevelSecurityCodeModeWrap {
+		return "", false, fmt.Errorf("%w: code-mode sql is not allowed for table `%s`", ErrRowLevelSecurity, table)
+	}
+
+	tables, err := client.ListTables(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	// $__table() is expanded by the macro engine, so it is not a direct reference.
+	for _, name := range pinot.FindTableReferences(tableMacroRegex.ReplaceAllString(code, " "), append(tables, table)) {
+		if nameFilter, err := scope.filterFor(name); err != nil || nameFilter != "" {
+			return "", false, fmt.Errorf("%w: sql must reference table `%s` with $__table()", ErrRowLevelSecurity, name)
+		}
+	}
+
+	if rowFilter == "" {
+		return tableExpr, false, nil
+	}
+	return pinot.SqlExpr(fmt.Sprintf("(SELECT * FROM %s WHERE %s) AS %s", tableExpr, rowFilter, tableExpr)), true, nil
+}
+
+// RejectUnfilterable returns an error if the table has a row filter, for queries that cannot apply one.
+func RejectUnfilterable(ctx context.Context, table string) error {
+	rowFilter, err := RowFilterFor(ctx, table)
+	if err != nil {
+		return err
+	}
+	if rowFilter != "" {
+		return fmt.Errorf("%w: table `%s` can only be queried with sql", ErrRowLevelSecurity, table)
+	}
+	return nil
+}
+
+var tableMacroRegex = regexp.MustCompile(`\$__table(\(\s*\))?`)
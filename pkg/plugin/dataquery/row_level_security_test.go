@@ -0,0 +1,208 @@
+package dataquery
+
+import (
+	"context"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testRowLevelSecurity = RowLevelSecurity{
+	Rules: []RowLevelSecurityRule{
+		{Users: []string{"jdoe"}, Tables: []string{"orders"}, Filter: `"tenant_id" = 'acme'`},
+		{Teams: []string{"initech"}, Tables: []string{"orders"}, Filter: `"tenant_id" = 'initech'`},
+		{Roles: []string{"Editor"}, Tables: []string{"orders"}, Filter: `"tenant_id" = 'globex'`},
+		{Users: []string{"auditor@example.com"}, Tables: []string{"orders"}, Filter: `"region" = 'eu'`},
+		{Roles: []string{"Admin"}, Tables: []string{"orders"}},
+	},
+}
+
+func TestRowLevelSecurity_FilterFor(t *testing.T) {
+	testCases := []struct {
+		name      string
+		principal Principal
+		table     string
+		want      pinot.SqlExpr
+		wantErr   string
+	}{
+		{name: "user by login", principal: Principal{Login: "jdoe"}, table: "orders", want: `(("tenant_id" = 'acme'))`},
+		{
+			name:      "several rules",
+			principal: Principal{Login: "jdoe", Role: "Editor"},
+			table:     "orders",
+			want:      `(("tenant_id" = 'acme') OR ("tenant_id" = 'globex'))`,
+		},
+		{name: "team", principal: Principal{Login: "guest", Teams: []string{"sales", "initech"}}, table: "orders", want: `(("tenant_id" = 'initech'))`},
+		{name: "user by email", principal: Principal{Login: "audit", Email: "Auditor@example.com"}, table: "orders", want: `(("region" = 'eu'))`},
+		{name: "unrestricted role", principal: Principal{Login: "jdoe", Role: "Admin"}, table: "orders", want: ``},
+		{name: "unprotected table", principal: Principal{}, table: "customers", want: ``},
+		{
+			name:      "no matching rule",
+			principal: Principal{Login: "guest", Role: "Viewer", Teams: []string{"sales"}},
+			table:     "orders",
+			wantErr:   "row-level security: no rule grants access to table `orders`",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testRowLevelSecurity.FilterFor(tt.principal, tt.table)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.ErrorIs(t, err, ErrRowLevelSecurity)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRowLevelSecurity_Validate(t *testing.T) {
+	assert.NoError(t, testRowLevelSecurity.Validate())
+	assert.NoError(t, RowLevelSecurity{}.Validate())
+	assert.EqualError(t, RowLevelSecurity{Rules: []RowLevelSecurityRule{{Filter: "1=1"}}}.Validate(),
+		"row-level security rule 1 must match users, teams or roles")
+	assert.EqualError(t, RowLevelSecurity{CodeMode: "allow"}.Validate(), "unknown row-level security code mode `allow`")
+}
+
+func TestWithRowLevelSecurity(t *testing.T) {
+	ctx := backend.WithUser(backend.WithPluginContext(context.Background(), backend.PluginContext{OrgID: 2}), &backend.User{Login: "jdoe", Email: "jdoe@example.com", Role: "Viewer"})
+
+	teams := NewGrafanaTeams(http.DefaultClient, newGrafanaTeamsTestServer(t, nil), "token")
+
+	rowFilter, err := RowFilterFor(WithRowLevelSecurity(ctx, testRowLevelSecurity, teams), "orders")
+	require.NoError(t, err)
+	assert.Equal(t, pinot.SqlExpr(`(("tenant_id" = 'acme') OR ("tenant_id" = 'initech'))`), rowFilter)
+
+	t.Run("teams lookup fails", func(t *testing.T) {
+		teams := NewGrafanaTeams(http.DefaultClient, "http://127.0.0.1:0", "token")
+		_, err := RowFilterFor(WithRowLevelSecurity(ctx, testRowLevelSecurity, teams), "orders")
+		assert.ErrorIs(t, err, ErrRowLevelSecurity)
+		assert.ErrorContains(t, err, "row-level security: failed to look up the teams of user `jdoe`")
+	})
+
+	t.Run("no teams lookup", func(t *testing.T) {
+		_, err := RowFilterFor(WithRowLevelSecurity(ctx, testRowLevelSecurity, nil), "orders")
+		assert.EqualError(t, err, "row-level security: teams cannot be looked up without a Grafana service account token")
+	})
+
+	t.Run("no user", func(t *testing.T) {
+		_, err := RowFilterFor(WithRowLevelSecurity(context.Background(), testRowLevelSecurity, nil), "orders")
+		assert.ErrorIs(t, err, ErrRowLevelSecurity)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		rowFilter, err := RowFilterFor(WithRowLevelSecurity(ctx, RowLevelSecurity{}, nil), "orders")
+		require.NoError(t, err)
+		assert.Empty(t, rowFilter)
+	})
+}
+
+func newRowLevelSecurityTestClient(t *testing.T) *pinot.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/tables":
+			_, _ = w.Write([]byte(`{"tables":["orders","customers"]}`))
+		case r.URL.Path == "/tables/orders", r.URL.Path == "/tables/customers":
+			_, _ = w.Write([]byte(`{}`))
+		case strings.HasSuffix(r.URL.Path, "/schema"):
+			_, _ = w.Write([]byte(`{
+				"schemaName": "orders",
+				"dimensionFieldSpecs": [{"name": "tenant_id", "dataType": "STRING"}, {"name": "message", "dataType": "STRING"}],
+				"dateTimeFieldSpecs": [{"name": "ts", "dataType": "LONG", "format": "1:MILLISECONDS:EPOCH", "granularity": "1:MILLISECONDS"}]
+			}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := pinot.NewPinotClient(http.DefaultClient, pinot.ClientProperties{ControllerUrl: server.URL, BrokerUrl: server.URL})
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestConstrainCode(t *testing.T) {
+	client := newRowLevelSecurityTestClient(t)
+	acme := backend.WithUser(context.Background(), &backend.User{Login: "jdoe"})
+	teams := NewGrafanaTeams(http.DefaultClient, newGrafanaTeamsTestServer(t, nil), "token")
+	wrapPolicy := testRowLevelSecurity
+	wrapPolicy.CodeMode = RowLevelSecurityCodeModeWrap
+
+	t.Run("disabled", func(t *testing.T) {
+		tableExpr, requiresMultistage, err := ConstrainCode(context.Background(), client, "orders", `SELECT * FROM orders`)
+		require.NoError(t, err)
+		assert.Equal(t, pinot.SqlExpr(`"orders"`), tableExpr)
+		assert.False(t, requiresMultistage)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		ctx := WithRowLevelSecurity(acme, testRowLevelSecurity, teams)
+		_, _, err := ConstrainCode(ctx, client, "orders", `SELECT * FROM $__table()`)
+		assert.EqualError(t, err, "row-level security: code-mode sql is not allowed for table `orders`")
+	})
+
+	t.Run("wrap", func(t *testing.T) {
+		ctx := WithRowLevelSecurity(acme, wrapPolicy, teams)
+		tableExpr, requiresMultistage, err := ConstrainCode(ctx, client, "orders", `SELECT 'orders', "customers_id" FROM $__table() WHERE "x" = 'FROM orders'`)
+		require.NoError(t, err)
+		assert.Equal(t, pinot.SqlExpr(`(SELECT * FROM "orders" WHERE (("tenant_id" = 'acme'))) AS "orders"`), tableExpr)
+		assert.True(t, requiresMultistage)
+	})
+
+	t.Run("wrap rejects direct references", func(t *testing.T) {
+		ctx := WithRowLevelSecurity(acme, wrapPolicy, teams)
+		for _, code := range []string{
+			`SELECT * FROM $__table() JOIN orders ON 1=1`,
+			`SELECT * FROM "ORDERS_OFFLINE"`,
+			`SELECT * FROM db.orders`,
+			"SELECT * FROM `orders`",
+		} {
+			_, _, err := ConstrainCode(ctx, client, "orders", code)
+			assert.EqualError(t, err, "row-level security: sql must reference table `orders` with $__table()", code)
+		}
+	})
+
+	t.Run("protected table from another table", func(t *testing.T) {
+		ctx := WithRowLevelSecurity(acme, testRowLevelSecurity, teams)
+		_, _, err := ConstrainCode(ctx, client, "customers", `SELECT * FROM $__table() WHERE id IN (SELECT id FROM orders)`)
+		assert.EqualError(t, err, "row-level security: sql must reference table `orders` with $__table()")
+
+		tableExpr, _, err := ConstrainCode(ctx, client, "customers", `SELECT * FROM customers`)
+		require.NoError(t, err)
+		assert.Equal(t, pinot.SqlExpr(`"customers"`), tableExpr)
+	})
+}
+
+func TestLogsBuilderQuery_RowLevelSecurity(t *testing.T) {
+	client := newRowLevelSecurityTestClient(t)
+	query := LogsBuilderQuery{
+		TimeRange:  TimeRange{From: time.UnixMilli(1000), To: time.UnixMilli(2000)},
+		TableName:  "orders",
+		TimeColumn: "ts",
+		LogColumn:  ComplexField{Name: "message"},
+	}
+	user := backend.WithUser(context.Background(), &backend.User{Login: "jdoe"})
+	guest := backend.WithUser(context.Background(), &backend.User{Login: "guest"})
+	teams := NewGrafanaTeams(http.DefaultClient, newGrafanaTeamsTestServer(t, nil), "token")
+
+	t.Run("filtered", func(t *testing.T) {
+		ctx := WithRowLevelSecurity(user, testRowLevelSecurity, teams)
+		sqlQuery, err := query.RenderSqlQuery(ctx, client)
+		require.NoError(t, err)
+		assert.Contains(t, sqlQuery.Sql, `AND (("tenant_id" = 'acme'))`)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		ctx := WithRowLevelSecurity(guest, testRowLevelSecurity, teams)
+		got := query.Execute(client, ctx)
+		assert.Equal(t, backend.StatusForbidden, got.Status)
+		assert.ErrorIs(t, got.Error, ErrRowLevelSecurity)
+	})
+}
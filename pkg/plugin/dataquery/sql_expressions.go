@@ -1,6 +1,9 @@
 package dataquery
 
-import "github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
+import (
+	"fmt"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
+)
 
 func OrderByExprs(orderByClauses []OrderByClause) []pinot.SqlExpr {
 	orderByExprs := make([]pinot.SqlExpr, 0, len(orderByClauses))
@@ -14,19 +17,69 @@ func OrderByExprs(orderByClauses []OrderByClause) []pinot.SqlExpr {
 	return orderByExprs[:]
 }
 
-func FilterExprsFrom(filters []DimensionFilter) []pinot.SqlExpr {
+func FilterExprsFrom(filters []DimensionFilter) ([]pinot.SqlExpr, error) {
 	exprs := make([]pinot.SqlExpr, 0, len(filters))
 	for _, filter := range filters {
-		expr := pinot.ColumnFilterExpr(pinot.ColumnFilter{
-			ColumnName: filter.ColumnName,
-			ColumnKey:  filter.ColumnKey,
-			ValueExprs: filter.ValueExprs,
-			Operator:   pinot.FilterOperator(filter.Operator),
-		})
+		columnFilter, err := columnFilterFrom(filter)
+		if err != nil {
+			return nil, err
+		}
+		expr := pinot.ColumnFilterExpr(columnFilter)
 		if expr == "" {
 			continue
 		}
 		exprs = append(exprs, expr)
 	}
-	return exprs[:]
+	return exprs[:], nil
+}
+
+// ValidateFilters returns an error if a filter has a value that is not a literal or does not fit the operator.
+func ValidateFilters(filters []DimensionFilter) error {
+	for _, filter := range filters {
+		if _, err := columnFilterFrom(filter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckFilterDataTypes returns an error if a typed filter value does not match the data type of its column.
+// Values of saved queries that only have value expressions are not checked, since Pinot used to coerce them.
+func CheckFilterDataTypes(schema pinot.TableSchema, filters []DimensionFilter) error {
+	for _, filter := range filters {
+		dataType, ok := pinot.GetColumnDataType(schema, filter.ColumnName, filter.ColumnKey)
+		if !ok {
+			continue
+		}
+		for _, val := range filter.Values {
+			if err := val.CheckDataType(dataType); err != nil {
+				return fmt.Errorf("filter on column `%s`: %w", filter.ColumnName, err)
+			}
+		}
+	}
+	return nil
+}
+
+func columnFilterFrom(filter DimensionFilter) (pinot.ColumnFilter, error) {
+	columnFilter := pinot.ColumnFilter{
+		ColumnName: filter.ColumnName,
+		ColumnKey:  filter.ColumnKey,
+		Values:     filter.Values,
+		Operator:   pinot.FilterOperator(filter.Operator),
+	}
+	if len(columnFilter.Values) == 0 {
+		// Saved queries hold the values as SQL expressions.
+		columnFilter.Values = make([]pinot.FilterValue, len(filter.ValueExprs))
+		for i, expr := range filter.ValueExprs {
+			val, err := pinot.ParseFilterValueExpr(expr)
+			if err != nil {
+				return pinot.ColumnFilter{}, fmt.Errorf("filter on column `%s`: %w", filter.ColumnName, err)
+			}
+			columnFilter.Values[i] = val
+		}
+	}
+	if err := columnFilter.Validate(); err != nil {
+		return pinot.ColumnFilter{}, fmt.Errorf("filter on column `%s`: %w", filter.ColumnName, err)
+	}
+	return columnFilter, nil
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"strings"
 	"testing"
 )
@@ -70,13 +71,97 @@ func TestFilterExprsFrom(t *testing.T) {
 	  {}
 	]`)).Decode(&filters))
 
-	got := FilterExprsFrom(filters)
+	got, err := FilterExprsFrom(filters)
+	require.NoError(t, err)
 	assert.EqualValues(t, []pinot.SqlExpr{
 		`("AirlineID" = 19393 OR "AirlineID" = 19790)`,
 		`("ArrTime" > -2147483648)`,
 		`("Cancelled" = 0)`,
 		`("Carrier" like 'DL')`,
-		`("Carrier" in 'DL')`,
-		`("Carrier" not in 'DL')`,
+		`("Carrier" in ('DL'))`,
+		`("Carrier" not in ('DL'))`,
 	}, got)
 }
+
+func TestFilterExprsFrom_TypedValues(t *testing.T) {
+	var filters []DimensionFilter
+	require.NoError(t, json.NewDecoder(strings.NewReader(`[
+	  {"columnName": "Carrier", "operator": "=", "values": [{"type": "string", "value": "it's"}, {"type": "null"}]},
+	  {"columnName": "Carrier", "operator": "!=", "values": [{"type": "null"}]},
+	  {"columnName": "AirlineID", "operator": "in", "values": [{"type": "list", "value": [{"type": "number", "value": 19393}, {"type": "number", "value": 19790}]}]},
+	  {"columnName": "Cancelled", "operator": "=", "values": [{"type": "boolean", "value": true}], "valueExprs": ["ignored"]},
+	  {"columnName": "Distance", "operator": ">", "values": [{"type": "number", "value": 1.5e3}]}
+	]`)).Decode(&filters))
+
+	got, err := FilterExprsFrom(filters)
+	require.NoError(t, err)
+	assert.EqualValues(t, []pinot.SqlExpr{
+		`("Carrier" = 'it''s' OR "Carrier" IS NULL)`,
+		`("Carrier" IS NOT NULL)`,
+		`("AirlineID" in (19393, 19790))`,
+		`("Cancelled" = TRUE)`,
+		`("Distance" > 1.5e3)`,
+	}, got)
+}
+
+func TestFilterExprsFrom_Invalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		filter  DimensionFilter
+		wantErr string
+	}{
+		{
+			name:    "value expr is not a literal",
+			filter:  DimensionFilter{ColumnName: "Carrier", Operator: "=", ValueExprs: []string{`'DL' OR 1=1`}},
+			wantErr: "filter on column `Carrier`: value expression `'DL' OR 1=1` is not a literal",
+		},
+		{
+			name:    "value expr is a subquery",
+			filter:  DimensionFilter{ColumnName: "Carrier", Operator: "in", ValueExprs: []string{`(SELECT 1)`}},
+			wantErr: "filter on column `Carrier`: value expression `(SELECT 1)` is not a literal",
+		},
+		{
+			name:    "list with equals",
+			filter:  DimensionFilter{ColumnName: "Carrier", Operator: "=", Values: []pinot.FilterValue{pinot.ListFilterValue(pinot.StringFilterValue("DL"))}},
+			wantErr: "filter on column `Carrier`: operator `=` does not accept a list value",
+		},
+		{
+			name:    "null with greater than",
+			filter:  DimensionFilter{ColumnName: "Carrier", Operator: ">", Values: []pinot.FilterValue{pinot.NullFilterValue()}},
+			wantErr: "filter on column `Carrier`: operator `>` does not accept a null value",
+		},
+		{
+			name:    "number with like",
+			filter:  DimensionFilter{ColumnName: "Carrier", Operator: "like", Values: []pinot.FilterValue{pinot.NumberFilterValue("1")}},
+			wantErr: "filter on column `Carrier`: operator `like` requires a string value, got number",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FilterExprsFrom([]DimensionFilter{tt.filter})
+			assert.EqualError(t, err, tt.wantErr)
+			assert.EqualError(t, ValidateFilters([]DimensionFilter{tt.filter}), tt.wantErr)
+		})
+	}
+}
+
+func TestCheckFilterDataTypes(t *testing.T) {
+	schema := pinot.TableSchema{
+		DimensionFieldSpecs: []pinot.DimensionFieldSpec{{Name: "Carrier", DataType: "STRING"}, {Name: "Cancelled", DataType: "BOOLEAN"}},
+		MetricFieldSpecs:    []pinot.MetricFieldSpec{{Name: "AirlineID", DataType: "INT"}},
+	}
+
+	assert.NoError(t, CheckFilterDataTypes(schema, []DimensionFilter{
+		{ColumnName: "Carrier", Operator: "=", Values: []pinot.FilterValue{pinot.StringFilterValue("DL"), pinot.NullFilterValue()}},
+		{ColumnName: "AirlineID", Operator: "in", Values: []pinot.FilterValue{pinot.ListFilterValue(pinot.NumberFilterValue("19393"))}},
+		{ColumnName: "Cancelled", Operator: "=", Values: []pinot.FilterValue{pinot.BooleanFilterValue(true)}},
+		{ColumnName: "Unknown", Operator: "=", Values: []pinot.FilterValue{pinot.BooleanFilterValue(true)}},
+		{ColumnName: "AirlineID", Operator: "=", ValueExprs: []string{`'19393'`}},
+	}))
+	assert.EqualError(t, CheckFilterDataTypes(schema, []DimensionFilter{
+		{ColumnName: "AirlineID", Operator: "=", Values: []pinot.FilterValue{pinot.StringFilterValue("19393")}},
+	}), "filter on column `AirlineID`: string value '19393' does not match column data type INT")
+	assert.EqualError(t, CheckFilterDataTypes(schema, []DimensionFilter{
+		{ColumnName: "AirlineID", Operator: "in", Values: []pinot.FilterValue{pinot.ListFilterValue(pinot.NumberFilterValue("1.5"))}},
+	}), "filter on column `AirlineID`: number value 1.5 does not match column data type INT")
+}
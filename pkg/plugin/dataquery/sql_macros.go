@@ -25,7 +25,9 @@ const (
 )
 
 type MacroEngine struct {
-	TableName    string
+	TableName string
+	// TableExpr replaces the table name in the expansion of $__table(), such as with a filtered subquery.
+	TableExpr    pinot.SqlExpr
 	TimeAlias    string
 	MetricAlias  string
 	TableSchema  pinot.TableSchema
@@ -66,7 +68,10 @@ func (x MacroEngine) ExpandMacros(ctx context.Context, query string) (string, er
 
 func (x MacroEngine) ExpandTableName(_ context.Context, query string) (string, error) {
 	return expandMacro(query, MacroTable, func(_ []string) (string, error) {
-		return fmt.Sprintf(`"%s"`, x.TableName), nil
+		if x.TableExpr != "" {
+			return x.TableExpr.String(), nil
+		}
+		return pinot.ObjectExpr(x.TableName).String(), nil
 	})
 }
 
@@ -151,13 +156,13 @@ func (x MacroEngine) ExpandTimeFrom(_ context.Context, query string) (string, er
 
 func (x MacroEngine) ExpandTimeAlias(_ context.Context, query string) (string, error) {
 	return expandMacro(query, MacroTimeAlias, func(_ []string) (string, error) {
-		return fmt.Sprintf(`"%s"`, x.TimeAlias), nil
+		return pinot.ObjectExpr(x.TimeAlias).String(), nil
 	})
 }
 
 func (x MacroEngine) ExpandMetricAlias(_ context.Context, query string) (string, error) {
 	return expandMacro(query, MacroMetricAlias, func(_ []string) (string, error) {
-		return fmt.Sprintf(`"%s"`, x.MetricAlias), nil
+		return pinot.ObjectExpr(x.MetricAlias).String(), nil
 	})
 }
 
@@ -218,7 +223,8 @@ func (x MacroEngine) ExpandPanelMillis(_ context.Context, query string) (string,
 }
 
 func expandMacro(query string, macroName string, render func(args []string) (string, error)) (string, error) {
-	re := regexp.MustCompile(fmt.Sprintf(`\$__%s(\([^)]*\))?`, macroName))
+	// Quoted arguments may contain parentheses and commas.
+	re := regexp.MustCompile(fmt.Sprintf(`\$__%s(\((?:"(?:[^"]|"")*"|'(?:[^']|'')*'|`+"`(?:[^`]|``)*`"+`|[^)"'`+"`"+`])*\))?`, macroName))
 	for _, matches := range re.FindAllStringSubmatch(query, -1) {
 		invocation, args := parseArgs(matches)
 		result, err := render(args)
@@ -256,10 +262,31 @@ func parseArgs(matches []string) (string, []string) {
 	}
 
 	argMatch = argMatch[1 : len(argMatch)-1] // Trim surrounding ().
-	rawArgs := strings.Split(argMatch, ",")
+	rawArgs := splitMacroArgs(argMatch)
 	args := make([]string, len(rawArgs))
 	for i := range rawArgs {
 		args[i] = strings.TrimSpace(rawArgs[i])
 	}
 	return matches[0], args
 }
+
+// splitMacroArgs splits the arguments at commas that are not inside quotes.
+func splitMacroArgs(argList string) []string {
+	var args []string
+	var quote rune
+	start := 0
+	for i, r := range argList {
+		switch {
+		case quote != 0 && r == quote:
+			// An escaped quote is two quotes, which closes and reopens the quoted section.
+			quote = 0
+		case quote != 0:
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == ',':
+			args = append(args, argList[start:i])
+			start = i + 1
+		}
+	}
+	return append(args, argList[start:])
+}
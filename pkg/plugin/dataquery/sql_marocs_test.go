@@ -218,3 +218,43 @@ func TestInvocationCoords(t *testing.T) {
 	assert.Equal(t, 2, line)
 	assert.Equal(t, 5, col)
 }
+
+func TestExpandMacros_QuotedNames(t *testing.T) {
+	engine := MacroEngine{
+		TableName:   `my"table`,
+		TimeAlias:   `time"`,
+		MetricAlias: `metric"`,
+		TableSchema: pinot.TableSchema{
+			DateTimeFieldSpecs: []pinot.DateTimeFieldSpec{{
+				Name:     `ts, "(utc)"`,
+				DataType: "LONG",
+				Format:   "1:SECONDS:EPOCH",
+			}},
+		},
+		TimeRange:    TimeRange{From: time.Unix(1, 0), To: time.Unix(90_001, 0)},
+		IntervalSize: 1 * time.Hour,
+	}
+
+	testArgs := []struct {
+		expr string
+		want string
+	}{
+		{expr: `$__table`, want: `"my""table"`},
+		{expr: `$__timeAlias`, want: `"time"""`},
+		{expr: `$__metricAlias`, want: `"metric"""`},
+		{expr: `$__timeFilter("ts, ""(utc)""", '1:SECONDS')`, want: `"ts, ""(utc)""" >= 1 AND "ts, ""(utc)""" < 90001`},
+		{expr: `$__timeTo("ts, ""(utc)""")`, want: `90001`},
+	}
+	for _, tt := range testArgs {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := engine.ExpandMacros(context.Background(), tt.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSplitMacroArgs(t *testing.T) {
+	assert.Equal(t, []string{`"a,b"`, ` 'c,''d'`, " `e,f`"}, splitMacroArgs("\"a,b\", 'c,''d', `e,f`"))
+	assert.Equal(t, []string{""}, splitMacroArgs(""))
+}
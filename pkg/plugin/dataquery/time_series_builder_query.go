@@ -28,6 +28,10 @@ type TimeSeriesBuilderQuery struct {
 	QueryOptions        []QueryOption
 	Legend              string
 	SeriesLimit         int
+	UseMultistageEngine bool
+	SkipResultCache     bool
+	PartialResults      PartialResultOptions
+	Trace               bool
 }
 
 func (query TimeSeriesBuilderQuery) Execute(client *pinot.Client, ctx context.Context) backend.DataResponse {
@@ -37,16 +41,16 @@ func (query TimeSeriesBuilderQuery) Execute(client *pinot.Client, ctx context.Co
 
 	sqlQuery, outputTimeFormat, err := query.RenderSqlQuery(ctx, client)
 	if err != nil {
-		return NewPluginErrorResponse(err)
+		return NewQueryErrorResponse(err)
 	}
 
-	results, exceptions, ok, backendResp := doSqlQuery(ctx, client, sqlQuery)
+	brokerResp, ok, backendResp := doSqlQuery(ctx, client, sqlQuery, query.PartialResults)
 	if !ok {
 		return backendResp
 	}
 
-	frame, err := query.ExtractResults(results, outputTimeFormat)
-	return NewSqlQueryDataResponse(frame, exceptions)
+	frame, err := query.ExtractResults(brokerResp.ResultTable, outputTimeFormat)
+	return NewBrokerDataResponse(frame, brokerResp, client.RenderSql(sqlQuery), query.PartialResults.Policy)
 }
 
 func (query TimeSeriesBuilderQuery) Validate() error {
@@ -59,8 +63,10 @@ func (query TimeSeriesBuilderQuery) Validate() error {
 		return errors.New("MetricColumn is required")
 	case query.AggregationFunction == "":
 		return errors.New("AggregationFunction is required")
+	case !pinot.IsSqlFunctionName(query.AggregationFunction):
+		return fmt.Errorf("AggregationFunction `%s` is not a valid function name", query.AggregationFunction)
 	default:
-		return nil
+		return ValidateFilters(query.DimensionFilters)
 	}
 }
 
@@ -80,24 +86,39 @@ func (query TimeSeriesBuilderQuery) RenderSqlQuery(ctx context.Context, client *
 		return pinot.SqlQuery{}, pinot.DateTimeFormat{}, err
 	}
 
+	if err = CheckFilterDataTypes(schema, query.DimensionFilters); err != nil {
+		return pinot.SqlQuery{}, pinot.DateTimeFormat{}, err
+	}
+	filterExprs, err := FilterExprsFrom(query.DimensionFilters)
+	if err != nil {
+		return pinot.SqlQuery{}, pinot.DateTimeFormat{}, err
+	}
+	filterExprs, err = AppendRowFilter(ctx, query.TableName, filterExprs)
+	if err != nil {
+		return pinot.SqlQuery{}, pinot.DateTimeFormat{}, err
+	}
+
 	var outputTimeFormat pinot.DateTimeFormat
 	var sql string
 	if query.AggregationFunction == AggregationFunctionNone {
 		outputTimeFormat = inputTimeFormat
+		// Align the time filter like the $__timeFilter macro, so that refreshes within a bucket render the same query.
+		derivedGranularities := pinot.DerivedGranularitiesFor(tableConfigs, query.TimeColumn, OutputTimeFormat())
+		granularity := ResolveGranularity(ctx, query.Granularity, inputTimeFormat, query.IntervalSize, derivedGranularities)
 		sql, err = pinot.RenderSingleMetricSql(pinot.SingleMetricSqlParams{
 			TableNameExpr:         pinot.ObjectExpr(query.TableName),
 			TimeColumn:            query.TimeColumn,
 			MetricColumnExpr:      query.metricExpr(),
 			TimeColumnAliasExpr:   pinot.ObjectExpr(BuilderTimeColumn),
 			MetricColumnAliasExpr: pinot.ObjectExpr(BuilderMetricColumn),
-			DimensionFilterExprs:  FilterExprsFrom(query.DimensionFilters),
-			Limit:                 query.resolveLimit(),
-			TimeFilterExpr: pinot.TimeFilterExpr(pinot.TimeFilter{
+			DimensionFilterExprs:  filterExprs,
+			Limit:                 client.Properties().QueryPolicy.ClampLimit(query.resolveLimit()),
+			TimeFilterExpr: pinot.TimeFilterBucketAlignedExpr(pinot.TimeFilter{
 				Column: query.TimeColumn,
 				Format: inputTimeFormat,
 				From:   query.TimeRange.From,
 				To:     query.TimeRange.To,
-			}),
+			}, granularity.Duration()),
 		})
 	} else {
 		outputTimeFormat = OutputTimeFormat()
@@ -112,9 +133,10 @@ func (query TimeSeriesBuilderQuery) RenderSqlQuery(ctx context.Context, client *
 			MetricColumnAliasExpr: pinot.ObjectExpr(BuilderMetricColumn),
 			AggregationFunction:   query.AggregationFunction,
 			GroupByColumnExprs:    query.groupByExprs(),
-			DimensionFilterExprs:  FilterExprsFrom(query.DimensionFilters),
-			Limit:                 query.resolveLimit(),
+			DimensionFilterExprs:  filterExprs,
+			Limit:                 client.Properties().QueryPolicy.ClampLimit(query.resolveLimit()),
 			OrderByExprs:          OrderByExprs(query.OrderByClauses),
+			UseMultistageEngine:   query.UseMultistageEngine,
 			TimeFilterExpr: pinot.TimeFilterBucketAlignedExpr(pinot.TimeFilter{
 				Column: query.TimeColumn,
 				Format: timeGroup.InputFormat,
@@ -127,12 +149,16 @@ func (query TimeSeriesBuilderQuery) RenderSqlQuery(ctx context.Context, client *
 		return pinot.SqlQuery{}, pinot.DateTimeFormat{}, err
 	}
 
-	return newSqlQueryWithOptions(sql, query.QueryOptions), outputTimeFormat, nil
+	return query.newSqlQuery(sql), outputTimeFormat, nil
 }
 
 func (query TimeSeriesBuilderQuery) RenderSqlWithMacros() (string, error) {
+	filterExprs, err := FilterExprsFrom(query.DimensionFilters)
+	if err != nil {
+		return "", err
+	}
+
 	var sql string
-	var err error
 
 	if query.AggregationFunction == AggregationFunctionNone {
 		sql, err = pinot.RenderSingleMetricSql(pinot.SingleMetricSqlParams{
@@ -142,7 +168,7 @@ func (query TimeSeriesBuilderQuery) RenderSqlWithMacros() (string, error) {
 			MetricColumnExpr:      pinot.ComplexFieldExpr(query.MetricColumn.Name, query.MetricColumn.Key),
 			MetricColumnAliasExpr: MacroExprFor(MacroMetricAlias),
 			TimeFilterExpr:        MacroExprFor(MacroTimeFilter, pinot.ObjectExpr(query.TimeColumn).String()),
-			DimensionFilterExprs:  FilterExprsFrom(query.DimensionFilters),
+			DimensionFilterExprs:  filterExprs,
 			Limit:                 query.resolveLimit(),
 		})
 	} else {
@@ -157,15 +183,25 @@ func (query TimeSeriesBuilderQuery) RenderSqlWithMacros() (string, error) {
 			MetricColumnAliasExpr: MacroExprFor(MacroMetricAlias),
 			GroupByColumnExprs:    query.groupByExprs(),
 			TimeFilterExpr:        MacroExprFor(MacroTimeFilter, timeColExpr.String(), granularityExpr.String()),
-			DimensionFilterExprs:  FilterExprsFrom(query.DimensionFilters),
+			DimensionFilterExprs:  filterExprs,
 			Limit:                 query.resolveLimit(),
 			OrderByExprs:          OrderByExprs(query.OrderByClauses),
+			UseMultistageEngine:   query.UseMultistageEngine,
 		})
 	}
 	if err != nil {
 		return "", err
 	}
-	return newSqlQueryWithOptions(sql, query.QueryOptions).RenderSql(), nil
+	return query.newSqlQuery(sql).RenderSql(), nil
+}
+
+func (query TimeSeriesBuilderQuery) newSqlQuery(sql string) pinot.SqlQuery {
+	sqlQuery := newSqlQueryWithOptions(sql, query.QueryOptions)
+	sqlQuery.TableName = query.TableName
+	sqlQuery.UseMultistageEngine = query.UseMultistageEngine
+	sqlQuery.SkipResultCache = query.SkipResultCache
+	sqlQuery.Trace = query.Trace
+	return sqlQuery
 }
 
 func (query TimeSeriesBuilderQuery) ExtractResults(results *pinot.ResultTable, outputTimeFormat pinot.DateTimeFormat) (*data.Frame, error) {
@@ -11,11 +11,14 @@ import (
 var _ ExecutableQuery = VariableQuery{}
 
 type VariableQuery struct {
-	VariableType VariableQueryType
-	TableName    string
-	ColumnName   string
-	ColumnType   ColumnType
-	PinotQlCode  string
+	VariableType        VariableQueryType
+	TableName           string
+	ColumnName          string
+	ColumnType          ColumnType
+	PinotQlCode         string
+	UseMultistageEngine bool
+	SkipResultCache     bool
+	PartialResults      PartialResultOptions
 }
 
 func (query VariableQuery) Execute(client *pinot.Client, ctx context.Context) backend.DataResponse {
@@ -26,6 +29,8 @@ func (query VariableQuery) Execute(client *pinot.Client, ctx context.Context) ba
 		return query.getDistinctValues(ctx, client)
 	case VariableQueryTypePinotQlCode:
 		return query.getSqlResults(ctx, client)
+	case VariableQueryTypeDatabaseList:
+		return query.getDatabaseList(ctx, client)
 	default:
 		return query.getTableList(ctx, client)
 	}
@@ -37,15 +42,23 @@ func (query VariableQuery) getSqlResults(ctx context.Context, client *pinot.Clie
 		return NewEmptyDataResponse()
 	}
 
-	sqlCode, err := MacroEngine{TableName: query.TableName}.ExpandTableName(ctx, sqlCode)
+	tableExpr, requiresMultistageEngine, err := ConstrainCode(ctx, client, query.TableName, sqlCode)
+	if err != nil {
+		return NewQueryErrorResponse(err)
+	}
+
+	sqlCode, err = MacroEngine{TableName: query.TableName, TableExpr: tableExpr}.ExpandTableName(ctx, sqlCode)
 	if err != nil {
 		return NewPluginErrorResponse(err)
 	}
 
-	results, exceptions, ok, backendResp := doSqlQuery(ctx, client, pinot.NewSqlQuery(sqlCode))
+	sqlQuery := query.newSqlQuery(sqlCode)
+	sqlQuery.UseMultistageEngine = sqlQuery.UseMultistageEngine || requiresMultistageEngine
+	brokerResp, ok, backendResp := doSqlQuery(ctx, client, sqlQuery, query.PartialResults)
 	if !ok {
 		return backendResp
 	}
+	results := brokerResp.ResultTable
 
 	cols := make([][]string, results.ColumnCount())
 	for colId := 0; colId < results.ColumnCount(); colId++ {
@@ -65,7 +78,7 @@ func (query VariableQuery) getSqlResults(ctx context.Context, client *pinot.Clie
 	}
 	values = pinot.GetDistinctValues(values)
 	frame := data.NewFrame("result", data.NewField("codeValues", nil, values))
-	return NewSqlQueryDataResponse(frame, exceptions)
+	return NewBrokerDataResponse(frame, brokerResp, client.RenderSql(sqlQuery), query.PartialResults.Policy)
 }
 
 func (query VariableQuery) getDistinctValues(ctx context.Context, client *pinot.Client) backend.DataResponse {
@@ -73,25 +86,32 @@ func (query VariableQuery) getDistinctValues(ctx context.Context, client *pinot.
 		return NewEmptyDataResponse()
 	}
 
+	filterExprs, err := AppendRowFilter(ctx, query.TableName, nil)
+	if err != nil {
+		return NewQueryErrorResponse(err)
+	}
+
 	sql, err := pinot.RenderDistinctValuesSql(pinot.DistinctValuesSqlParams{
-		ColumnExpr: pinot.ObjectExpr(query.ColumnName),
-		TableName:  query.TableName,
+		ColumnExpr:           pinot.ObjectExpr(query.ColumnName),
+		TableName:            query.TableName,
+		DimensionFilterExprs: filterExprs,
 	})
 	if err != nil {
 		return NewPluginErrorResponse(err)
 	}
 
-	results, exceptions, ok, backendResp := doSqlQuery(ctx, client, pinot.NewSqlQuery(sql))
+	sqlQuery := query.newSqlQuery(sql)
+	brokerResp, ok, backendResp := doSqlQuery(ctx, client, sqlQuery, query.PartialResults)
 	if !ok {
 		return backendResp
 	}
 
-	values, err := pinot.ExtractColumnAsStrings(results, 0)
+	values, err := pinot.ExtractColumnAsStrings(brokerResp.ResultTable, 0)
 	if err != nil {
 		return NewPluginErrorResponse(err)
 	}
 	frame := data.NewFrame("result", data.NewField("distinctValues", nil, values))
-	return NewSqlQueryDataResponse(frame, exceptions)
+	return NewBrokerDataResponse(frame, brokerResp, client.RenderSql(sqlQuery), query.PartialResults.Policy)
 }
 
 func (query VariableQuery) getColumnList(ctx context.Context, client *pinot.Client) backend.DataResponse {
@@ -100,7 +120,7 @@ func (query VariableQuery) getColumnList(ctx context.Context, client *pinot.Clie
 	}
 	schema, err := client.GetTableSchema(ctx, query.TableName)
 	if err != nil {
-		return NewPluginErrorResponse(err)
+		return NewQueryErrorResponse(err)
 	}
 
 	var columns []string
@@ -133,3 +153,20 @@ func (query VariableQuery) getTableList(ctx context.Context, client *pinot.Clien
 	frame := data.NewFrame("result", data.NewField("tables", nil, tables))
 	return NewOkDataResponse(frame)
 }
+
+func (query VariableQuery) getDatabaseList(ctx context.Context, client *pinot.Client) backend.DataResponse {
+	databases, err := client.ListDatabases(ctx)
+	if err != nil {
+		return NewPluginErrorResponse(err)
+	}
+	frame := data.NewFrame("result", data.NewField("databases", nil, databases))
+	return NewOkDataResponse(frame)
+}
+
+func (query VariableQuery) newSqlQuery(sql string) pinot.SqlQuery {
+	sqlQuery := pinot.NewSqlQuery(sql)
+	sqlQuery.TableName = query.TableName
+	sqlQuery.UseMultistageEngine = query.UseMultistageEngine
+	sqlQuery.SkipResultCache = query.SkipResultCache
+	return sqlQuery
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/plugin/dataquery"
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/plugin/log"
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/plugin/resources"
+	"net/http"
+	"sync"
 )
 
 var (
@@ -45,38 +47,85 @@ func NewInstance(ctx context.Context, settings backend.DataSourceInstanceSetting
 	}
 
 	log.FromContext(ctx).Info("HTTP client options", "opts", fmt.Sprintf("%+v", opts))
+	if unknown := pinot.UnknownQueryOptions(config.PinotQueryOptions()); len(unknown) > 0 {
+		log.FromContext(ctx).Warn("Datasource config has unknown query options.", "queryOptions", unknown)
+	}
 
 	httpClient, err := httpclient.New(opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
 	}
 
+	// Teams are looked up with a client without the datasource's auth and headers, which are meant for Pinot.
+	grafanaHttpClient, err := httpclient.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	teams := config.GrafanaTeams(grafanaHttpClient)
+
 	pinotClient := PinotClientOf(httpClient, config)
 	return &Datasource{
-		QueryDataHandler:    newQueryDataHandler(pinotClient),
-		CallResourceHandler: newCallResourceHandler(pinotClient),
+		QueryDataHandler:    newQueryDataHandler(pinotClient, config.ResolveMaxConcurrentQueries(), config.QueryDefaults(), config.RowLevelSecurity, teams),
+		CallResourceHandler: newCallResourceHandler(pinotClient, config.RowLevelSecurity, teams),
 		CheckHealthHandler:  newCheckHealthHandler(pinotClient),
-		InstanceDisposer:    disposerFunc(func() {}),
+		InstanceDisposer:    disposerFunc(pinotClient.Close),
 	}, nil
 }
 
-func newQueryDataHandler(client *pinot.Client) backend.QueryDataHandler {
+// newQueryDataHandler runs the queries of each request in parallel.
+// At most maxConcurrentQueries queries run at once across all requests to the instance.
+func newQueryDataHandler(client *pinot.Client, maxConcurrentQueries int, defaults dataquery.QueryDefaults, rowLevelSecurity dataquery.RowLevelSecurity, teams *dataquery.GrafanaTeams) backend.QueryDataHandler {
+	semaphore := make(chan struct{}, maxConcurrentQueries)
 	return backend.QueryDataHandlerFunc(func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
+		ctx = dataquery.WithRowLevelSecurity(ctx, rowLevelSecurity, teams)
 		// OAuth pass-through is now handled automatically by the SDK HTTP client
 		resp := backend.NewQueryDataResponse()
+		var mu sync.Mutex
+		var wg sync.WaitGroup
 		for _, query := range req.Queries {
-			log.FromContext(ctx).Debug("received Pinot data query", "contents", string(query.JSON))
-			resp.Responses[query.RefID] = dataquery.ExecuteQuery(client, ctx, query)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				queryResp := executeQuery(ctx, semaphore, client, query, defaults)
+				mu.Lock()
+				defer mu.Unlock()
+				resp.Responses[query.RefID] = queryResp
+			}()
 		}
+		wg.Wait()
 		return resp, nil
 	})
 }
 
-func newCallResourceHandler(client *pinot.Client) backend.CallResourceHandler {
-	return httpadapter.New(resources.NewResourceHandler(client))
+func executeQuery(ctx context.Context, semaphore chan struct{}, client *pinot.Client, query backend.DataQuery, defaults dataquery.QueryDefaults) (resp backend.DataResponse) {
+	select {
+	case semaphore <- struct{}{}:
+		defer func() { <-semaphore }()
+	case <-ctx.Done():
+		return dataquery.NewPluginErrorResponse(ctx.Err())
+	}
+
+	// A panic in a query goroutine would otherwise take down the plugin.
+	defer func() {
+		if r := recover(); r != nil {
+			log.FromContext(ctx).Error("Pinot data query panicked", "refId", query.RefID, "panic", r)
+			resp = dataquery.NewPluginErrorResponse(fmt.Errorf("query %s failed: %v", query.RefID, r))
+		}
+	}()
+
+	log.FromContext(ctx).Debug("received Pinot data query", "contents", string(query.JSON))
+	return dataquery.ExecuteQuery(client, ctx, query, defaults)
+}
+
+func newCallResourceHandler(client *pinot.Client, rowLevelSecurity dataquery.RowLevelSecurity, teams *dataquery.GrafanaTeams) backend.CallResourceHandler {
+	handler := resources.NewResourceHandler(client)
+	return httpadapter.New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := dataquery.WithRowLevelSecurity(r.Context(), rowLevelSecurity, teams)
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	}))
 }
 
 func newCheckHealthHandler(client *pinot.Client) backend.CheckHealthHandler {
@@ -85,31 +134,7 @@ func newCheckHealthHandler(client *pinot.Client) backend.CheckHealthHandler {
 			return nil, ctx.Err()
 		}
 		// OAuth pass-through is now handled automatically by the SDK HTTP client
-		// Test connection to controller
-		if tables, err := client.ListTables(ctx); err != nil {
-			return &backend.CheckHealthResult{
-				Status:  backend.HealthStatusError,
-				Message: err.Error(),
-			}, nil
-		} else if len(tables) == 0 {
-			return &backend.CheckHealthResult{
-				Status:  backend.HealthStatusError,
-				Message: fmt.Sprintf("Got an empty list of tables from %s. Please check the authentication and database settings.", client.Properties().ControllerUrl),
-			}, nil
-		}
-
-		// Test connection to broker
-		if _, err := client.ExecuteSqlQuery(ctx, pinot.NewSqlQuery("SELECT 1")); err != nil {
-			return &backend.CheckHealthResult{
-				Status:  backend.HealthStatusError,
-				Message: err.Error(),
-			}, nil
-		}
-
-		return &backend.CheckHealthResult{
-			Status:  backend.HealthStatusOk,
-			Message: "Pinot data source is working",
-		}, nil
+		return diagnoseHealth(ctx, client).Result()
 	})
 }
 
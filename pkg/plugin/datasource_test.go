@@ -3,11 +3,19 @@ package plugin
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/plugin/dataquery"
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/plugin/test_helpers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestDatasource(t *testing.T) {
@@ -29,7 +37,7 @@ func TestDatasource(t *testing.T) {
 func TestQueryData(t *testing.T) {
 	client := test_helpers.SetupPinotAndCreateClient(t)
 
-	handler := newQueryDataHandler(client)
+	handler := newQueryDataHandler(client, DefaultMaxConcurrentQueries, dataquery.QueryDefaults{}, dataquery.RowLevelSecurity{}, nil)
 	resp, err := handler.QueryData(
 		context.Background(),
 		&backend.QueryDataRequest{
@@ -46,3 +54,62 @@ func TestQueryData(t *testing.T) {
 		t.Fatal("QueryData must return a response")
 	}
 }
+
+func TestQueryData_Parallel(t *testing.T) {
+	const maxConcurrentQueries = 2
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/query/sql":
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				observed := maxInFlight.Load()
+				if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+					break
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+			_, _ = w.Write([]byte(`{"resultTable":{"dataSchema":{"columnNames":["value"],"columnDataTypes":["INT"]},"rows":[[1]]}}`))
+		case strings.HasSuffix(r.URL.Path, "/schema"):
+			_, _ = w.Write([]byte(`{"schemaName":"tbl"}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := pinot.NewPinotClient(http.DefaultClient, pinot.ClientProperties{ControllerUrl: server.URL, BrokerUrl: server.URL})
+	handler := newQueryDataHandler(client, maxConcurrentQueries, dataquery.QueryDefaults{}, dataquery.RowLevelSecurity{}, nil)
+
+	var queries []backend.DataQuery
+	for i := range 5 {
+		queries = append(queries, backend.DataQuery{
+			RefID:    fmt.Sprintf("Q%d", i),
+			Interval: time.Minute,
+			JSON: json.RawMessage(fmt.Sprintf(
+				`{"queryType":"PinotQL","editorMode":"Code","displayType":"TABLE","tableName":"tbl","pinotQlCode":"SELECT %d"}`, i)),
+		})
+	}
+	queries = append(queries, backend.DataQuery{RefID: "invalid", JSON: json.RawMessage(`not json`)})
+
+	resp, err := handler.QueryData(context.Background(), &backend.QueryDataRequest{Queries: queries})
+	require.NoError(t, err)
+	require.Len(t, resp.Responses, len(queries))
+	for i := range 5 {
+		assert.Equal(t, backend.StatusOK, resp.Responses[fmt.Sprintf("Q%d", i)].Status)
+	}
+	assert.Equal(t, backend.StatusBadRequest, resp.Responses["invalid"].Status)
+	assert.Equal(t, int32(maxConcurrentQueries), maxInFlight.Load())
+}
+
+func TestQueryData_Canceled(t *testing.T) {
+	client := pinot.NewPinotClient(http.DefaultClient, pinot.ClientProperties{})
+	handler := newQueryDataHandler(client, 1, dataquery.QueryDefaults{}, dataquery.RowLevelSecurity{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := handler.QueryData(ctx, &backend.QueryDataRequest{Queries: []backend.DataQuery{{RefID: "A"}}})
+	assert.ErrorIs(t, err, context.Canceled)
+}
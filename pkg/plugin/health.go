@@ -0,0 +1,269 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
+	"slices"
+	"strings"
+	"time"
+)
+
+type HealthCheckStatus string
+
+const (
+	HealthCheckStatusOk      HealthCheckStatus = "ok"
+	HealthCheckStatusWarning HealthCheckStatus = "warning"
+	HealthCheckStatusError   HealthCheckStatus = "error"
+	HealthCheckStatusSkipped HealthCheckStatus = "skipped"
+)
+
+// HealthCheck is the outcome of one step of the datasource health check.
+type HealthCheck struct {
+	Name    string            `json:"name"`
+	Status  HealthCheckStatus `json:"status"`
+	Message string            `json:"message,omitempty"`
+}
+
+// HealthDiagnostics are returned as the JSON details of the health check result.
+type HealthDiagnostics struct {
+	Checks               []HealthCheck       `json:"checks"`
+	ControllerUrl        string              `json:"controllerUrl"`
+	ControllerVersion    string              `json:"controllerVersion,omitempty"`
+	Brokers              []pinot.BrokerState `json:"brokers"`
+	Capabilities         *pinot.Capabilities `json:"capabilities,omitempty"`
+	SampleQueryLatencyMs *float64            `json:"sampleQueryLatencyMs,omitempty"`
+}
+
+// diagnoseHealth checks the controller, credentials, database, brokers, timeseries api and a sample query.
+// Every step runs unless it depends on a step that failed, so that one response explains all misconfigurations.
+// Cached capabilities and metadata are dropped first, so that every step reports the current state of the cluster.
+func diagnoseHealth(ctx context.Context, client *pinot.Client) HealthDiagnostics {
+	diagnostics := HealthDiagnostics{ControllerUrl: client.Properties().ControllerUrl}
+
+	client.InvalidateMetadataCache("")
+	capabilities, capabilitiesErr := client.RefreshCapabilities(ctx)
+	if capabilitiesErr == nil {
+		diagnostics.Capabilities = &capabilities
+		diagnostics.ControllerVersion = capabilities.Version
+	}
+
+	tables, tablesErr := client.ListTables(ctx)
+	diagnostics.addCheck(controllerHealthCheck(diagnostics.ControllerUrl, diagnostics.ControllerVersion, tablesErr))
+
+	diagnostics.Brokers = client.CheckBrokers(ctx)
+	diagnostics.addCheck(brokersHealthCheck(diagnostics.ControllerUrl, diagnostics.Brokers))
+
+	start := time.Now()
+	sampleQuery := pinot.NewSqlQuery("SELECT 1")
+	sampleQuery.SkipResultCache = true
+	sampleResp, sampleErr := client.ExecuteSqlQuery(ctx, sampleQuery)
+	if sampleErr == nil && sampleResp.HasExceptions() {
+		sampleErr = pinot.NewBrokerExceptionError(sampleResp.Exceptions)
+	}
+	if sampleErr == nil {
+		latencyMs := float64(time.Since(start).Microseconds()) / 1000
+		diagnostics.SampleQueryLatencyMs = &latencyMs
+	}
+
+	hasAuthorization := client.Properties().Authorization != "" || client.Properties().OAuth2ClientCredentials != nil
+	diagnostics.addCheck(authHealthCheck(hasAuthorization, tablesErr, sampleErr))
+	diagnostics.addCheck(databaseHealthCheck(ctx, client, capabilities, capabilitiesErr, tables, tablesErr))
+	diagnostics.addCheck(timeseriesHealthCheck(capabilities, capabilitiesErr))
+	diagnostics.addCheck(multistageEngineHealthCheck(capabilities, capabilitiesErr))
+	diagnostics.addCheck(sampleQueryHealthCheck(diagnostics.SampleQueryLatencyMs, sampleErr))
+	return diagnostics
+}
+
+func (x *HealthDiagnostics) addCheck(check HealthCheck) {
+	x.Checks = append(x.Checks, check)
+}
+
+// Result summarizes the diagnostics. The health check fails when any step failed.
+func (x HealthDiagnostics) Result() (*backend.CheckHealthResult, error) {
+	details, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+
+	var errors, warnings []string
+	for _, check := range x.Checks {
+		switch check.Status {
+		case HealthCheckStatusError:
+			errors = append(errors, check.Message)
+		case HealthCheckStatusWarning:
+			warnings = append(warnings, check.Message)
+		}
+	}
+
+	if len(errors) > 0 {
+		return &backend.CheckHealthResult{
+			Status:      backend.HealthStatusError,
+			Message:     strings.Join(errors, "\n"),
+			JSONDetails: details,
+		}, nil
+	}
+
+	message := "Pinot data source is working"
+	for _, warning := range warnings {
+		message += ". " + strings.TrimSuffix(warning, ".")
+	}
+	return &backend.CheckHealthResult{
+		Status:      backend.HealthStatusOk,
+		Message:     message,
+		JSONDetails: details,
+	}, nil
+}
+
+func controllerHealthCheck(controllerUrl string, version string, tablesErr error) HealthCheck {
+	check := HealthCheck{Name: "controller"}
+	switch {
+	case tablesErr == nil, pinot.IsStatusUnauthorizedError(tablesErr), pinot.IsStatusForbiddenError(tablesErr):
+		// A rejected request still shows the controller is reachable. The auth check reports the rejection.
+		check.Status = HealthCheckStatusOk
+		check.Message = fmt.Sprintf("Controller %s is reachable", controllerUrl)
+		if version != "" {
+			check.Message += fmt.Sprintf(" (version %s)", version)
+		}
+	default:
+		check.Status = HealthCheckStatusError
+		check.Message = fmt.Sprintf("Controller %s is not reachable: %s", controllerUrl, tablesErr)
+	}
+	return check
+}
+
+func brokersHealthCheck(controllerUrl string, brokers []pinot.BrokerState) HealthCheck {
+	check := HealthCheck{Name: "brokers"}
+	if len(brokers) == 0 {
+		check.Status = HealthCheckStatusError
+		check.Message = fmt.Sprintf("Found no brokers. Please check the broker settings and that %s lists the cluster brokers.", controllerUrl)
+		return check
+	}
+
+	var downBrokers []string
+	for _, broker := range brokers {
+		if !broker.Healthy {
+			downBrokers = append(downBrokers, fmt.Sprintf("%s: %s", broker.Url, broker.LastError))
+		}
+	}
+	switch {
+	case len(downBrokers) == len(brokers):
+		check.Status = HealthCheckStatusError
+		check.Message = strings.Join(downBrokers, "\n")
+	case len(downBrokers) > 0:
+		check.Status = HealthCheckStatusWarning
+		check.Message = fmt.Sprintf("%d of %d brokers are unavailable (%s)", len(downBrokers), len(brokers), strings.Join(downBrokers, "; "))
+	default:
+		check.Status = HealthCheckStatusOk
+		check.Message = fmt.Sprintf("%d of %d brokers are reachable", len(brokers), len(brokers))
+	}
+	return check
+}
+
+func authHealthCheck(hasAuthorization bool, errs ...error) HealthCheck {
+	check := HealthCheck{Name: "auth"}
+	for _, err := range errs {
+		switch {
+		case pinot.IsStatusUnauthorizedError(err):
+			check.Status = HealthCheckStatusError
+			check.Message = "Pinot rejected the credentials (401 Unauthorized). Please check the token type and token."
+			return check
+		case pinot.IsStatusForbiddenError(err):
+			check.Status = HealthCheckStatusError
+			check.Message = "The credentials are not allowed to access Pinot (403 Forbidden). Please check the permissions of the token."
+			return check
+		}
+	}
+
+	check.Status = HealthCheckStatusOk
+	if hasAuthorization {
+		check.Message = "Credentials are valid"
+	} else {
+		check.Message = "No credentials are configured"
+	}
+	return check
+}
+
+func databaseHealthCheck(ctx context.Context, client *pinot.Client, capabilities pinot.Capabilities, capabilitiesErr error, tables []string, tablesErr error) HealthCheck {
+	check := HealthCheck{Name: "database"}
+	database := client.Properties().DatabaseName
+	if database == "" {
+		database = pinot.DefaultDatabase
+	}
+
+	if tablesErr != nil {
+		check.Status = HealthCheckStatusSkipped
+		check.Message = "Tables could not be listed"
+		return check
+	}
+
+	if database != pinot.DefaultDatabase && capabilitiesErr == nil && capabilities.Databases {
+		databases, err := client.ListDatabases(ctx)
+		if err == nil && !slices.Contains(databases, database) {
+			check.Status = HealthCheckStatusError
+			check.Message = fmt.Sprintf("Database %s is not visible. Please check the database setting and the permissions of the token.", database)
+			return check
+		}
+	}
+
+	if len(tables) == 0 {
+		check.Status = HealthCheckStatusError
+		check.Message = fmt.Sprintf("Got an empty list of tables from %s. Please check the authentication and database settings.", client.Properties().ControllerUrl)
+		return check
+	}
+
+	check.Status = HealthCheckStatusOk
+	check.Message = fmt.Sprintf("%d tables are visible in database %s", len(tables), database)
+	return check
+}
+
+func timeseriesHealthCheck(capabilities pinot.Capabilities, capabilitiesErr error) HealthCheck {
+	check := HealthCheck{Name: "timeseries"}
+	switch {
+	case capabilitiesErr != nil:
+		check.Status = HealthCheckStatusWarning
+		check.Message = fmt.Sprintf("Timeseries API availability is unknown: %s", capabilitiesErr)
+	case capabilities.IsUnknown("timeseriesApi"):
+		check.Status = HealthCheckStatusWarning
+		check.Message = "Timeseries API availability is unknown, since the probe of the brokers failed"
+	case capabilities.TimeseriesApi:
+		check.Status = HealthCheckStatusOk
+		check.Message = "Timeseries API is available"
+	default:
+		// The timeseries api is optional, so only report whether it is available.
+		check.Status = HealthCheckStatusWarning
+		check.Message = "Timeseries API is not available, so PromQL queries are not supported"
+	}
+	return check
+}
+
+func multistageEngineHealthCheck(capabilities pinot.Capabilities, capabilitiesErr error) HealthCheck {
+	check := HealthCheck{Name: "multistageEngine"}
+	switch {
+	case capabilitiesErr != nil, capabilities.IsUnknown("multistageEngine"):
+		check.Status = HealthCheckStatusSkipped
+		check.Message = "Cluster capabilities could not be detected"
+	case capabilities.MultistageEngine:
+		check.Status = HealthCheckStatusOk
+		check.Message = "The multi-stage query engine is available"
+	default:
+		// The multi-stage engine is optional, so only report whether it is available.
+		check.Status = HealthCheckStatusWarning
+		check.Message = "The multi-stage query engine is not available"
+	}
+	return check
+}
+
+func sampleQueryHealthCheck(latencyMs *float64, sampleErr error) HealthCheck {
+	check := HealthCheck{Name: "sampleQuery"}
+	if sampleErr != nil {
+		check.Status = HealthCheckStatusError
+		check.Message = fmt.Sprintf("Sample query failed: %s", sampleErr)
+		return check
+	}
+	check.Status = HealthCheckStatusOk
+	check.Message = fmt.Sprintf("Sample query completed in %.1f ms", *latencyMs)
+	return check
+}
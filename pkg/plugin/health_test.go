@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckHealth(t *testing.T) {
+	const sampleQueryResponse = `{"resultTable":{"dataSchema":{"columnNames":["EXPR$0"],"columnDataTypes":["INT"]},"rows":[[1]]}}`
+
+	newClient := func(t *testing.T, routes map[string]string, status int) *pinot.Client {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			body, ok := routes[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		client := pinot.NewPinotClient(http.DefaultClient, pinot.ClientProperties{ControllerUrl: server.URL, BrokerUrl: server.URL})
+		t.Cleanup(client.Close)
+		return client
+	}
+
+	checkHealth := func(t *testing.T, client *pinot.Client) (*backend.CheckHealthResult, map[string]HealthCheck) {
+		result, err := newCheckHealthHandler(client).CheckHealth(context.Background(), &backend.CheckHealthRequest{})
+		require.NoError(t, err)
+
+		var diagnostics HealthDiagnostics
+		require.NoError(t, json.Unmarshal(result.JSONDetails, &diagnostics))
+		checks := make(map[string]HealthCheck)
+		for _, check := range diagnostics.Checks {
+			checks[check.Name] = check
+		}
+		return result, checks
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		client := newClient(t, map[string]string{
+			"/version":   `{"pinot-controller":"1.3.0"}`,
+			"/tables":    `{"tables":["tbl"]}`,
+			"/query/sql": sampleQueryResponse,
+		}, http.StatusOK)
+
+		result, checks := checkHealth(t, client)
+		assert.Equal(t, backend.HealthStatusOk, result.Status)
+		assert.Equal(t, "Pinot data source is working. Timeseries API is not available, so PromQL queries are not supported", result.Message)
+		assert.Equal(t, HealthCheckStatusOk, checks["controller"].Status)
+		assert.Contains(t, checks["controller"].Message, "version 1.3.0")
+		assert.Equal(t, HealthCheckStatusOk, checks["brokers"].Status)
+		assert.Equal(t, HealthCheckStatusOk, checks["auth"].Status)
+		assert.Equal(t, HealthCheckStatusOk, checks["database"].Status)
+		assert.Equal(t, HealthCheckStatusWarning, checks["timeseries"].Status)
+		assert.Equal(t, HealthCheckStatusOk, checks["multistageEngine"].Status)
+		assert.Equal(t, HealthCheckStatusOk, checks["sampleQuery"].Status)
+
+		var diagnostics HealthDiagnostics
+		require.NoError(t, json.Unmarshal(result.JSONDetails, &diagnostics))
+		assert.NotNil(t, diagnostics.SampleQueryLatencyMs)
+		assert.Equal(t, "1.3.0", diagnostics.ControllerVersion)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		client := newClient(t, nil, http.StatusUnauthorized)
+
+		result, checks := checkHealth(t, client)
+		assert.Equal(t, backend.HealthStatusError, result.Status)
+		assert.Equal(t, HealthCheckStatusOk, checks["controller"].Status)
+		assert.Equal(t, HealthCheckStatusError, checks["auth"].Status)
+		assert.Contains(t, checks["auth"].Message, "401 Unauthorized")
+		assert.Equal(t, HealthCheckStatusSkipped, checks["database"].Status)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		client := newClient(t, nil, http.StatusForbidden)
+
+		_, checks := checkHealth(t, client)
+		assert.Equal(t, HealthCheckStatusError, checks["auth"].Status)
+		assert.Contains(t, checks["auth"].Message, "403 Forbidden")
+	})
+
+	t.Run("controller down after metadata was cached", func(t *testing.T) {
+		var down atomic.Bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case down.Load():
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case r.URL.Path == "/version":
+				_, _ = w.Write([]byte(`{"pinot-controller":"1.3.0"}`))
+			case r.URL.Path == "/tables":
+				_, _ = w.Write([]byte(`{"tables":["tbl"]}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(server.Close)
+		client := pinot.NewPinotClient(http.DefaultClient, pinot.ClientProperties{
+			ControllerUrl:    server.URL,
+			BrokerUrl:        server.URL,
+			MetadataCacheTTL: pinot.DefaultMetadataCacheTTL,
+		})
+		t.Cleanup(client.Close)
+
+		_, err := client.ListTables(context.Background())
+		require.NoError(t, err)
+		_, err = client.Capabilities(context.Background())
+		require.NoError(t, err)
+		down.Store(true)
+
+		result, checks := checkHealth(t, client)
+		assert.Equal(t, backend.HealthStatusError, result.Status)
+		assert.Equal(t, HealthCheckStatusError, checks["controller"].Status)
+		assert.NotContains(t, checks["controller"].Message, "1.3.0")
+		assert.Equal(t, HealthCheckStatusWarning, checks["timeseries"].Status)
+		assert.Contains(t, checks["timeseries"].Message, "availability is unknown")
+	})
+
+	t.Run("no tables", func(t *testing.T) {
+		client := newClient(t, map[string]string{
+			"/tables":    `{"tables":[]}`,
+			"/query/sql": sampleQueryResponse,
+		}, http.StatusOK)
+
+		result, checks := checkHealth(t, client)
+		assert.Equal(t, backend.HealthStatusError, result.Status)
+		assert.Equal(t, HealthCheckStatusError, checks["database"].Status)
+		assert.Contains(t, result.Message, "Got an empty list of tables")
+	})
+}
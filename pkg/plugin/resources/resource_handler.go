@@ -40,9 +40,13 @@ func NewResourceHandler(client *pinot.Client) http.Handler {
 	router := mux.NewRouter()
 	router.HandleFunc("/databases", adaptHandler(client, ListDatabases))
 	router.HandleFunc("/isPromQlSupported", adaptHandler(client, IsPromQlSupported))
+	router.HandleFunc("/capabilities", adaptHandler(client, GetCapabilities))
+	router.HandleFunc("/queryOptions", adaptHandler(client, ListQueryOptions))
 	router.HandleFunc("/preview/sql/builder", adaptHandlerWithBody(client, PreviewSqlBuilder))
 	router.HandleFunc("/preview/logs/sql", adaptHandlerWithBody(client, PreviewLogsSql))
 	router.HandleFunc("/preview/sql/code", adaptHandlerWithBody(client, PreviewSqlCode))
+	router.HandleFunc("/explain/sql/builder", adaptHandlerWithBody(client, ExplainSqlBuilder))
+	router.HandleFunc("/explain/sql/code", adaptHandlerWithBody(client, ExplainSqlCode))
 	router.HandleFunc("/preview/sql/distinctValues", adaptHandlerWithBody(client, PreviewSqlDistinctValues))
 	router.HandleFunc("/query/distinctValues", adaptHandlerWithBody(client, QueryDistinctValues))
 	router.HandleFunc("/tables", adaptHandler(client, ListTables))
@@ -53,6 +57,7 @@ func NewResourceHandler(client *pinot.Client) http.Handler {
 	router.HandleFunc("/timeseries/labelValues", adaptHandlerWithBody(client, ListTimeSeriesLabelValues))
 	router.HandleFunc("/granularities", adaptHandlerWithBody(client, ListSuggestedGranularities))
 	router.HandleFunc("/columns", adaptHandlerWithBody(client, ListColumns))
+	router.HandleFunc("/cache/invalidate", adaptHandlerWithBody(client, InvalidateCache))
 	return router
 }
 
@@ -92,7 +97,7 @@ func GetTableSchema(client *pinot.Client, r *http.Request) *Response[pinot.Table
 
 	schema, err := client.GetTableSchema(r.Context(), table)
 	if err != nil {
-		return newInternalServerErrorResponse[pinot.TableSchema](err)
+		return newQueryErrorResponse[pinot.TableSchema](err)
 	}
 	return newOkResponse(schema)
 }
@@ -110,6 +115,7 @@ type PreviewSqlBuilderRequest struct {
 	Granularity         string                      `json:"granularity"`
 	OrderByClauses      []dataquery.OrderByClause   `json:"orderBy"`
 	QueryOptions        []dataquery.QueryOption     `json:"queryOptions"`
+	UseMultistageEngine bool                        `json:"useMultistageEngine"`
 	ExpandMacros        bool                        `json:"expandMacros"`
 }
 
@@ -118,21 +124,7 @@ func PreviewSqlBuilder(client *pinot.Client, ctx context.Context, data PreviewSq
 		return newOkResponse("")
 	}
 
-	query := dataquery.TimeSeriesBuilderQuery{
-		TimeRange:           data.TimeRange,
-		IntervalSize:        parseIntervalSize(data.IntervalSize),
-		TableName:           data.TableName,
-		TimeColumn:          data.TimeColumn,
-		MetricColumn:        data.MetricColumn,
-		GroupByColumns:      data.GroupByColumns,
-		AggregationFunction: data.AggregationFunction,
-		DimensionFilters:    data.DimensionFilters,
-		Limit:               data.Limit,
-		Granularity:         data.Granularity,
-		OrderByClauses:      data.OrderByClauses,
-		QueryOptions:        data.QueryOptions,
-	}
-
+	query := data.timeSeriesBuilderQuery()
 	if !data.ExpandMacros {
 		sql, err := query.RenderSqlWithMacros()
 		if err != nil {
@@ -150,19 +142,53 @@ func PreviewSqlBuilder(client *pinot.Client, ctx context.Context, data PreviewSq
 	return newOkResponse(client.RenderSql(sqlQuery))
 }
 
+// ExplainSqlBuilder returns the explain plan of the builder query, rendered as it would be executed.
+func ExplainSqlBuilder(client *pinot.Client, ctx context.Context, data PreviewSqlBuilderRequest) *Response[*pinot.ExplainPlan] {
+	query := data.timeSeriesBuilderQuery()
+	if err := query.Validate(); err != nil {
+		return newBadRequestResponse[*pinot.ExplainPlan](err)
+	}
+
+	sqlQuery, _, err := query.RenderSqlQuery(ctx, client)
+	if err != nil {
+		return newQueryErrorResponse[*pinot.ExplainPlan](err)
+	}
+	return explainSqlQuery(client, ctx, sqlQuery)
+}
+
+func (data PreviewSqlBuilderRequest) timeSeriesBuilderQuery() dataquery.TimeSeriesBuilderQuery {
+	return dataquery.TimeSeriesBuilderQuery{
+		TimeRange:           data.TimeRange,
+		IntervalSize:        parseIntervalSize(data.IntervalSize),
+		TableName:           data.TableName,
+		TimeColumn:          data.TimeColumn,
+		MetricColumn:        data.MetricColumn,
+		GroupByColumns:      data.GroupByColumns,
+		AggregationFunction: data.AggregationFunction,
+		DimensionFilters:    data.DimensionFilters,
+		Limit:               data.Limit,
+		Granularity:         data.Granularity,
+		OrderByClauses:      data.OrderByClauses,
+		QueryOptions:        data.QueryOptions,
+		UseMultistageEngine: data.UseMultistageEngine,
+	}
+}
+
 type PreviewLogsBuilderSqlRequest struct {
-	TimeRange        dataquery.TimeRange         `json:"timeRange"`
-	TableName        string                      `json:"tableName"`
-	TimeColumn       string                      `json:"timeColumn"`
-	LogColumn        dataquery.ComplexField      `json:"logColumn"`
-	LogColumnAlias   string                      `json:"logColumnAlias"`
-	MetadataColumns  []dataquery.ComplexField    `json:"metadataColumns"`
-	JsonExtractors   []dataquery.JsonExtractor   `json:"jsonExtractors"`
-	RegexpExtractors []dataquery.RegexpExtractor `json:"regexpExtractors"`
-	DimensionFilters []dataquery.DimensionFilter `json:"filters"`
-	QueryOptions     []dataquery.QueryOption     `json:"queryOptions"`
-	Limit            int64                       `json:"limit"`
-	ExpandMacros     bool                        `json:"expandMacros"`
+	TimeRange           dataquery.TimeRange         `json:"timeRange"`
+	IntervalSize        string                      `json:"intervalSize"`
+	TableName           string                      `json:"tableName"`
+	TimeColumn          string                      `json:"timeColumn"`
+	LogColumn           dataquery.ComplexField      `json:"logColumn"`
+	LogColumnAlias      string                      `json:"logColumnAlias"`
+	MetadataColumns     []dataquery.ComplexField    `json:"metadataColumns"`
+	JsonExtractors      []dataquery.JsonExtractor   `json:"jsonExtractors"`
+	RegexpExtractors    []dataquery.RegexpExtractor `json:"regexpExtractors"`
+	DimensionFilters    []dataquery.DimensionFilter `json:"filters"`
+	QueryOptions        []dataquery.QueryOption     `json:"queryOptions"`
+	Limit               int64                       `json:"limit"`
+	UseMultistageEngine bool                        `json:"useMultistageEngine"`
+	ExpandMacros        bool                        `json:"expandMacros"`
 }
 
 func PreviewLogsSql(client *pinot.Client, ctx context.Context, data PreviewLogsBuilderSqlRequest) *Response[string] {
@@ -171,17 +197,19 @@ func PreviewLogsSql(client *pinot.Client, ctx context.Context, data PreviewLogsB
 	}
 
 	query := dataquery.LogsBuilderQuery{
-		TimeRange:        data.TimeRange,
-		TableName:        data.TableName,
-		TimeColumn:       data.TimeColumn,
-		LogColumn:        data.LogColumn,
-		LogColumnAlias:   data.LogColumnAlias,
-		MetadataColumns:  data.MetadataColumns,
-		JsonExtractors:   data.JsonExtractors,
-		RegexpExtractors: data.RegexpExtractors,
-		DimensionFilters: data.DimensionFilters,
-		QueryOptions:     data.QueryOptions,
-		Limit:            data.Limit,
+		TimeRange:           data.TimeRange,
+		IntervalSize:        parseIntervalSize(data.IntervalSize),
+		TableName:           data.TableName,
+		TimeColumn:          data.TimeColumn,
+		LogColumn:           data.LogColumn,
+		LogColumnAlias:      data.LogColumnAlias,
+		MetadataColumns:     data.MetadataColumns,
+		JsonExtractors:      data.JsonExtractors,
+		RegexpExtractors:    data.RegexpExtractors,
+		DimensionFilters:    data.DimensionFilters,
+		QueryOptions:        data.QueryOptions,
+		Limit:               data.Limit,
+		UseMultistageEngine: data.UseMultistageEngine,
 	}
 
 	if !data.ExpandMacros {
@@ -202,13 +230,14 @@ func PreviewLogsSql(client *pinot.Client, ctx context.Context, data PreviewLogsB
 }
 
 type PreviewSqlCodeRequest struct {
-	TimeRange         dataquery.TimeRange `json:"timeRange"`
-	IntervalSize      string              `json:"intervalSize"`
-	TableName         string              `json:"tableName"`
-	TimeColumnAlias   string              `json:"timeColumnAlias"`
-	TimeColumnFormat  string              `json:"timeColumnFormat"`
-	MetricColumnAlias string              `json:"metricColumnAlias"`
-	Code              string              `json:"code"`
+	TimeRange           dataquery.TimeRange `json:"timeRange"`
+	IntervalSize        string              `json:"intervalSize"`
+	TableName           string              `json:"tableName"`
+	TimeColumnAlias     string              `json:"timeColumnAlias"`
+	TimeColumnFormat    string              `json:"timeColumnFormat"`
+	MetricColumnAlias   string              `json:"metricColumnAlias"`
+	Code                string              `json:"code"`
+	UseMultistageEngine bool                `json:"useMultistageEngine"`
 }
 
 func PreviewSqlCode(client *pinot.Client, ctx context.Context, data PreviewSqlCodeRequest) *Response[string] {
@@ -217,16 +246,7 @@ func PreviewSqlCode(client *pinot.Client, ctx context.Context, data PreviewSqlCo
 		return newOkResponse("")
 	}
 
-	query := dataquery.PinotQlCodeQuery{
-		TableName:         data.TableName,
-		TimeRange:         data.TimeRange,
-		IntervalSize:      parseIntervalSize(data.IntervalSize),
-		TimeColumnAlias:   data.TimeColumnAlias,
-		MetricColumnAlias: data.MetricColumnAlias,
-		Code:              data.Code,
-	}
-
-	sql, err := query.RenderSqlQuery(ctx, client)
+	sql, err := data.pinotQlCodeQuery().RenderSqlQuery(ctx, client)
 	if err != nil {
 		log.WithError(err).FromContext(ctx).Error("RenderPinotSql() failed.")
 		return newOkResponse("")
@@ -234,6 +254,44 @@ func PreviewSqlCode(client *pinot.Client, ctx context.Context, data PreviewSqlCo
 	return newOkResponse(client.RenderSql(sql))
 }
 
+// ExplainSqlCode returns the explain plan of the code query, rendered as it would be executed.
+func ExplainSqlCode(client *pinot.Client, ctx context.Context, data PreviewSqlCodeRequest) *Response[*pinot.ExplainPlan] {
+	if data.TableName == "" {
+		return newBadRequestResponse[*pinot.ExplainPlan](errors.New("tableName is required"))
+	} else if data.Code == "" {
+		return newBadRequestResponse[*pinot.ExplainPlan](errors.New("code is required"))
+	}
+
+	sqlQuery, err := data.pinotQlCodeQuery().RenderSqlQuery(ctx, client)
+	if err != nil {
+		return newQueryErrorResponse[*pinot.ExplainPlan](err)
+	}
+	return explainSqlQuery(client, ctx, sqlQuery)
+}
+
+func (data PreviewSqlCodeRequest) pinotQlCodeQuery() dataquery.PinotQlCodeQuery {
+	return dataquery.PinotQlCodeQuery{
+		TableName:           data.TableName,
+		TimeRange:           data.TimeRange,
+		IntervalSize:        parseIntervalSize(data.IntervalSize),
+		TimeColumnAlias:     data.TimeColumnAlias,
+		MetricColumnAlias:   data.MetricColumnAlias,
+		Code:                data.Code,
+		UseMultistageEngine: data.UseMultistageEngine,
+	}
+}
+
+func explainSqlQuery(client *pinot.Client, ctx context.Context, sqlQuery pinot.SqlQuery) *Response[*pinot.ExplainPlan] {
+	plan, err := client.ExplainPlan(ctx, sqlQuery)
+	var brokerErr *pinot.BrokerExceptionError
+	if errors.As(err, &brokerErr) {
+		return newBadRequestResponse[*pinot.ExplainPlan](err)
+	} else if err != nil {
+		return newQueryErrorResponse[*pinot.ExplainPlan](err)
+	}
+	return newOkResponse(plan)
+}
+
 type QueryDistinctValuesRequest struct {
 	TableName        string                      `json:"tableName"`
 	ColumnName       string                      `json:"columnName"`
@@ -246,15 +304,17 @@ type QueryDistinctValuesRequest struct {
 func QueryDistinctValues(client *pinot.Client, ctx context.Context, data QueryDistinctValuesRequest) *Response[[]string] {
 	sql, err := getDistinctValuesSql(client, ctx, data)
 	if err != nil {
-		return newInternalServerErrorResponse[[]string](err)
+		return newQueryErrorResponse[[]string](err)
 	}
 	if sql == "" {
 		return newOkResponse[[]string](nil)
 	}
 
-	results, err := client.ExecuteSqlQuery(ctx, pinot.NewSqlQuery(sql))
+	sqlQuery := pinot.NewSqlQuery(sql)
+	sqlQuery.TableName = data.TableName
+	results, err := client.ExecuteSqlQuery(ctx, sqlQuery)
 	if err != nil {
-		return newInternalServerErrorResponse[[]string](err)
+		return newQueryErrorResponse[[]string](err)
 	}
 
 	var valueExprs []string
@@ -283,6 +343,15 @@ func getDistinctValuesSql(client *pinot.Client, ctx context.Context, data QueryD
 		return "", nil
 	}
 
+	filterExprs, err := dataquery.FilterExprsFrom(data.DimensionFilters)
+	if err != nil {
+		return "", err
+	}
+	filterExprs, err = dataquery.AppendRowFilter(ctx, data.TableName, filterExprs)
+	if err != nil {
+		return "", err
+	}
+
 	var timeFilterExpr pinot.SqlExpr
 	if data.TimeRange != nil {
 		tableSchema, err := client.GetTableSchema(ctx, data.TableName)
@@ -307,7 +376,7 @@ func getDistinctValuesSql(client *pinot.Client, ctx context.Context, data QueryD
 		ColumnExpr:           pinot.ComplexFieldExpr(data.ColumnName, data.ColumnKey),
 		TableName:            data.TableName,
 		TimeFilterExpr:       timeFilterExpr,
-		DimensionFilterExprs: dataquery.FilterExprsFrom(data.DimensionFilters),
+		DimensionFilterExprs: filterExprs,
 	})
 }
 
@@ -328,9 +397,11 @@ func ListTimeSeriesMetrics(client *pinot.Client, ctx context.Context, data ListT
 	if data.TableName == "" {
 		return newBadRequestResponse[[]string](errors.New("tableName is required"))
 	} else if ok, err := client.IsTimeSeriesTable(ctx, data.TableName); err != nil {
-		return newInternalServerErrorResponse[[]string](err)
+		return newQueryErrorResponse[[]string](err)
 	} else if !ok {
 		return newBadRequestResponse[[]string](fmt.Errorf("table `%s` is not a time series table", data.TableName))
+	} else if err = dataquery.RejectUnfilterable(ctx, data.TableName); err != nil {
+		return newQueryErrorResponse[[]string](err)
 	}
 
 	metrics, err := client.ListTimeSeriesMetrics(ctx, pinot.TimeSeriesMetricNamesQuery{
@@ -339,7 +410,7 @@ func ListTimeSeriesMetrics(client *pinot.Client, ctx context.Context, data ListT
 		To:        data.TimeRange.To,
 	})
 	if err != nil {
-		return newInternalServerErrorResponse[[]string](err)
+		return newQueryErrorResponse[[]string](err)
 	}
 	return newOkResponse(metrics)
 }
@@ -354,9 +425,11 @@ func ListTimeSeriesLabels(client *pinot.Client, ctx context.Context, data ListTi
 	if data.TableName == "" {
 		return newBadRequestResponse[[]string](errors.New("tableName is required"))
 	} else if ok, err := client.IsTimeSeriesTable(ctx, data.TableName); err != nil {
-		return newInternalServerErrorResponse[[]string](err)
+		return newQueryErrorResponse[[]string](err)
 	} else if !ok {
 		return newBadRequestResponse[[]string](fmt.Errorf("table `%s` is not a time series table", data.TableName))
+	} else if err = dataquery.RejectUnfilterable(ctx, data.TableName); err != nil {
+		return newQueryErrorResponse[[]string](err)
 	}
 
 	labels, err := client.ListTimeSeriesLabelNames(ctx, pinot.TimeSeriesLabelNamesQuery{
@@ -366,7 +439,7 @@ func ListTimeSeriesLabels(client *pinot.Client, ctx context.Context, data ListTi
 		To:         data.TimeRange.To,
 	})
 	if err != nil {
-		return newInternalServerErrorResponse[[]string](err)
+		return newQueryErrorResponse[[]string](err)
 	}
 	return newOkResponse(labels)
 }
@@ -384,9 +457,11 @@ func ListTimeSeriesLabelValues(client *pinot.Client, ctx context.Context, data L
 	} else if data.LabelName == "" {
 		return newBadRequestResponse[[]string](errors.New("labelName is required"))
 	} else if ok, err := client.IsTimeSeriesTable(ctx, data.TableName); err != nil {
-		return newInternalServerErrorResponse[[]string](err)
+		return newQueryErrorResponse[[]string](err)
 	} else if !ok {
 		return newBadRequestResponse[[]string](fmt.Errorf("table `%s` is not a time series table", data.TableName))
+	} else if err = dataquery.RejectUnfilterable(ctx, data.TableName); err != nil {
+		return newQueryErrorResponse[[]string](err)
 	}
 
 	values, err := client.ListTimeSeriesLabelValues(ctx, pinot.TimeSeriesLabelValuesQuery{
@@ -397,7 +472,7 @@ func ListTimeSeriesLabelValues(client *pinot.Client, ctx context.Context, data L
 		To:         data.TimeRange.To,
 	})
 	if err != nil {
-		return newInternalServerErrorResponse[[]string](err)
+		return newQueryErrorResponse[[]string](err)
 	}
 	return newOkResponse(values)
 }
@@ -410,6 +485,19 @@ func IsPromQlSupported(client *pinot.Client, r *http.Request) *Response[bool] {
 	return newOkResponse(ok)
 }
 
+func GetCapabilities(client *pinot.Client, r *http.Request) *Response[pinot.Capabilities] {
+	capabilities, err := client.Capabilities(r.Context())
+	if err != nil {
+		return newInternalServerErrorResponse[pinot.Capabilities](err)
+	}
+	return newOkResponse(capabilities)
+}
+
+// ListQueryOptions returns the catalog of known query options, for the query editor.
+func ListQueryOptions(*pinot.Client, *http.Request) *Response[[]pinot.QueryOptionSpec] {
+	return newOkResponse(pinot.QueryOptionCatalog)
+}
+
 type ListSuggestedGranularitiesRequest = struct {
 	TableName  string `json:"tableName"`
 	TimeColumn string `json:"timeColumn"`
@@ -437,7 +525,7 @@ func ListSuggestedGranularities(client *pinot.Client, ctx context.Context, req L
 
 	schema, err := client.GetTableSchema(ctx, req.TableName)
 	if err != nil {
-		return newInternalServerErrorResponse[[]Granularity](err)
+		return newQueryErrorResponse[[]Granularity](err)
 	}
 
 	timeColumnFormat, err := pinot.GetTimeColumnFormat(schema, req.TimeColumn)
@@ -448,7 +536,7 @@ func ListSuggestedGranularities(client *pinot.Client, ctx context.Context, req L
 
 	configs, err := client.ListTableConfigs(ctx, req.TableName)
 	if err != nil {
-		return newInternalServerErrorResponse[[]Granularity](err)
+		return newQueryErrorResponse[[]Granularity](err)
 	}
 
 	distinctSuggestions := make(map[float64]Granularity)
@@ -507,12 +595,12 @@ func ListColumns(client *pinot.Client, ctx context.Context, req ListColumnsReque
 
 	schema, err := client.GetTableSchema(ctx, req.TableName)
 	if err != nil {
-		return newInternalServerErrorResponse[[]Column](err)
+		return newQueryErrorResponse[[]Column](err)
 	}
 
 	tableConfigs, err := client.ListTableConfigs(ctx, req.TableName)
 	if err != nil {
-		return newInternalServerErrorResponse[[]Column](err)
+		return newQueryErrorResponse[[]Column](err)
 	}
 
 	isDerivedTimeCol := make(map[string]bool)
@@ -560,7 +648,14 @@ func ListColumns(client *pinot.Client, ctx context.Context, req ListColumnsReque
 		From:   req.TimeRange.From,
 		To:     req.TimeRange.To,
 	})
-	filterExprs := dataquery.FilterExprsFrom(req.DimensionFilters)
+	filterExprs, err := dataquery.FilterExprsFrom(req.DimensionFilters)
+	if err != nil {
+		return newOkResponse(columns)
+	}
+	filterExprs, err = dataquery.AppendRowFilter(ctx, req.TableName, filterExprs)
+	if err != nil {
+		return newOkResponse(columns)
+	}
 	for _, spec := range schema.ComplexFieldSpecs {
 		keys := listMapColumnKeys(client, ctx, req.TableName, spec.Name, timeFilterExpr, filterExprs)
 		for _, key := range keys {
@@ -585,7 +680,9 @@ func listMapColumnKeys(client *pinot.Client, ctx context.Context, tableName stri
 		DimensionFilterExprs: filterExprs,
 	})
 
-	results, err := client.ExecuteSqlQuery(ctx, pinot.NewSqlQuery(sql))
+	sqlQuery := pinot.NewSqlQuery(sql)
+	sqlQuery.TableName = tableName
+	results, err := client.ExecuteSqlQuery(ctx, sqlQuery)
 	if err != nil {
 		log.WithError(err).FromContext(ctx).Error("Query to extract map keys failed")
 		return nil
@@ -614,6 +711,16 @@ func listMapColumnKeys(client *pinot.Client, ctx context.Context, tableName stri
 	return keys
 }
 
+type InvalidateCacheRequest struct {
+	TableName string `json:"tableName"`
+}
+
+// InvalidateCache removes the cached metadata of the table, or all cached metadata if no table is given.
+func InvalidateCache(client *pinot.Client, ctx context.Context, req InvalidateCacheRequest) *Response[any] {
+	client.InvalidateMetadataCache(req.TableName)
+	return newOkResponse[any](nil)
+}
+
 func newOkResponse[T any](result T) *Response[T] {
 	return &Response[T]{Code: http.StatusOK, Result: result}
 }
@@ -626,6 +733,19 @@ func newInternalServerErrorResponse[T any](err error) *Response[T] {
 	return newErrorResponse[T](http.StatusInternalServerError, err)
 }
 
+// newQueryErrorResponse returns a forbidden response for queries rejected by row-level security,
+// and a bad request response for queries rejected by the query policy.
+func newQueryErrorResponse[T any](err error) *Response[T] {
+	if errors.Is(err, dataquery.ErrRowLevelSecurity) {
+		return newErrorResponse[T](http.StatusForbidden, err)
+	} else if errors.Is(err, pinot.ErrQueryPolicy) || errors.Is(err, pinot.ErrInvalidQueryOption) {
+		return newBadRequestResponse[T](err)
+	} else if errors.Is(err, pinot.BrokerErrorKindQueryTimeout) {
+		return newErrorResponse[T](http.StatusGatewayTimeout, err)
+	}
+	return newInternalServerErrorResponse[T](err)
+}
+
 func newErrorResponse[T any](code int, err error) *Response[T] {
 	return &Response[T]{Code: code, Error: err.Error()}
 }
@@ -634,7 +754,7 @@ func adaptHandler[T any](client *pinot.Client, handler func(*pinot.Client, *http
 	return func(w http.ResponseWriter, req *http.Request) {
 		startTime := time.Now()
 		// OAuth pass-through is now handled automatically by the SDK HTTP client
-		resp := handler(client, req)
+		resp := handler(clientForRequest(client, req), req)
 		writeResponse(w, resp)
 		captureMetrics(startTime, req, resp)
 	}
@@ -650,7 +770,7 @@ func adaptHandlerWithBody[TIn any, TOut any](client *pinot.Client, handler func(
 		if err := json.NewDecoder(req.Body).Decode(&data); err != nil {
 			resp = newBadRequestResponse[TOut](err)
 		} else {
-			resp = handler(client, req.Context(), data)
+			resp = handler(clientForRequest(client, req), req.Context(), data)
 		}
 
 		writeResponse(w, resp)
@@ -658,6 +778,12 @@ func adaptHandlerWithBody[TIn any, TOut any](client *pinot.Client, handler func(
 	}
 }
 
+// clientForRequest returns the client of the database named by the request's database parameter,
+// so that the editor of a query that overrides the database lists the tables and columns of that database.
+func clientForRequest(client *pinot.Client, req *http.Request) *pinot.Client {
+	return client.WithDatabase(req.URL.Query().Get("database"))
+}
+
 func captureMetrics[TOut any](startTime time.Time, req *http.Request, resp *Response[TOut]) {
 	labels := prometheus.Labels{
 		"endpoint": req.URL.Path,
@@ -3,6 +3,7 @@ package resources
 import (
 	"bytes"
 	"encoding/json"
+	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/pinot"
 	"github.com/startreedata/startree-grafana-pinot-datasource/pkg/plugin/test_helpers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -113,6 +114,57 @@ LIMIT 1000000`
 	assert.Equal(t, want, got["result"])
 }
 
+func TestExplainSqlBuilder(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	var got struct {
+		Code   int                `json:"code"`
+		Result *pinot.ExplainPlan `json:"result"`
+	}
+	doPostRequest(t, server.URL+"/explain/sql/builder", `{
+  "aggregationFunction": "SUM",
+  "intervalSize": "30m",
+  "metricColumn": {"name":"value"},
+  "tableName": "benchmark",
+  "timeColumn": "ts",
+  "timeRange": {
+    "to": "2014-02-01T18:44:26.214Z",
+    "from": "2013-12-29T14:50:28.931Z"
+  }
+}`, &got)
+
+	assert.Equal(t, http.StatusOK, got.Code)
+	require.NotNil(t, got.Result)
+	assert.NotEmpty(t, got.Result.Nodes)
+}
+
+func TestExplainSqlCode(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	var data bytes.Buffer
+	require.NoError(t, json.NewEncoder(&data).Encode(map[string]interface{}{
+		"intervalSize": "30m",
+		"tableName":    "benchmark",
+		"timeRange": map[string]interface{}{
+			"to":   "2014-02-01T18:44:26.214Z",
+			"from": "2013-12-29T14:50:28.931Z",
+		},
+		"code": `SELECT SUM("value") FROM $__table() WHERE $__timeFilter("ts")`,
+	}))
+
+	var got struct {
+		Code   int                `json:"code"`
+		Result *pinot.ExplainPlan `json:"result"`
+	}
+	doPostRequest(t, server.URL+"/explain/sql/code", data.String(), &got)
+
+	assert.Equal(t, http.StatusOK, got.Code)
+	require.NotNil(t, got.Result)
+	assert.NotEmpty(t, got.Result.Nodes)
+}
+
 func TestPreviewLogSql(t *testing.T) {
 	server := newTestServer(t)
 	defer server.Close()
@@ -140,7 +192,7 @@ func TestPreviewLogSql(t *testing.T) {
 		}},
 		"filters": []map[string]interface{}{{
 			"columnName": "dim1",
-			"valueExprs": []string{"val1"},
+			"valueExprs": []string{"'val1'"},
 			"operator":   "=",
 		}},
 		"queryOptions": []map[string]string{{
@@ -160,7 +212,7 @@ func TestPreviewLogSql(t *testing.T) {
 FROM "benchmark"
 WHERE "logColumn" IS NOT NULL
     AND "ts" >= 1388328628931 AND "ts" < 1391280266214
-    AND ("dim1" = val1)
+    AND ("dim1" = 'val1')
 ORDER BY
     "ts" ASC,
     "__message" ASC
@@ -187,7 +239,7 @@ SET myOption=myOptionValue;`
 FROM $__table()
 WHERE "logColumn" IS NOT NULL
     AND $__timeFilter("ts")
-    AND ("dim1" = val1)
+    AND ("dim1" = 'val1')
 ORDER BY
     "ts" ASC,
     "__message" ASC
@@ -364,6 +416,20 @@ func TestListColumns(t *testing.T) {
 	}
 }
 
+func TestListQueryOptions(t *testing.T) {
+	server := httptest.NewServer(NewResourceHandler(pinot.NewPinotClient(http.DefaultClient, pinot.ClientProperties{})))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/queryOptions")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, resp.Body.Close()) }()
+
+	var got Response[[]pinot.QueryOptionSpec]
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, http.StatusOK, got.Code)
+	assert.Equal(t, pinot.QueryOptionCatalog, got.Result)
+}
+
 func newTestServer(t *testing.T) *httptest.Server {
 	client := test_helpers.SetupPinotAndCreateClient(t)
 	return httptest.NewServer(NewResourceHandler(client))
@@ -2,7 +2,8 @@ import React, { useState } from 'react';
 import { DataSourcePluginOptionsEditorProps } from '@grafana/data';
 import { PinotConnectionConfig, PinotSecureConfig } from '../../config/PinotConnectionConfig';
 import { DataSourceDescription } from '@grafana/experimental';
-import { InputPinotToken } from './InputPinotToken';
+import { InputPinotToken, TokenTypeOAuth2ClientCredentials } from './InputPinotToken';
+import { InputOAuth2ClientCredentials } from './InputOAuth2ClientCredentials';
 import { InputUrl } from './InputUrl';
 import allLabels from 'labels';
 import { InlineField, InlineSwitch, useTheme2 } from '@grafana/ui';
@@ -147,6 +148,34 @@ export function ConfigEditor(props: ConfigEditorProps) {
             })
           }
         />
+        {jsonData.tokenType === TokenTypeOAuth2ClientCredentials && (
+          <InputOAuth2ClientCredentials
+            tokenUrl={jsonData.oauth2TokenUrl}
+            clientId={jsonData.oauth2ClientId}
+            scopes={jsonData.oauth2Scopes}
+            isClientSecretConfigured={!!secureJsonFields?.oauth2ClientSecret}
+            clientSecret={secureJsonData.oauth2ClientSecret}
+            onChangeTokenUrl={(oauth2TokenUrl) => onConfigChange({ ...jsonData, oauth2TokenUrl })}
+            onChangeClientId={(oauth2ClientId) => onConfigChange({ ...jsonData, oauth2ClientId })}
+            onChangeScopes={(oauth2Scopes) => onConfigChange({ ...jsonData, oauth2Scopes })}
+            onChangeClientSecret={(oauth2ClientSecret) =>
+              onSecureConfigChange({ ...secureJsonData, oauth2ClientSecret })
+            }
+            onResetClientSecret={() =>
+              onOptionsChange({
+                ...options,
+                secureJsonFields: {
+                  ...secureJsonFields,
+                  oauth2ClientSecret: false,
+                },
+                secureJsonData: {
+                  ...secureJsonData,
+                  oauth2ClientSecret: undefined,
+                },
+              })
+            }
+          />
+        )}
       </div>
     </>
   );
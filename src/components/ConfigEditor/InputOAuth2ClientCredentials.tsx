@@ -0,0 +1,69 @@
+import React, { ChangeEvent } from 'react';
+import { InlineField, Input, SecretInput, TagsInput } from '@grafana/ui';
+import allLabels from '../../labels';
+import { InputUrl } from './InputUrl';
+
+export function InputOAuth2ClientCredentials(props: {
+  tokenUrl: string | undefined;
+  clientId: string | undefined;
+  scopes: string[] | undefined;
+  isClientSecretConfigured: boolean;
+  clientSecret: string | undefined;
+  onChangeTokenUrl: (val: string | undefined) => void;
+  onChangeClientId: (val: string | undefined) => void;
+  onChangeScopes: (val: string[] | undefined) => void;
+  onChangeClientSecret: (val: string | undefined) => void;
+  onResetClientSecret: () => void;
+}) {
+  const labels = allLabels.components.ConfigEditor.oauth2;
+
+  return (
+    <div data-testid="input-oauth2-client-credentials">
+      <InputUrl
+        label={labels.tokenUrl.label}
+        tooltip={labels.tokenUrl.tooltip}
+        placeholder={labels.tokenUrl.placeholder}
+        value={props.tokenUrl}
+        onChange={(tokenUrl) => props.onChangeTokenUrl(tokenUrl || undefined)}
+      />
+      <InlineField label={labels.clientId.label} labelWidth={24} grow required data-testid="input-oauth2-client-id">
+        <Input
+          width={40}
+          value={props.clientId}
+          placeholder={labels.clientId.placeholder}
+          onChange={(event) => props.onChangeClientId(event.currentTarget.value || undefined)}
+        />
+      </InlineField>
+      <InlineField
+        label={labels.clientSecret.label}
+        labelWidth={24}
+        grow
+        required
+        data-testid="input-oauth2-client-secret"
+      >
+        <SecretInput
+          isConfigured={props.isClientSecretConfigured}
+          value={props.clientSecret}
+          placeholder={labels.clientSecret.placeholder}
+          width={40}
+          onReset={props.onResetClientSecret}
+          onChange={(event: ChangeEvent<HTMLInputElement>) => props.onChangeClientSecret(event.target.value)}
+        />
+      </InlineField>
+      <InlineField
+        label={labels.scopes.label}
+        labelWidth={24}
+        tooltip={labels.scopes.tooltip}
+        grow
+        data-testid="input-oauth2-scopes"
+      >
+        <TagsInput
+          width={40}
+          tags={props.scopes || []}
+          placeholder={labels.scopes.placeholder}
+          onChange={(scopes) => props.onChangeScopes(scopes.length > 0 ? scopes : undefined)}
+        />
+      </InlineField>
+    </div>
+  );
+}
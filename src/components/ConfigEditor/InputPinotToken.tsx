@@ -3,9 +3,11 @@ import React, { ChangeEvent } from 'react';
 import allLabels from '../../labels';
 
 const DefaultTokenType = 'Basic';
+export const TokenTypeOAuth2ClientCredentials = 'OAuth2ClientCredentials';
 const TokenTypeOptions = [
   { label: 'Basic', value: 'Basic' },
   { label: 'Bearer', value: 'Bearer' },
+  { label: 'OAuth2 Client Credentials', value: TokenTypeOAuth2ClientCredentials },
   { label: 'None', value: 'None' },
 ];
 
@@ -31,26 +33,28 @@ export function InputPinotToken(props: {
           options={TokenTypeOptions}
           isSearchable={false}
           value={tokenType || DefaultTokenType}
-          width={12}
+          width={30}
           onChange={(change) => onChangeType(change.value)}
         />
       </InlineField>
-      <InlineField
-        label={labels.valueLabel}
-        labelWidth={8}
-        required
-        data-testid="input-pinot-token-value"
-        disabled={tokenType === 'None'}
-      >
-        <SecretInput
-          isConfigured={isConfigured}
-          value={tokenValue}
-          placeholder={labels.valuePlaceholder}
-          width={40}
-          onReset={onResetToken}
-          onChange={(event: ChangeEvent<HTMLInputElement>) => onChangeToken(event.target.value)}
-        />
-      </InlineField>
+      {tokenType !== TokenTypeOAuth2ClientCredentials && (
+        <InlineField
+          label={labels.valueLabel}
+          labelWidth={8}
+          required
+          data-testid="input-pinot-token-value"
+          disabled={tokenType === 'None'}
+        >
+          <SecretInput
+            isConfigured={isConfigured}
+            value={tokenValue}
+            placeholder={labels.valuePlaceholder}
+            width={40}
+            onReset={onResetToken}
+            onChange={(event: ChangeEvent<HTMLInputElement>) => onChangeToken(event.target.value)}
+          />
+        </InlineField>
+      )}
     </div>
   );
 }
@@ -4,7 +4,7 @@ import { AccessoryButton, InputGroup } from '@grafana/experimental';
 import { MultiSelect, Select } from '@grafana/ui';
 import React, { useState } from 'react';
 import { PinotDataType, PinotDataTypes } from '../../dataquery/PinotDataType';
-import { DimensionFilter } from '../../dataquery/DimensionFilter';
+import { DimensionFilter, filterValueExprsOf, filterValueOf } from '../../dataquery/DimensionFilter';
 import { queryDistinctValuesForFilters } from '../../resources/distinctValues';
 import { Column } from '../../resources/columns';
 import { complexFieldOf, formDataOf } from '../../pinotql/complexField';
@@ -82,7 +82,10 @@ export function EditFilter(props: {
       .then(() => setIsLoadingValues(false));
   };
 
-  const valueOptions = [...(thisFilter.valueExprs || []), ...(distinctValues || [])]
+  const valueExprs = filterValueExprsOf(thisFilter);
+  const valuesOf = (exprs: string[]) => exprs.map((expr) => filterValueOf(expr, thisColumn?.dataType));
+
+  const valueOptions = [...valueExprs, ...(distinctValues || [])]
     .filter((v, i, a) => a.indexOf(v) === i)
     .map((val) => ({ label: val, value: val }));
 
@@ -129,7 +132,7 @@ export function EditFilter(props: {
             placeholder="Select value"
             width="auto"
             isLoading={isLoadingValues}
-            value={thisFilter.valueExprs?.map((v) => ({ label: v, value: v }))}
+            value={valueExprs.map((v) => ({ label: v, value: v }))}
             allowCustomValue
             options={valueOptions}
             onOpenMenu={() => loadValueOptions()}
@@ -137,7 +140,8 @@ export function EditFilter(props: {
               const selected = change.map((v) => v.value).filter((v) => v !== undefined) as string[];
               onChange({
                 ...thisFilter,
-                valueExprs: selected,
+                values: valuesOf(selected),
+                valueExprs: undefined,
                 operator: thisFilter.operator ?? DefaultFilterOperator.value,
               });
             }}
@@ -146,7 +150,7 @@ export function EditFilter(props: {
           <Select
             placeholder="Select value"
             width="auto"
-            value={thisFilter.valueExprs?.find((v, i) => i === 0)}
+            value={valueExprs.find((v, i) => i === 0)}
             onOpenMenu={() => loadValueOptions()}
             isLoading={isLoadingValues}
             allowCustomValue
@@ -155,7 +159,8 @@ export function EditFilter(props: {
               if (change.value) {
                 onChange({
                   ...thisFilter,
-                  valueExprs: [change.value],
+                  values: valuesOf([change.value]),
+                  valueExprs: undefined,
                   operator: thisFilter.operator ?? DefaultFilterOperator.value,
                 });
               }
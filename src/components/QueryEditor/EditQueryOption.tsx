@@ -1,16 +1,17 @@
 import React, { ChangeEvent, useState } from 'react';
 import { QueryOption } from '../../dataquery/QueryOption';
 import { AccessoryButton, InputGroup } from '@grafana/experimental';
-import { Input, Select } from '@grafana/ui';
+import { Icon, Input, Select, Tooltip } from '@grafana/ui';
 import { styles } from '../../styles';
 
 export function EditQueryOption(props: {
   queryOption: QueryOption;
   unused: Set<string>;
+  isUnknown?: boolean;
   onDelete: () => void;
   onChange: (val: QueryOption) => void;
 }) {
-  const { queryOption, unused, onChange, onDelete } = props;
+  const { queryOption, unused, isUnknown, onChange, onDelete } = props;
   const [value, setValue] = useState(queryOption.value);
 
   const selectableNames = queryOption.name ? [queryOption.name, ...unused] : [...unused];
@@ -39,6 +40,13 @@ export function EditQueryOption(props: {
           onBlur={() => queryOption.value !== value && onChange({ ...queryOption, value })}
         />
       </div>
+      {isUnknown && (
+        <Tooltip content="This query option is unknown to the datasource and may be ignored by Pinot.">
+          <div style={{ padding: 6 }} data-testid="unknown-query-option-warning">
+            <Icon name="exclamation-triangle" />
+          </div>
+        </Tooltip>
+      )}
       <AccessoryButton data-testid="delete-query-option-btn" icon="times" variant="secondary" onClick={onDelete} />
     </InputGroup>
   );
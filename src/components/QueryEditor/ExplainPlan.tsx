@@ -0,0 +1,59 @@
+import { Button } from '@grafana/ui';
+import React, { useState } from 'react';
+import { FormLabel } from './FormLabel';
+import allLabels from '../../labels';
+import { ExplainPlan as Plan, ExplainPlanNode } from '../../resources/explainPlan';
+
+export function ExplainPlan(props: { disabled: boolean; explain: () => Promise<Plan> }) {
+  const { disabled, explain } = props;
+  const labels = allLabels.components.QueryEditor.explainPlan;
+
+  const [plan, setPlan] = useState<Plan | undefined>();
+  const [error, setError] = useState<string | undefined>();
+  const [loading, setLoading] = useState(false);
+
+  const onExplain = () => {
+    setLoading(true);
+    explain()
+      .then((plan) => {
+        setPlan(plan);
+        setError(undefined);
+      })
+      .catch((err) => {
+        setPlan(undefined);
+        setError(err?.data?.error || err?.message || 'Failed to explain the query.');
+      })
+      .finally(() => setLoading(false));
+  };
+
+  return (
+    <div className="gf-form" data-testid="explain-plan-container">
+      <FormLabel tooltip={labels.tooltip} label={labels.label} />
+      <div>
+        <Button
+          size="sm"
+          variant="secondary"
+          icon={loading ? 'spinner' : 'sitemap'}
+          disabled={disabled}
+          onClick={onExplain}
+        >
+          Explain
+        </Button>
+        {error && <pre data-testid="explain-plan-error">{error}</pre>}
+        {plan && (
+          <pre data-testid="explain-plan">
+            {plan.indexTypes.length > 0 && `Indexes: ${plan.indexTypes.join(', ')}\n\n`}
+            {linesOf(plan.nodes, 0).join('\n')}
+          </pre>
+        )}
+      </div>
+    </div>
+  );
+}
+
+function linesOf(nodes: ExplainPlanNode[], depth: number): string[] {
+  return nodes.flatMap((node) => [
+    '  '.repeat(depth) + node.operator + (node.indexType ? ` [${node.indexType}]` : ''),
+    ...linesOf(node.children || [], depth + 1),
+  ]);
+}
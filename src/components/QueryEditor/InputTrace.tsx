@@ -0,0 +1,16 @@
+import { InlineSwitch } from '@grafana/ui';
+import React from 'react';
+import { FormLabel } from './FormLabel';
+import allLabels from '../../labels';
+
+export function InputTrace(props: { current: boolean; onChange: (val: boolean) => void }) {
+  const { current, onChange } = props;
+  const labels = allLabels.components.QueryEditor.trace;
+
+  return (
+    <div className={'gf-form'} data-testid="input-trace">
+      <FormLabel tooltip={labels.tooltip} label={labels.label} />
+      <InlineSwitch value={current} onChange={() => onChange(!current)} />
+    </div>
+  );
+}
@@ -28,6 +28,7 @@ export function PinotQlBuilder(props: PinotQueryEditorProps) {
                   to: props.range?.to,
                   from: props.range?.from,
                 }}
+                intervalSize={props.data?.request?.interval}
                 savedParams={LogsBuilder.paramsFrom(props.query)}
                 interpolatedParams={LogsBuilder.paramsFrom(
                   interpolateVariables(props.query, props.data?.request?.scopedVars)
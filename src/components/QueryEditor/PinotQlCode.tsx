@@ -4,12 +4,15 @@ import { InputTimeColumnAlias } from './InputTimeColumnAlias';
 import { InputMetricColumnAlias } from './InputMetricColumnAlias';
 import { PinotDataQuery } from '../../dataquery/PinotDataQuery';
 import { SqlPreview } from './SqlPreview';
+import { ExplainPlan } from './ExplainPlan';
 import { SelectDisplayType } from './SelectDisplayType';
 import { DateTime } from '@grafana/data';
 import { DataSource } from '../../datasource';
 import { InputMetricLegend } from './InputMetricLegend';
 import { InputLogColumnAlias } from './InputLogColumnAlias';
 import { SelectTable } from './SelectTable';
+import { SelectQueryEngine } from './SelectQueryEngine';
+import { InputTrace } from './InputTrace';
 import { DisplayType } from '../../dataquery/DisplayType';
 import { CodeQuery } from '../../pinotql';
 import { InputSeriesLimit } from './InputLimit';
@@ -49,6 +52,13 @@ export function PinotQlCode(props: {
         isLoading={resources.isTablesLoading}
         onChange={(tableName) => onChangeAndRun({ ...savedParams, tableName })}
       />
+      <div style={{ display: 'flex', flexDirection: 'row' }}>
+        <SelectQueryEngine
+          useMultistageEngine={savedParams.useMultistageEngine}
+          onChange={(useMultistageEngine) => onChangeAndRun({ ...savedParams, useMultistageEngine })}
+        />
+        <InputTrace current={savedParams.trace} onChange={(trace) => onChangeAndRun({ ...savedParams, trace })} />
+      </div>
       {savedParams.displayType === DisplayType.TABLE && (
         <InputTimeColumnAlias
           current={savedParams.timeColumnAlias}
@@ -86,6 +96,10 @@ export function PinotQlCode(props: {
         onChange={(pinotQlCode) => onChange({ ...savedParams, pinotQlCode })}
       />
       <SqlPreview sql={resources.sqlPreview} />
+      <ExplainPlan
+        disabled={!interpolatedParams.tableName || !interpolatedParams.pinotQlCode}
+        explain={() => CodeQuery.explain(datasource, intervalSize, timeRange, interpolatedParams)}
+      />
       {savedParams.displayType === DisplayType.TIMESERIES && (
         <div style={{ display: 'flex', flexDirection: 'row' }}>
           <InputMetricLegend
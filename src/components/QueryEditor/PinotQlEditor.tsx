@@ -1,12 +1,36 @@
-import React from 'react';
+import React, { useMemo } from 'react';
 import { PinotQueryEditorProps } from '../../dataquery/PinotQueryEditorProps';
 import { EditorMode } from '../../dataquery/EditorMode';
 import { PinotQlCode } from './PinotQlCode';
 import { interpolateVariables } from '../../dataquery/PinotDataQuery';
 import { PinotQlBuilder } from './PinotQlBuilder';
 import { CodeQuery } from '../../pinotql';
+import { SelectDatabase } from './SelectDatabase';
+import { useCapabilities } from '../../resources/capabilities';
+import { useDatabases, withDatabase } from '../../resources/databases';
 
 export function PinotQlEditor(props: PinotQueryEditorProps) {
+  const [capabilities] = useCapabilities(props.datasource);
+  const databases = useDatabases(props.datasource);
+  const database = interpolateVariables(props.query, props.data?.request?.scopedVars).database;
+  const datasource = useMemo(() => withDatabase(props.datasource, database), [props.datasource, database]);
+
+  return (
+    <>
+      {(capabilities?.databases || props.query.database) && (
+        <SelectDatabase
+          selected={props.query.database}
+          options={databases.result}
+          isLoading={databases.loading}
+          onChange={(database) => props.onChange({ ...props.query, database, tableName: undefined })}
+        />
+      )}
+      <PinotQlEditorOfMode {...props} datasource={datasource} />
+    </>
+  );
+}
+
+function PinotQlEditorOfMode(props: PinotQueryEditorProps) {
   switch (props.query.editorMode) {
     case EditorMode.Code:
       return (
@@ -1,6 +1,8 @@
 import { DateTime } from '@grafana/data';
 import { DataSource } from '../../datasource';
 import { SelectTable } from './SelectTable';
+import { SelectQueryEngine } from './SelectQueryEngine';
+import { InputTrace } from './InputTrace';
 import { SelectTimeColumn } from './SelectTimeColumn';
 import { SelectFilters } from './SelectFilters';
 import { SelectQueryOptions } from './SelectQueryOptions';
@@ -17,12 +19,13 @@ export function PinotQlLogsBuilder(props: {
   savedParams: LogsBuilder.Params;
   interpolatedParams: LogsBuilder.Params;
   timeRange: { to: DateTime | undefined; from: DateTime | undefined };
+  intervalSize: string | undefined;
   datasource: DataSource;
   onChange: (value: LogsBuilder.Params) => void;
   onRunQuery: () => void;
 }) {
-  const { timeRange, datasource, savedParams, interpolatedParams, onChange, onRunQuery } = props;
-  const resources = LogsBuilder.useResources(datasource, timeRange, interpolatedParams);
+  const { timeRange, intervalSize, datasource, savedParams, interpolatedParams, onChange, onRunQuery } = props;
+  const resources = LogsBuilder.useResources(datasource, timeRange, intervalSize, interpolatedParams);
 
   const onChangeAndRun = (newParams: LogsBuilder.Params) => {
     onChange(newParams);
@@ -92,9 +95,17 @@ export function PinotQlLogsBuilder(props: {
         onChange={(val) => onChangeAndRun({ ...savedParams, filters: val })}
       />
       <SelectQueryOptions
+        datasource={datasource}
         selected={savedParams.queryOptions}
         onChange={(queryOptions) => onChangeAndRun({ ...savedParams, queryOptions })}
       />
+      <div style={{ display: 'flex', flexDirection: 'row' }}>
+        <SelectQueryEngine
+          useMultistageEngine={savedParams.useMultistageEngine}
+          onChange={(useMultistageEngine) => onChangeAndRun({ ...savedParams, useMultistageEngine })}
+        />
+        <InputTrace current={savedParams.trace} onChange={(trace) => onChangeAndRun({ ...savedParams, trace })} />
+      </div>
       <InputLimit current={savedParams.limit} onChange={(limit) => onChangeAndRun({ ...savedParams, limit })} />
       <SqlPreview sql={resources.sqlPreview} />
     </>
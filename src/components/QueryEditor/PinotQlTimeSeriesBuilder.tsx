@@ -2,12 +2,15 @@ import { SelectMetricColumn } from './SelectMetricColumn';
 import { AggregationFunction, SelectAggregation } from './SelectAggregation';
 import { SelectGroupBy } from './SelectGroupBy';
 import { SqlPreview } from './SqlPreview';
+import { ExplainPlan } from './ExplainPlan';
 import React from 'react';
 import { InputLimit, InputSeriesLimit } from './InputLimit';
 import { SelectFilters } from './SelectFilters';
 import { SelectTimeColumn } from './SelectTimeColumn';
 import { SelectGranularity } from './SelectGranularity';
 import { SelectTable } from './SelectTable';
+import { SelectQueryEngine } from './SelectQueryEngine';
+import { InputTrace } from './InputTrace';
 import { SelectOrderBy } from './SelectOrderBy';
 import { SelectQueryOptions } from './SelectQueryOptions';
 import { DateTime } from '@grafana/data';
@@ -104,11 +107,23 @@ export function PinotQlTimeSeriesBuilder(props: {
         onChange={(filters) => onChangeAndRun({ ...savedParams, filters })}
       />
       <SelectQueryOptions
+        datasource={datasource}
         selected={savedParams.queryOptions}
         onChange={(queryOptions) => onChangeAndRun({ ...savedParams, queryOptions })}
       />
+      <div style={{ display: 'flex', flexDirection: 'row' }}>
+        <SelectQueryEngine
+          useMultistageEngine={savedParams.useMultistageEngine}
+          onChange={(useMultistageEngine) => onChangeAndRun({ ...savedParams, useMultistageEngine })}
+        />
+        <InputTrace current={savedParams.trace} onChange={(trace) => onChangeAndRun({ ...savedParams, trace })} />
+      </div>
       <InputLimit current={savedParams.limit} onChange={(limit) => onChangeAndRun({ ...savedParams, limit })} />
       <SqlPreview sql={resources.sqlPreview} />
+      <ExplainPlan
+        disabled={!TimeSeriesBuilder.canRunQuery(interpolatedParams)}
+        explain={() => TimeSeriesBuilder.explain(datasource, intervalSize, timeRange, interpolatedParams)}
+      />
       <div style={{ display: 'flex', flexDirection: 'row' }}>
         <InputMetricLegend
           current={savedParams.legend}
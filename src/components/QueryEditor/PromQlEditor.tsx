@@ -5,12 +5,13 @@ import { FormLabel } from './FormLabel';
 import { useTimeSeriesTables } from '../../resources/timeseries';
 import { InputMetricLegend } from './InputMetricLegend';
 import { Button, Icon, Modal } from '@grafana/ui';
-import { useIsPromQlSupported } from '../../resources/isPromQlSupported';
+import { useCapabilities } from '../../resources/capabilities';
 import { QueryType } from '../../dataquery/QueryType';
 import { DataSource } from '../../datasource';
 import { PromQlExpressionEditor } from './PromQlExpressionEditor';
 import { InputSeriesLimit } from './InputLimit';
 import { dataQueryOf, Params, paramsFrom } from '../../promql/params';
+import { SelectQueryOptions } from './SelectQueryOptions';
 
 export function PromQlEditor(props: PinotQueryEditorProps) {
   const { result: tables, loading: isTablesLoading } = useTimeSeriesTables(props.datasource);
@@ -58,12 +59,18 @@ export function PromQlEditor(props: PinotQueryEditorProps) {
           onChange={(seriesLimit) => onChangeAndRun({ ...params, seriesLimit })}
         />
       </div>
+      <SelectQueryOptions
+        datasource={props.datasource}
+        selected={params.queryOptions}
+        onChange={(queryOptions) => onChangeAndRun({ ...params, queryOptions })}
+      />
     </>
   );
 }
 
 function UnsupportedModel(props: { datasource: DataSource; onClose: () => void }) {
-  const [isSupported, loading] = useIsPromQlSupported(props.datasource);
+  const [capabilities, loading] = useCapabilities(props.datasource);
+  const isSupported = capabilities?.timeseriesApi || false;
   const [showModal, setShowModal] = useState(false);
   const onCloseModal = () => {
     setShowModal(false);
@@ -4,6 +4,8 @@ import React from 'react';
 import { SelectQueryType } from './SelectQueryType';
 import { SelectEditorMode } from './SelectEditorMode';
 import {QueryType} from "../../dataquery/QueryType";
+import { interpolateVariables } from '../../dataquery/PinotDataQuery';
+import { withDatabase } from '../../resources/databases';
 
 export function QueryEditorHeader(props: PinotQueryEditorProps) {
   const { query, onChange, onRunQuery } = props;
@@ -20,7 +22,10 @@ export function QueryEditorHeader(props: PinotQueryEditorProps) {
       <div style={{ display: 'flex' }}>
         <div className={'gf-form'}>
           <SelectEditorMode
-            datasource={props.datasource}
+            datasource={withDatabase(
+              props.datasource,
+              interpolateVariables(props.query, props.data?.request?.scopedVars).database
+            )}
             query={props.query}
             onChange={props.onChange}
             timeRange={{
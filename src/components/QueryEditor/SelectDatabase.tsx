@@ -0,0 +1,33 @@
+import { Select } from '@grafana/ui';
+import { styles } from '../../styles';
+import React from 'react';
+import { FormLabel } from './FormLabel';
+import allLabels from '../../labels';
+
+export function SelectDatabase(props: {
+  selected: string | undefined;
+  options: string[];
+  isLoading: boolean;
+  onChange: (val: string | undefined) => void;
+}) {
+  const { selected, options, isLoading, onChange } = props;
+  const labels = allLabels.components.QueryEditor.database;
+
+  return (
+    <div className={'gf-form'} data-testid="select-database">
+      <FormLabel tooltip={labels.tooltip} label={labels.label} />
+      <div data-testid="select-database-dropdown">
+        <Select
+          className={`${styles.QueryEditor.inputForm}`}
+          options={options.map((name) => ({ label: name, value: name }))}
+          value={selected || null}
+          isLoading={isLoading}
+          allowCustomValue
+          isClearable
+          placeholder="Data source default"
+          onChange={(change) => onChange(change?.value || undefined)}
+        />
+      </div>
+    </div>
+  );
+}
@@ -58,6 +58,7 @@ export function SelectEditorMode(props: {
             const builderParams = LogsBuilder.paramsFrom(query);
             previewLogsSql(datasource, {
               ...builderParams,
+              intervalSize: intervalSize,
               timeRange: timeRange,
               expandMacros: false,
             }).then((sql) =>
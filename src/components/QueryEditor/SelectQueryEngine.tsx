@@ -0,0 +1,26 @@
+import { RadioButtonGroup } from '@grafana/ui';
+import React from 'react';
+import { FormLabel } from './FormLabel';
+import allLabels from '../../labels';
+
+const QueryEngineOptions = [
+  { label: 'Single-stage', value: false },
+  { label: 'Multi-stage', value: true },
+];
+
+export function SelectQueryEngine(props: { useMultistageEngine: boolean; onChange: (val: boolean) => void }) {
+  const { useMultistageEngine, onChange } = props;
+  const labels = allLabels.components.QueryEditor.queryEngine;
+
+  return (
+    <div className={'gf-form'} data-testid="select-query-engine">
+      <FormLabel tooltip={labels.tooltip} label={labels.label} />
+      <RadioButtonGroup
+        data-testid="radio"
+        options={QueryEngineOptions}
+        onChange={onChange}
+        value={useMultistageEngine}
+      />
+    </div>
+  );
+}
@@ -4,10 +4,16 @@ import { AccessoryButton } from '@grafana/experimental';
 import allLabels from '../../labels';
 import { QueryOption } from '../../dataquery/QueryOption';
 import { EditQueryOption } from './EditQueryOption';
-import { PinotQueryOptions } from '../../pinotql/pinotQueryOptions';
+import { DataSource } from '../../datasource';
+import { useQueryOptionCatalog } from '../../resources/queryOptions';
 
-export function SelectQueryOptions(props: { selected: QueryOption[]; onChange: (val: QueryOption[]) => void }) {
-  const { selected, onChange } = props;
+export function SelectQueryOptions(props: {
+  datasource: DataSource;
+  selected: QueryOption[];
+  onChange: (val: QueryOption[]) => void;
+}) {
+  const { datasource, selected, onChange } = props;
+  const catalog = useQueryOptionCatalog(datasource);
   const labels = allLabels.components.QueryEditor.queryOptions;
 
   const onChangeOption = (val: QueryOption, idx: number) => {
@@ -23,7 +29,12 @@ export function SelectQueryOptions(props: { selected: QueryOption[]; onChange: (
     .filter((name) => name)
     .reduce((collector, name) => collector.add(name), new Set<string>());
 
-  const unused = PinotQueryOptions.map(({ name }) => name)
+  const known = catalog
+    .map(({ name }) => name.toLowerCase())
+    .reduce((collector, name) => collector.add(name), new Set<string>());
+
+  const unused = catalog
+    .map(({ name }) => name)
     .filter((name) => !selectedNames.has(name))
     .reduce((collector, name) => collector.add(name), new Set<string>());
 
@@ -36,6 +47,7 @@ export function SelectQueryOptions(props: { selected: QueryOption[]; onChange: (
             <EditQueryOption
               queryOption={option}
               unused={unused}
+              isUnknown={!!option.name && !known.has(option.name.toLowerCase())}
               onChange={(val) => onChangeOption(val, idx)}
               onDelete={() => onDeleteOption(idx)}
             />
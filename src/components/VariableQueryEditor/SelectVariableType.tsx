@@ -8,6 +8,7 @@ export const VariableType = Object.freeze({
   ColumnList: 'COLUMN_LIST',
   DistinctValues: 'DISTINCT_VALUES',
   PinotQlCode: 'PINOT_QL_CODE',
+  DatabaseList: 'DATABASE_LIST',
 });
 
 export function SelectVariableType(props: { selected: string; onChange: (val: string) => void }) {
@@ -18,6 +19,7 @@ export function SelectVariableType(props: { selected: string; onChange: (val: st
     { label: 'Columns', value: VariableType.ColumnList },
     { label: 'Distinct Values', value: VariableType.DistinctValues },
     { label: 'Sql Query', value: VariableType.PinotQlCode },
+    { label: 'Databases', value: VariableType.DatabaseList },
   ];
 
   return (
@@ -4,12 +4,50 @@ import { QueryOption } from '../dataquery/QueryOption';
 export interface PinotConnectionConfig extends DataSourceJsonData {
   controllerUrl?: string;
   brokerUrl?: string;
+  brokerUrls?: string[];
+  brokerDiscovery?: boolean;
   databaseName?: string;
   tokenType?: string;
   queryOptions: QueryOption[];
   oauthPassThru?: boolean;
+  oauth2TokenUrl?: string;
+  oauth2ClientId?: string;
+  oauth2Scopes?: string[];
+  maxRetries?: number;
+  retryInitialBackoffMs?: number;
+  retryMaxBackoffMs?: number;
+  metadataCacheTtlSeconds?: number;
+  queryResultCacheTtlSeconds?: number;
+  queryResultCacheMaxMb?: number;
+  maxConcurrentQueries?: number;
+  partialResultPolicy?: string;
+  minServerResponseRatio?: number;
+  rowLevelSecurity?: RowLevelSecurity;
+  deniedTables?: string[];
+  timeFilterTables?: string[];
+  maxQueryLimit?: number;
+  maxDocsScanned?: number;
+  maxQueryResponseSizeBytes?: number;
+  tableIncludePatterns?: string[];
+  tableExcludePatterns?: string[];
+}
+
+export interface RowLevelSecurity {
+  rules?: RowLevelSecurityRule[];
+  codeMode?: 'reject' | 'wrap';
+  grafanaUrl?: string;
+}
+
+export interface RowLevelSecurityRule {
+  users?: string[];
+  teams?: string[];
+  roles?: string[];
+  tables?: string[];
+  filter?: string;
 }
 
 export interface PinotSecureConfig {
   authToken?: string;
+  oauth2ClientSecret?: string;
+  rowLevelSecurityToken?: string;
 }
@@ -0,0 +1,45 @@
+import { filterValueExprOf, filterValueExprsOf, filterValueOf } from './DimensionFilter';
+import { PinotDataType } from './PinotDataType';
+
+describe('filterValueOf', () => {
+  test.each([
+    { expr: "'abc'", dataType: PinotDataType.STRING, want: { type: 'string', value: 'abc' } },
+    { expr: "'it''s'", dataType: PinotDataType.STRING, want: { type: 'string', value: "it's" } },
+    { expr: 'abc', dataType: PinotDataType.STRING, want: { type: 'string', value: 'abc' } },
+    { expr: '123', dataType: PinotDataType.STRING, want: { type: 'string', value: '123' } },
+    { expr: '123', dataType: PinotDataType.LONG, want: { type: 'number', value: '123' } },
+    { expr: '$threshold', dataType: PinotDataType.DOUBLE, want: { type: 'number', value: '$threshold' } },
+    { expr: 'true', dataType: PinotDataType.BOOLEAN, want: { type: 'boolean', value: true } },
+    { expr: 'null', dataType: PinotDataType.INT, want: { type: 'null' } },
+    { expr: '1.5', dataType: undefined, want: { type: 'number', value: '1.5' } },
+    { expr: '$var', dataType: undefined, want: { type: 'string', value: '$var' } },
+  ])('$expr as $dataType', ({ expr, dataType, want }) => {
+    expect(filterValueOf(expr, dataType)).toEqual(want);
+  });
+});
+
+describe('filterValueExprOf', () => {
+  test('literals', () => {
+    expect(filterValueExprOf({ type: 'string', value: "it's" })).toEqual("'it''s'");
+    expect(filterValueExprOf({ type: 'number', value: 1.5 })).toEqual('1.5');
+    expect(filterValueExprOf({ type: 'boolean', value: false })).toEqual('false');
+    expect(filterValueExprOf({ type: 'null' })).toEqual('null');
+    expect(
+      filterValueExprOf({
+        type: 'list',
+        value: [
+          { type: 'number', value: 1 },
+          { type: 'string', value: 'a' },
+        ],
+      })
+    ).toEqual("(1, 'a')");
+  });
+});
+
+describe('filterValueExprsOf', () => {
+  test('prefers typed values', () => {
+    expect(filterValueExprsOf({ values: [{ type: 'string', value: 'a' }], valueExprs: ["'b'"] })).toEqual(["'a'"]);
+    expect(filterValueExprsOf({ valueExprs: ["'b'"] })).toEqual(["'b'"]);
+    expect(filterValueExprsOf({})).toEqual([]);
+  });
+});
@@ -1,6 +1,58 @@
+import { NumericPinotDataTypes, PinotDataType } from './PinotDataType';
+
 export interface DimensionFilter {
   columnName?: string;
   columnKey?: string;
   operator?: string;
+  values?: FilterValue[];
   valueExprs?: string[];
 }
+
+export type FilterValue =
+  | { type: 'string'; value: string }
+  | { type: 'number'; value: number | string }
+  | { type: 'boolean'; value: boolean }
+  | { type: 'list'; value: FilterValue[] }
+  | { type: 'null' };
+
+const StringLiteralRegex = /^'(?:[^']|'')*'$/;
+const NumberLiteralRegex = /^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$/;
+
+// Renders the filter value as the SQL literal shown in the filter editor.
+export function filterValueExprOf(value: FilterValue): string {
+  switch (value.type) {
+    case 'string':
+      return `'${value.value.replace(/'/g, "''")}'`;
+    case 'number':
+    case 'boolean':
+      return String(value.value);
+    case 'list':
+      return `(${value.value.map(filterValueExprOf).join(', ')})`;
+    default:
+      return 'null';
+  }
+}
+
+// Returns the typed value of an expression picked or typed in the filter editor.
+// Literals keep their own type. Other input, such as template variables, takes the type of the column.
+export function filterValueOf(expr: string, dataType: string | undefined): FilterValue {
+  const trimmed = expr.trim();
+  if (StringLiteralRegex.test(trimmed)) {
+    return { type: 'string', value: trimmed.slice(1, -1).replace(/''/g, "'") };
+  } else if (trimmed.toLowerCase() === 'null') {
+    return { type: 'null' };
+  } else if (dataType && NumericPinotDataTypes.includes(dataType)) {
+    return { type: 'number', value: trimmed };
+  } else if (dataType === PinotDataType.BOOLEAN && ['true', 'false'].includes(trimmed.toLowerCase())) {
+    return { type: 'boolean', value: trimmed.toLowerCase() === 'true' };
+  } else if (dataType === undefined && NumberLiteralRegex.test(trimmed)) {
+    return { type: 'number', value: trimmed };
+  } else {
+    return { type: 'string', value: expr };
+  }
+}
+
+// Returns the values of the filter as SQL literals. Saved queries may only have value expressions.
+export function filterValueExprsOf(filter: DimensionFilter): string[] {
+  return filter.values?.length ? filter.values.map(filterValueExprOf) : filter.valueExprs || [];
+}
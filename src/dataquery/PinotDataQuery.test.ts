@@ -52,6 +52,7 @@ describe('interpolateVariables', () => {
             $filterColumn: 'filterColumnReplaced',
             $filterColumnKey: 'filterColumnKeyReplaced',
             $filterColumnValue: 'filterColumnValueReplaced',
+            $filterNumberValue: '42',
             $groupByColumn: 'groupByColumnReplaced',
             $groupByColumnKey: 'groupByColumnKeyReplaced',
             $orderByColumn: 'orderByColumnReplaced',
@@ -91,6 +92,15 @@ describe('interpolateVariables', () => {
             operator: '=',
             valueExprs: ['$filterColumnValue'],
           },
+          {
+            columnName: '$filterColumn',
+            operator: 'in',
+            values: [
+              { type: 'string', value: '$filterColumnValue' },
+              { type: 'number', value: 1 },
+              { type: 'number', value: '$filterNumberValue' },
+            ],
+          },
         ],
         granularity: '$granularity',
         groupByColumns: ['$groupByColumn'],
@@ -140,6 +150,15 @@ describe('interpolateVariables', () => {
           operator: '=',
           valueExprs: ['filterColumnValueReplaced'],
         },
+        {
+          columnName: 'filterColumnReplaced',
+          operator: 'in',
+          values: [
+            { type: 'string', value: 'filterColumnValueReplaced' },
+            { type: 'number', value: 1 },
+            { type: 'number', value: '42' },
+          ],
+        },
       ],
       granularity: 'granularityReplaced',
       groupByColumns: ['groupByColumnReplaced'],
@@ -12,7 +12,13 @@ import { RegexpExtractor } from './RegexpExtractor';
 export interface PinotDataQuery extends DataQuery {
   queryType?: string;
   editorMode?: string;
+  database?: string;
   tableName?: string;
+  useMultistageEngine?: boolean;
+  skipResultCache?: boolean;
+  trace?: boolean;
+  partialResultPolicy?: string;
+  minServerResponseRatio?: number;
 
   // PinotQl Builder
   timeColumn?: string;
@@ -61,6 +67,8 @@ export function interpolateVariables(query: PinotDataQuery, scopedVars?: ScopedV
   return {
     ...query,
 
+    database: replaceIfExists(query.database),
+
     // Sql Builder
 
     timeColumn: replaceIfExists(query.timeColumn),
@@ -107,10 +115,15 @@ export function interpolateVariables(query: PinotDataQuery, scopedVars?: ScopedV
       alias: replaceIfExists(alias),
       group,
     })),
-    filters: query.filters?.map(({ columnName, columnKey, operator, valueExprs }) => ({
+    filters: query.filters?.map(({ columnName, columnKey, operator, values, valueExprs }) => ({
       columnName: replaceIfExists(columnName),
       columnKey: replaceIfExists(columnKey),
       operator,
+      values: values?.map((value) =>
+        value.type === 'string' || (value.type === 'number' && typeof value.value === 'string')
+          ? { ...value, value: replace(String(value.value)) }
+          : value
+      ),
       valueExprs: valueExprs?.map((expr) => replace(expr)),
     })),
     queryOptions: query.queryOptions?.map(({ name, value }) => ({
@@ -8,6 +8,8 @@ export const PinotDataType = Object.freeze({
   BYTES: 'BYTES',
   JSON: 'JSON',
   BIG_DECIMAL: 'BIG_DECIMAL',
+  BOOLEAN: 'BOOLEAN',
+  TIMESTAMP: 'TIMESTAMP',
 });
 
 export const PinotDataTypes = Object.values(PinotDataType);
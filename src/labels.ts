@@ -17,6 +17,26 @@ export default {
         valueLabel: 'Token',
         valuePlaceholder: 'Token',
       },
+      oauth2: {
+        tokenUrl: {
+          label: 'Token URL',
+          placeholder: 'Token URL',
+          tooltip: 'The OAuth2 endpoint that issues access tokens for the client credentials.',
+        },
+        clientId: {
+          label: 'Client ID',
+          placeholder: 'Client ID',
+        },
+        clientSecret: {
+          label: 'Client Secret',
+          placeholder: 'Client Secret',
+        },
+        scopes: {
+          label: 'Scopes',
+          placeholder: 'Add a scope',
+          tooltip: 'Optionally specify the scopes to request.',
+        },
+      },
       database: {
         label: 'Database',
         placeholder: 'default',
@@ -97,7 +117,7 @@ export default {
         label: 'Log Column',
       },
       database: {
-        tooltip: 'Select the Pinot database. Required.',
+        tooltip: 'Select the Pinot database, or enter a template variable. Defaults to the database of the data source.',
         label: 'Database',
       },
       table: {
@@ -118,10 +138,22 @@ export default {
         copyTooltip: 'Copy SQL to clipboard.',
         copiedTooltip: 'Copied!',
       },
+      explainPlan: {
+        tooltip: 'Show how Pinot executes the query, and which indexes it uses.',
+        label: 'Explain Plan',
+      },
       display: {
         tooltip: 'Choose display type.',
         label: 'Display',
       },
+      queryEngine: {
+        tooltip: 'Choose the Pinot query engine. The multi-stage engine supports joins, subqueries and window functions.',
+        label: 'Engine',
+      },
+      trace: {
+        tooltip: 'Trace the query. The operator timings are returned as an additional table. Traced results are not cached.',
+        label: 'Trace',
+      },
       queryOptions: {
         help: 'https://docs.pinot.apache.org/users/user-guide-query/query-options',
         tooltip: 'Add query options.',
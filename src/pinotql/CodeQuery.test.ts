@@ -12,6 +12,8 @@ const newEmptyParams = (): CodeQuery.Params => {
     logColumnAlias: '',
     legend: '',
     seriesLimit: 0,
+    useMultistageEngine: false,
+    trace: false,
   };
 };
 
@@ -26,6 +28,8 @@ describe('paramsFrom', () => {
       tableName: '',
       timeColumnAlias: '',
       seriesLimit: 0,
+      useMultistageEngine: false,
+      trace: false,
     });
   });
 
@@ -41,6 +45,8 @@ describe('paramsFrom', () => {
         tableName: 'test_table',
         timeColumnAlias: 'test_time_column_alias',
         seriesLimit: 101,
+        useMultistageEngine: true,
+        trace: true,
       })
     ).toEqual<CodeQuery.Params>({
       displayType: 'LOGS',
@@ -51,6 +57,8 @@ describe('paramsFrom', () => {
       tableName: 'test_table',
       timeColumnAlias: 'test_time_column_alias',
       seriesLimit: 101,
+      useMultistageEngine: true,
+      trace: true,
     });
   });
 });
@@ -62,6 +70,8 @@ describe('paramsFromTimeSeriesBuilder', () => {
     metricColumn: { name: 'test_metric', key: 'test_metric_key' },
     legend: '{{ dim }}',
     seriesLimit: 1,
+    useMultistageEngine: true,
+    trace: true,
 
     // These fields are not used in the function.
     aggregationFunction: '',
@@ -82,6 +92,8 @@ describe('paramsFromTimeSeriesBuilder', () => {
     tableName: 'test_table',
     timeColumnAlias: '',
     seriesLimit: 1,
+    useMultistageEngine: true,
+    trace: true,
   });
 });
 
@@ -91,6 +103,8 @@ describe('paramsFromLogsBuilder', () => {
       {
         tableName: 'test_table',
         logColumn: { name: 'test_log', key: 'test_log_key' },
+        useMultistageEngine: true,
+        trace: true,
 
         // These fields are not used in the function.
         filters: [],
@@ -112,6 +126,8 @@ describe('paramsFromLogsBuilder', () => {
     legend: '',
     timeColumnAlias: '',
     seriesLimit: 0,
+    useMultistageEngine: true,
+    trace: true,
   });
 });
 
@@ -134,6 +150,8 @@ GROUP BY $__timeGroup("timestamp")
 ORDER BY $__timeAlias() DESC
 LIMIT 100000`,
       seriesLimit: 0,
+      useMultistageEngine: false,
+      trace: false,
     });
   });
 
@@ -147,6 +165,8 @@ LIMIT 100000`,
       logColumnAlias: 'test_log_column_alias',
       legend: '{{ dim }}',
       seriesLimit: 101,
+      useMultistageEngine: false,
+      trace: false,
     };
     expect(CodeQuery.applyDefaults(params)).toEqual(false);
     expect(params).toEqual<CodeQuery.Params>({
@@ -158,6 +178,8 @@ LIMIT 100000`,
       logColumnAlias: 'test_log_column_alias',
       legend: '{{ dim }}',
       seriesLimit: 101,
+      useMultistageEngine: false,
+      trace: false,
     });
   });
 });
@@ -189,6 +211,8 @@ describe('dataQueryOf', () => {
       logColumnAlias: 'test_log_column_alias',
       legend: '{{ dim }}',
       seriesLimit: 101,
+      useMultistageEngine: true,
+      trace: true,
     };
 
     expect(CodeQuery.dataQueryOf({ refId: 'test_id' }, params)).toEqual<PinotDataQuery>({
@@ -203,6 +227,8 @@ describe('dataQueryOf', () => {
       logColumnAlias: 'test_log_column_alias',
       legend: '{{ dim }}',
       seriesLimit: 101,
+      useMultistageEngine: true,
+      trace: true,
     });
   });
 });
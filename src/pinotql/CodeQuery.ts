@@ -8,6 +8,7 @@ import { useTables } from '../resources/tables';
 import { UseResourceResult } from '../resources/UseResourceResult';
 import { useEffect, useState } from 'react';
 import { previewSqlCode, PreviewSqlCodeRequest } from '../resources/previewSql';
+import { ExplainPlan, explainSqlCode } from '../resources/explainPlan';
 import { Params as TimeSeriesBuilderParams } from './TimeSeriesBuilder';
 import { Params as LogsBuilderParams } from './LogsBuilder';
 import {columnLabelOf} from "./complexField";
@@ -29,6 +30,8 @@ export interface Params {
   logColumnAlias: string;
   legend: string;
   seriesLimit: number;
+  useMultistageEngine: boolean;
+  trace: boolean;
 }
 
 export function paramsFrom(query: PinotDataQuery): Params {
@@ -41,6 +44,8 @@ export function paramsFrom(query: PinotDataQuery): Params {
     logColumnAlias: query.logColumnAlias || '',
     legend: query.legend || '',
     seriesLimit: query.seriesLimit || 0,
+    useMultistageEngine: query.useMultistageEngine || false,
+    trace: query.trace || false,
   };
 }
 
@@ -54,6 +59,8 @@ export function paramsFromTimeSeriesBuilder(params: TimeSeriesBuilderParams, sql
     timeColumnAlias: '',
     logColumnAlias: '',
     seriesLimit: params.seriesLimit,
+    useMultistageEngine: params.useMultistageEngine,
+    trace: params.trace,
   };
 }
 
@@ -67,6 +74,8 @@ export function paramsFromLogsBuilder(params: LogsBuilderParams, sql: string): P
     metricColumnAlias: '',
     legend: '',
     seriesLimit: 0,
+    useMultistageEngine: params.useMultistageEngine,
+    trace: params.trace,
   };
 }
 
@@ -96,6 +105,8 @@ export function dataQueryOf(query: PinotDataQuery, params: Params): PinotDataQue
     logColumnAlias: params.logColumnAlias || undefined,
     legend: params.legend || undefined,
     seriesLimit: params.seriesLimit || undefined,
+    useMultistageEngine: params.useMultistageEngine || undefined,
+    trace: params.trace || undefined,
   };
 }
 
@@ -139,7 +150,27 @@ function useSqlPreview(
   const [result, setResult] = useState('');
   const [loading, setLoading] = useState(false);
 
-  const previewRequest: PreviewSqlCodeRequest = {
+  const previewRequest = previewRequestOf(intervalSize, timeRange, interpolatedParams);
+
+  useEffect(() => {
+    setLoading(true);
+    previewSqlCode(datasource, previewRequest)
+      .then((val) => val && setResult(val))
+      .finally(() => setLoading(false));
+  }, [datasource, JSON.stringify(previewRequest)]); // eslint-disable-line react-hooks/exhaustive-deps
+
+  return { result, loading };
+}
+
+function previewRequestOf(
+  intervalSize: string | undefined,
+  timeRange: {
+    to: DateTime | undefined;
+    from: DateTime | undefined;
+  },
+  interpolatedParams: Params
+): PreviewSqlCodeRequest {
+  return {
     intervalSize: intervalSize,
     timeRange: {
       to: timeRange.to?.endOf('second'),
@@ -149,14 +180,19 @@ function useSqlPreview(
     timeColumnAlias: interpolatedParams.timeColumnAlias,
     metricColumnAlias: interpolatedParams.metricColumnAlias,
     code: interpolatedParams.pinotQlCode,
+    useMultistageEngine: interpolatedParams.useMultistageEngine,
   };
+}
 
-  useEffect(() => {
-    setLoading(true);
-    previewSqlCode(datasource, previewRequest)
-      .then((val) => val && setResult(val))
-      .finally(() => setLoading(false));
-  }, [datasource, JSON.stringify(previewRequest)]); // eslint-disable-line react-hooks/exhaustive-deps
-
-  return { result, loading };
+// explain returns the explain plan of the query, rendered as it would be executed.
+export async function explain(
+  datasource: DataSource,
+  intervalSize: string | undefined,
+  timeRange: {
+    to: DateTime | undefined;
+    from: DateTime | undefined;
+  },
+  interpolatedParams: Params
+): Promise<ExplainPlan> {
+  return explainSqlCode(datasource, previewRequestOf(intervalSize, timeRange, interpolatedParams));
 }
@@ -11,6 +11,8 @@ const newEmptyParams = (): LogsBuilder.Params => ({
   timeColumn: '',
   logColumn: {},
   limit: 0,
+  useMultistageEngine: false,
+  trace: false,
   filters: [],
   queryOptions: [],
   metadataColumns: [],
@@ -25,6 +27,8 @@ describe('paramsFrom', () => {
     timeColumn: 'test_time_column',
     logColumn: { name: 'test_log_column', key: 'test_metric_column_key' },
     limit: 100,
+    useMultistageEngine: true,
+    trace: true,
     filters: [{ columnName: 'test_filter_column', operator: '=', valueExprs: ['test_value'] }],
     queryOptions: [{ name: 'test_query_option', value: 'test_option_value' }],
     metadataColumns: [{ name: 'metadata_column', key: 'metadata_column_key' }],
@@ -53,6 +57,8 @@ describe('paramsFrom', () => {
       timeColumn: 'test_time_column',
       logColumn: { name: 'test_log_column', key: 'test_metric_column_key' },
       limit: 100,
+      useMultistageEngine: true,
+      trace: true,
       filters: [{ columnName: 'test_filter_column', operator: '=', valueExprs: ['test_value'] }],
       queryOptions: [{ name: 'test_query_option', value: 'test_option_value' }],
       metadataColumns: [{ name: 'metadata_column', key: 'metadata_column_key' }],
@@ -82,6 +88,8 @@ describe('paramsFrom', () => {
       timeColumn: '',
       logColumn: {},
       limit: 0,
+      useMultistageEngine: false,
+      trace: false,
       filters: [],
       queryOptions: [],
       metadataColumns: [],
@@ -97,6 +105,8 @@ describe('canRunQuery', () => {
     timeColumn: 'test_time_column',
     logColumn: { name: 'test_log_column', key: 'test_metric_column_key' },
     limit: 100,
+    useMultistageEngine: false,
+    trace: false,
     filters: [{ columnName: 'test_filter_column', operator: '=', valueExprs: ['test_value'] }],
     queryOptions: [{ name: 'test_query_option', value: 'test_option_value' }],
     metadataColumns: [{ name: 'metadata_column', key: 'metadata_column_key' }],
@@ -186,6 +196,8 @@ describe('applyDefaults', () => {
       timeColumn: 'ts',
       logColumn: { name: 'message1', key: undefined },
       limit: 0,
+      useMultistageEngine: false,
+      trace: false,
       filters: [],
       queryOptions: [],
       metadataColumns: [],
@@ -200,6 +212,8 @@ describe('applyDefaults', () => {
       timeColumn: 'test_time_column',
       logColumn: { name: 'test_log_column', key: 'test_metric_column_key' },
       limit: 100,
+      useMultistageEngine: false,
+      trace: false,
       filters: [{ columnName: 'test_filter_column', operator: '=', valueExprs: ['test_value'] }],
       queryOptions: [{ name: 'test_query_option', value: 'test_option_value' }],
       metadataColumns: [{ name: 'metadata_column', key: 'metadata_column_key' }],
@@ -227,6 +241,8 @@ describe('applyDefaults', () => {
       timeColumn: 'test_time_column',
       logColumn: { name: 'test_log_column', key: 'test_metric_column_key' },
       limit: 100,
+      useMultistageEngine: false,
+      trace: false,
       filters: [{ columnName: 'test_filter_column', operator: '=', valueExprs: ['test_value'] }],
       queryOptions: [{ name: 'test_query_option', value: 'test_option_value' }],
       metadataColumns: [{ name: 'metadata_column', key: 'metadata_column_key' }],
@@ -279,6 +295,8 @@ describe('dataQueryOf', () => {
         timeColumn: 'test_time_column',
         logColumn: { name: 'test_log_column', key: 'test_metric_column_key' },
         limit: 100,
+        useMultistageEngine: true,
+        trace: true,
         filters: [{ columnName: 'test_filter_column', operator: '=', valueExprs: ['test_value'] }],
         queryOptions: [{ name: 'test_query_option', value: 'test_option_value' }],
         metadataColumns: [{ name: 'metadata_column', key: 'metadata_column_key' }],
@@ -309,6 +327,8 @@ describe('dataQueryOf', () => {
       timeColumn: 'test_time_column',
       logColumn: { name: 'test_log_column', key: 'test_metric_column_key' },
       limit: 100,
+      useMultistageEngine: true,
+      trace: true,
       filters: [{ columnName: 'test_filter_column', operator: '=', valueExprs: ['test_value'] }],
       queryOptions: [{ name: 'test_query_option', value: 'test_option_value' }],
       metadataColumns: [{ name: 'metadata_column', key: 'metadata_column_key' }],
@@ -27,6 +27,8 @@ export interface Params {
   metadataColumns: ComplexField[];
   jsonExtractors: JsonExtractor[];
   regexpExtractors: RegexpExtractor[];
+  useMultistageEngine: boolean;
+  trace: boolean;
 }
 
 export interface Resources {
@@ -54,6 +56,8 @@ export function paramsFrom(query: PinotDataQuery): Params {
     filters: query.filters || [],
     queryOptions: query.queryOptions || [],
     limit: query.limit || 0,
+    useMultistageEngine: query.useMultistageEngine || false,
+    trace: query.trace || false,
   };
 }
 
@@ -108,12 +112,15 @@ export function dataQueryOf(query: PinotDataQuery, params: Params): PinotDataQue
     filters: isEmpty(params.filters) ? undefined : params.filters,
     queryOptions: isEmpty(params.queryOptions) ? undefined : params.queryOptions,
     limit: params.limit || undefined,
+    useMultistageEngine: params.useMultistageEngine || undefined,
+    trace: params.trace || undefined,
   };
 }
 
 export function useResources(
   datasource: DataSource,
   timeRange: { to: DateTime | undefined; from: DateTime | undefined },
+  intervalSize: string | undefined,
   interpolatedParams: Params
 ): Resources {
   const tablesResult = useTables(datasource);
@@ -128,7 +135,7 @@ export function useResources(
     filters: interpolatedParams.filters,
   });
 
-  const sqlPreviewResult = useSqlPreview(datasource, timeRange, interpolatedParams);
+  const sqlPreviewResult = useSqlPreview(datasource, intervalSize, timeRange, interpolatedParams);
   return resourcesFrom(tablesResult, columnsResult, sqlPreviewResult);
 }
 
@@ -157,6 +164,7 @@ export function resourcesFrom(
 
 function useSqlPreview(
   datasource: DataSource,
+  intervalSize: string | undefined,
   timeRange: {
     to: DateTime | undefined;
     from: DateTime | undefined;
@@ -167,6 +175,7 @@ function useSqlPreview(
   const [loading, setLoading] = useState(false);
 
   const previewRequest: PreviewLogsSqlRequest = {
+    intervalSize: intervalSize,
     timeRange: {
       to: timeRange.to?.endOf('second'),
       from: timeRange.from?.startOf('second'),
@@ -181,6 +190,7 @@ function useSqlPreview(
     jsonExtractors: interpolatedParams.jsonExtractors,
     regexpExtractors: interpolatedParams.regexpExtractors,
     filters: interpolatedParams.filters,
+    useMultistageEngine: interpolatedParams.useMultistageEngine,
   };
 
   useEffect(() => {
@@ -20,6 +20,8 @@ const newEmptyParams = (): TimeSeriesBuilder.Params => ({
   legend: '',
   groupByColumns: [],
   seriesLimit: 0,
+  useMultistageEngine: false,
+  trace: false,
 });
 
 describe('paramsFrom', () => {
@@ -40,6 +42,8 @@ describe('paramsFrom', () => {
     groupByColumns: ['test_dim_column_1'],
     groupByColumnsV2: [{ name: 'test_dim_column2', key: 'test_dim_column2_key' }],
     seriesLimit: 101,
+    useMultistageEngine: true,
+    trace: true,
   };
 
   test('query is fully populated', () => {
@@ -56,6 +60,8 @@ describe('paramsFrom', () => {
       legend: '{{test_dim_column}}',
       groupByColumns: [{ name: 'test_dim_column_1' }, { name: 'test_dim_column2', key: 'test_dim_column2_key' }],
       seriesLimit: 101,
+      useMultistageEngine: true,
+      trace: true,
     });
   });
 
@@ -73,6 +79,8 @@ describe('paramsFrom', () => {
       legend: '{{test_dim_column}}',
       groupByColumns: [{ name: 'test_dim_column_1' }, { name: 'test_dim_column2', key: 'test_dim_column2_key' }],
       seriesLimit: 101,
+      useMultistageEngine: true,
+      trace: true,
     });
   });
 
@@ -89,7 +97,9 @@ describe('paramsFrom', () => {
       queryOptions: [],
       legend: '',
       groupByColumns: [],
-      seriesLimit: 0
+      seriesLimit: 0,
+      useMultistageEngine: false,
+      trace: false,
     });
   });
 });
@@ -108,6 +118,8 @@ describe('canRunQuery', () => {
     legend: '{{test_dim_column}}',
     groupByColumns: [{ name: 'test_dim_column' }],
     seriesLimit: 101,
+    useMultistageEngine: false,
+    trace: false,
   };
 
   test('params are empty', () => {
@@ -194,7 +206,9 @@ describe('applyDefaults', () => {
       queryOptions: [],
       legend: '',
       groupByColumns: [],
-      seriesLimit: 0
+      seriesLimit: 0,
+      useMultistageEngine: false,
+      trace: false,
     });
   });
 
@@ -212,6 +226,8 @@ describe('applyDefaults', () => {
       legend: '{{test_dim_column}}',
       groupByColumns: [{ name: 'test_dim_column' }],
       seriesLimit: 101,
+      useMultistageEngine: false,
+      trace: false,
     };
     expect(TimeSeriesBuilder.applyDefaults(params, { timeColumns, metricColumns })).toEqual(false);
     expect(params).toEqual<TimeSeriesBuilder.Params>({
@@ -227,6 +243,8 @@ describe('applyDefaults', () => {
       legend: '{{test_dim_column}}',
       groupByColumns: [{ name: 'test_dim_column' }],
       seriesLimit: 101,
+      useMultistageEngine: false,
+      trace: false,
     });
   });
 });
@@ -271,6 +289,8 @@ describe('dataQueryOf', () => {
         legend: '{{test_dim_column}}',
         groupByColumns: [{ name: 'test_dim_column' }],
         seriesLimit: 101,
+        useMultistageEngine: true,
+        trace: true,
       })
     ).toEqual<PinotDataQuery>({
       refId: 'test_id',
@@ -289,6 +309,8 @@ describe('dataQueryOf', () => {
       legend: '{{test_dim_column}}',
       groupByColumnsV2: [{ name: 'test_dim_column' }],
       seriesLimit: 101,
+      useMultistageEngine: true,
+      trace: true,
     });
   });
 });
@@ -16,6 +16,7 @@ import { UseResourceResult } from '../resources/UseResourceResult';
 import { useEffect, useState } from 'react';
 import { previewSqlBuilder, PreviewSqlBuilderRequest } from '../resources/previewSql';
 import { DisplayType } from '../dataquery/DisplayType';
+import { ExplainPlan, explainSqlBuilder } from '../resources/explainPlan';
 
 export interface Params {
   tableName: string;
@@ -30,6 +31,8 @@ export interface Params {
   legend: string;
   groupByColumns: ComplexField[];
   seriesLimit: number;
+  useMultistageEngine: boolean;
+  trace: boolean;
 }
 
 export interface Resources {
@@ -61,6 +64,8 @@ export function paramsFrom(query: PinotDataQuery): Params {
     legend: query.legend || '',
     groupByColumns: groupByColumnsFrom(query),
     seriesLimit: query.seriesLimit || 0,
+    useMultistageEngine: query.useMultistageEngine || false,
+    trace: query.trace || false,
   };
 }
 
@@ -140,6 +145,8 @@ export function dataQueryOf(query: PinotDataQuery, params: Params): PinotDataQue
     groupByColumns: undefined,
     groupByColumnsV2: isEmpty(params.groupByColumns) ? undefined : params.groupByColumns,
     seriesLimit: params.seriesLimit || undefined,
+    useMultistageEngine: params.useMultistageEngine || undefined,
+    trace: params.trace || undefined,
   };
 }
 
@@ -204,7 +211,26 @@ function useSqlPreview(
   const [result, setResult] = useState('');
   const [loading, setLoading] = useState(false);
 
-  const previewRequest: PreviewSqlBuilderRequest = {
+  const previewRequest = previewRequestOf(intervalSize, timeRange, interpolatedParams);
+
+  useEffect(() => {
+    setLoading(true);
+    previewSqlBuilder(datasource, previewRequest)
+      .then((val) => val && setResult(val))
+      .finally(() => setLoading(false));
+  }, [datasource, JSON.stringify(previewRequest)]); // eslint-disable-line react-hooks/exhaustive-deps
+  return { result, loading };
+}
+
+function previewRequestOf(
+  intervalSize: string | undefined,
+  timeRange: {
+    to: DateTime | undefined;
+    from: DateTime | undefined;
+  },
+  interpolatedParams: Params
+): PreviewSqlBuilderRequest {
+  return {
     intervalSize: intervalSize,
     timeRange: {
       to: timeRange.to?.endOf('second'),
@@ -221,15 +247,19 @@ function useSqlPreview(
     granularity: interpolatedParams.granularity,
     orderBy: interpolatedParams.orderBy,
     queryOptions: interpolatedParams.queryOptions,
+    useMultistageEngine: interpolatedParams.useMultistageEngine,
   };
-
-  useEffect(() => {
-    setLoading(true);
-    previewSqlBuilder(datasource, previewRequest)
-      .then((val) => val && setResult(val))
-      .finally(() => setLoading(false));
-  }, [datasource, JSON.stringify(previewRequest)]); // eslint-disable-line react-hooks/exhaustive-deps
-  return { result, loading };
 }
 
-
+// explain returns the explain plan of the query, rendered as it would be executed.
+export async function explain(
+  datasource: DataSource,
+  intervalSize: string | undefined,
+  timeRange: {
+    to: DateTime | undefined;
+    from: DateTime | undefined;
+  },
+  interpolatedParams: Params
+): Promise<ExplainPlan> {
+  return explainSqlBuilder(datasource, previewRequestOf(intervalSize, timeRange, interpolatedParams));
+}
@@ -1,5 +1,5 @@
 // ref https://docs.pinot.apache.org/users/user-guide-query/query-options
-// TODO: Is there a pinot api that provides this list?
+// Mirrors pinot.QueryOptionCatalog. The query editor loads the catalog from the datasource and uses this list as a fallback.
 
 export const PinotQueryOptions: Array<{ name: string }> = [
   { name: 'timeoutMs' },
@@ -10,6 +10,8 @@ export const PinotQueryOptions: Array<{ name: string }> = [
   { name: 'numReplicaGroupsToQuery' },
   { name: 'minSegmentGroupTrimSize' },
   { name: 'minServerGroupTrimSize' },
+  { name: 'groupTrimThreshold' },
+  { name: 'numGroupsLimit' },
   { name: 'skipIndexes' },
   { name: 'skipUpsert' },
   { name: 'useStarTree' },
@@ -9,6 +9,7 @@ describe('paramsFrom', () => {
       promQlCode: '',
       legend: '',
       seriesLimit: 0,
+      queryOptions: [],
     });
   });
 
@@ -20,12 +21,14 @@ describe('paramsFrom', () => {
         promQlCode: 'sum(rate(http_requests[15m])) by(path)',
         legend: '{{path}}',
         seriesLimit: 101,
+        queryOptions: [{ name: 'timeoutMs', value: '1000' }],
       })
     ).toEqual<Params>({
       tableName: 'test_table',
       promQlCode: 'sum(rate(http_requests[15m])) by(path)',
       legend: '{{path}}',
       seriesLimit: 101,
+      queryOptions: [{ name: 'timeoutMs', value: '1000' }],
     });
   });
 });
@@ -39,6 +42,7 @@ describe('dataQueryWithParams', () => {
         promQlCode: '',
         legend: '',
         seriesLimit: 0,
+        queryOptions: [],
       })
     ).toEqual<PinotDataQuery>({
       refId: 'test_id',
@@ -47,6 +51,7 @@ describe('dataQueryWithParams', () => {
       promQlCode: undefined,
       legend: undefined,
       seriesLimit: undefined,
+      queryOptions: undefined,
     });
   });
 
@@ -57,6 +62,7 @@ describe('dataQueryWithParams', () => {
         promQlCode: 'sum(rate(http_requests[15m])) by(path)',
         legend: '{{path}}',
         seriesLimit: 101,
+        queryOptions: [{ name: 'timeoutMs', value: '1000' }],
       })
     ).toEqual<PinotDataQuery>({
       refId: 'test_id',
@@ -65,6 +71,7 @@ describe('dataQueryWithParams', () => {
       promQlCode: 'sum(rate(http_requests[15m])) by(path)',
       legend: '{{path}}',
       seriesLimit: 101,
+      queryOptions: [{ name: 'timeoutMs', value: '1000' }],
     });
   });
 });
@@ -1,11 +1,14 @@
+import { isEmpty } from 'lodash';
 import { PinotDataQuery } from '../dataquery/PinotDataQuery';
 import { QueryType } from '../dataquery/QueryType';
+import { QueryOption } from '../dataquery/QueryOption';
 
 export interface Params {
   tableName: string;
   promQlCode: string;
   legend: string;
   seriesLimit: number;
+  queryOptions: QueryOption[];
 }
 
 export function paramsFrom(query: PinotDataQuery): Params {
@@ -14,6 +17,7 @@ export function paramsFrom(query: PinotDataQuery): Params {
     promQlCode: query.promQlCode || '',
     legend: query.legend || '',
     seriesLimit: query.seriesLimit || 0,
+    queryOptions: query.queryOptions || [],
   };
 }
 
@@ -25,5 +29,6 @@ export function dataQueryOf(query: PinotDataQuery, params: Params): PinotDataQue
     promQlCode: params.promQlCode || undefined,
     legend: params.legend || undefined,
     seriesLimit: params.seriesLimit || undefined,
+    queryOptions: isEmpty(params.queryOptions) ? undefined : params.queryOptions,
   };
 }
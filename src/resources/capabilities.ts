@@ -0,0 +1,28 @@
+import { DataSource } from '../datasource';
+import { PinotResourceResponse } from './PinotResourceResponse';
+import { useEffect, useState } from 'react';
+
+export interface Capabilities {
+  version?: string;
+  multistageEngine: boolean;
+  timeseriesApi: boolean;
+  myTablesApi: boolean;
+  databases: boolean;
+  cursors: boolean;
+  unknown?: string[];
+  detectedAt: string;
+}
+
+export function useCapabilities(datasource: DataSource): [Capabilities | undefined, boolean] {
+  const [capabilities, setCapabilities] = useState<Capabilities | undefined>();
+  const [loading, setLoading] = useState<boolean>(true);
+  useEffect(() => {
+    type CapabilitiesResponse = PinotResourceResponse<Capabilities>;
+
+    datasource
+      .getResource<CapabilitiesResponse>('capabilities')
+      .then((resp) => setCapabilities(resp.result))
+      .finally(() => setLoading(false));
+  }, [datasource]);
+  return [capabilities, loading];
+}
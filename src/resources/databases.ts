@@ -0,0 +1,33 @@
+import { DataSource } from '../datasource';
+import { useEffect, useState } from 'react';
+import { PinotResourceResponse } from './PinotResourceResponse';
+import { UseResourceResult } from './UseResourceResult';
+
+export function useDatabases(datasource: DataSource): UseResourceResult<string[]> {
+  const [result, setResult] = useState<string[]>([]);
+  const [loading, setLoading] = useState<boolean>(false);
+
+  useEffect(() => {
+    type ListDatabasesResponse = PinotResourceResponse<string[]>;
+
+    setLoading(true);
+    datasource
+      .getResource<ListDatabasesResponse>('databases')
+      .then((resp) => setResult(resp.result || []))
+      .finally(() => setLoading(false));
+  }, [datasource]);
+
+  return { result, loading };
+}
+
+// withDatabase returns a datasource whose resource requests use the database instead of the configured one.
+export function withDatabase(datasource: DataSource, database: string | undefined): DataSource {
+  if (!database) {
+    return datasource;
+  }
+  const scoped: DataSource = Object.create(datasource);
+  scoped.getResource = (path, params, options) => datasource.getResource(path, { ...params, database }, options);
+  scoped.postResource = (path, data, options) =>
+    datasource.postResource(path, data, { ...options, params: { ...options?.params, database } });
+  return scoped;
+}
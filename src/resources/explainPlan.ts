@@ -0,0 +1,28 @@
+import { DataSource } from '../datasource';
+import { PinotResourceResponse } from './PinotResourceResponse';
+import { PreviewSqlBuilderRequest, PreviewSqlCodeRequest } from './previewSql';
+
+export interface ExplainPlanNode {
+  operator: string;
+  indexType?: string;
+  children?: ExplainPlanNode[];
+}
+
+export interface ExplainPlan {
+  nodes: ExplainPlanNode[];
+  indexTypes: string[];
+}
+
+type ExplainPlanResponse = PinotResourceResponse<ExplainPlan>;
+
+export async function explainSqlBuilder(datasource: DataSource, request: PreviewSqlBuilderRequest): Promise<ExplainPlan> {
+  return datasource
+    .postResource<ExplainPlanResponse>('explain/sql/builder', request)
+    .then((resp) => resp.result || { nodes: [], indexTypes: [] });
+}
+
+export async function explainSqlCode(datasource: DataSource, request: PreviewSqlCodeRequest): Promise<ExplainPlan> {
+  return datasource
+    .postResource<ExplainPlanResponse>('explain/sql/code', request)
+    .then((resp) => resp.result || { nodes: [], indexTypes: [] });
+}
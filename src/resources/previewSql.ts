@@ -24,6 +24,7 @@ export interface PreviewSqlBuilderRequest {
   granularity: string | undefined;
   orderBy: OrderByClause[] | undefined;
   queryOptions: QueryOption[] | undefined;
+  useMultistageEngine: boolean | undefined;
   expandMacros: boolean;
 }
 
@@ -48,6 +49,7 @@ export async function previewSqlBuilder(datasource: DataSource, request: Preview
 
 export interface PreviewLogsSqlRequest {
   timeRange: { to: DateTime | undefined; from: DateTime | undefined };
+  intervalSize: string | undefined;
   tableName: string | undefined;
   timeColumn: string | undefined;
   logColumn: ComplexField | undefined;
@@ -57,6 +59,7 @@ export interface PreviewLogsSqlRequest {
   filters: DimensionFilter[] | undefined;
   queryOptions: QueryOption[] | undefined;
   limit: number | undefined;
+  useMultistageEngine: boolean | undefined;
   expandMacros: boolean | undefined;
 }
 
@@ -78,6 +81,7 @@ export interface PreviewSqlCodeRequest {
   timeColumnAlias: string | undefined;
   metricColumnAlias: string | undefined;
   code: string | undefined;
+  useMultistageEngine: boolean | undefined;
 }
 
 export async function previewSqlCode(datasource: DataSource, request: PreviewSqlCodeRequest): Promise<string> {
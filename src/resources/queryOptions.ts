@@ -0,0 +1,22 @@
+import { DataSource } from '../datasource';
+import { PinotResourceResponse } from './PinotResourceResponse';
+import { useEffect, useState } from 'react';
+import { PinotQueryOptions } from '../pinotql/pinotQueryOptions';
+
+export interface QueryOptionSpec {
+  name: string;
+  type?: 'BOOLEAN' | 'INTEGER' | 'STRING';
+  values?: string[];
+  description?: string;
+}
+
+export function useQueryOptionCatalog(datasource: DataSource): QueryOptionSpec[] {
+  const [catalog, setCatalog] = useState<QueryOptionSpec[]>(PinotQueryOptions);
+  useEffect(() => {
+    datasource
+      .getResource<PinotResourceResponse<QueryOptionSpec[]>>('queryOptions')
+      .then((resp) => resp.result && setCatalog(resp.result))
+      .catch(() => setCatalog(PinotQueryOptions));
+  }, [datasource]);
+  return catalog;
+}
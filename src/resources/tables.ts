@@ -3,22 +3,24 @@ import { useEffect, useState } from 'react';
 import { PinotResourceResponse } from './PinotResourceResponse';
 import { UseResourceResult } from './UseResourceResult';
 
-export function useTables(datasource: DataSource): UseResourceResult<string[]> {
+export function useTables(datasource: DataSource, database?: string): UseResourceResult<string[]> {
   const [result, setResult] = useState<string[]>([]);
   const [loading, setLoading] = useState<boolean>(false);
 
   useEffect(() => {
     setLoading(true);
-    listTables(datasource)
+    listTables(datasource, database)
       .then((tables) => setResult(tables))
       .finally(() => setLoading(false));
-  }, [datasource]);
+  }, [datasource, database]);
 
   return { result, loading };
 }
 
-export async function listTables(datasource: DataSource): Promise<string[]> {
+export async function listTables(datasource: DataSource, database?: string): Promise<string[]> {
   const endpoint = 'tables';
   type ListTablesResponse = PinotResourceResponse<string[]>;
-  return datasource.getResource<ListTablesResponse>(endpoint).then((resp) => resp.result || []);
+  return datasource
+    .getResource<ListTablesResponse>(endpoint, database ? { database } : undefined)
+    .then((resp) => resp.result || []);
 }